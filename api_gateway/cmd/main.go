@@ -1,19 +1,42 @@
 package main
 
 import (
+	gatewayconfig "apigateway/gateway_config"
+	kafka "apigateway/kafka_producer"
 	"apigateway/proxy"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 )
 
 func main() {
-	g, err := proxy.NewGrpcClients()
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML config file, overridden by the environment")
+	printConfig := flag.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+	flag.Parse()
+	cfg, err := gatewayconfig.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Invalid config: %v\n", err)
+	}
+	if *printConfig {
+		if err := gatewayconfig.Print(os.Stdout, cfg); err != nil {
+			log.Fatalf("Failed to print config: %v\n", err)
+		}
+		return
+	}
+
+	kafka.Init(cfg.Kafka.Brokers, cfg.Kafka.StatsTopic)
+	g, err := proxy.NewGrpcClients(cfg)
 	if err != nil {
 		log.Fatalf("Failed create grpc clients: %v\n", err)
 	}
-	r := proxy.NewRouter(g)
-	err = http.ListenAndServe(":8082", r)
+	limits, err := proxy.NewRateLimits(cfg.RateLimit)
+	if err != nil {
+		log.Fatalf("Failed to set up rate limits: %v\n", err)
+	}
+	r := proxy.NewRouter(g, limits)
+	err = http.ListenAndServe(cfg.ListenAddress, r)
 	if err != nil {
 		log.Fatalf("Failed starting server: %v\n", err)
 	}
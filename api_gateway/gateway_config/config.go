@@ -0,0 +1,135 @@
+package gatewayconfig
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	configloader "shared/config_loader"
+)
+
+// Config is the effective configuration of the API gateway. Every field can be
+// set in the YAML file under its yaml key and overridden by the environment
+// variable in its env tag. Fields tagged secret are redacted when the
+// configuration is printed.
+type Config struct {
+	ListenAddress       string    `yaml:"listen_address" env:"LISTEN_ADDRESS"`
+	AuthServiceAddress  string    `yaml:"auth_service_address" env:"AUTH_SERVICE_ADDRESS"`
+	PromoServiceAddress string    `yaml:"promo_service_address" env:"PROMO_SERVICE_ADDRESS"`
+	StatsServiceURL     string    `yaml:"stats_service_url" env:"STATS_SERVICE_URL"`
+	Kafka               Kafka     `yaml:"kafka"`
+	RateLimit           RateLimit `yaml:"rate_limit"`
+}
+
+type Kafka struct {
+	Brokers    []string `yaml:"brokers" env:"KAFKA_BROKERS"`
+	StatsTopic string   `yaml:"stats_topic" env:"KAFKA_STATS_TOPIC"`
+}
+
+// RateLimit keeps the buckets in the memory of the replica unless Backend is
+// redis. Default applies to every request, the other groups add a stricter
+// limit on top for the routes they cover.
+type RateLimit struct {
+	Backend        string   `yaml:"backend" env:"RATE_LIMIT_BACKEND"`
+	RedisAddress   string   `yaml:"redis_address" env:"RATE_LIMIT_REDIS_ADDRESS"`
+	RedisPassword  string   `yaml:"redis_password" env:"RATE_LIMIT_REDIS_PASSWORD" secret:"true"`
+	RedisDB        int      `yaml:"redis_db" env:"RATE_LIMIT_REDIS_DB"`
+	TrustedProxies []string `yaml:"trusted_proxies" env:"RATE_LIMIT_TRUSTED_PROXIES"`
+	Default        Limit    `yaml:"default" env:"RATE_LIMIT_DEFAULT_"`
+	Auth           Limit    `yaml:"auth" env:"RATE_LIMIT_AUTH_"`
+	Comments       Limit    `yaml:"comments" env:"RATE_LIMIT_COMMENTS_"`
+	Clicks         Limit    `yaml:"clicks" env:"RATE_LIMIT_CLICKS_"`
+}
+
+// Limit lets a client make PerMinute requests on average and Burst at once.
+// A zero PerMinute disables it.
+type Limit struct {
+	PerMinute int `yaml:"per_minute" env:"PER_MINUTE"`
+	Burst     int `yaml:"burst" env:"BURST"`
+}
+
+// Load starts from Default, applies the YAML file at path unless path is
+// empty, then the environment, and validates the result.
+func Load(path string) (Config, error) {
+	return configloader.Load(path, Default())
+}
+
+// Print writes the redacted configuration as YAML that Load accepts back.
+func Print(w io.Writer, c Config) error {
+	return configloader.Print(w, c)
+}
+
+// Default matches the docker-compose setup.
+func Default() Config {
+	return Config{
+		ListenAddress:       ":8082",
+		AuthServiceAddress:  "auth-service:8080",
+		PromoServiceAddress: "loyalty-service:8083",
+		StatsServiceURL:     "http://stats-service:8085",
+		Kafka: Kafka{
+			Brokers:    []string{"kafka:9092"},
+			StatsTopic: "stats",
+		},
+		RateLimit: RateLimit{
+			Backend:  "memory",
+			Default:  Limit{PerMinute: 600, Burst: 100},
+			Auth:     Limit{PerMinute: 10, Burst: 5},
+			Comments: Limit{PerMinute: 10, Burst: 5},
+			Clicks:   Limit{PerMinute: 60, Burst: 20},
+		},
+	}
+}
+
+// Validate reports every invalid value at once.
+func (c Config) Validate() error {
+	var errs []error
+	if c.ListenAddress == "" {
+		errs = append(errs, errors.New("listen_address is empty"))
+	}
+	if c.AuthServiceAddress == "" {
+		errs = append(errs, errors.New("auth_service_address is empty"))
+	}
+	if c.PromoServiceAddress == "" {
+		errs = append(errs, errors.New("promo_service_address is empty"))
+	}
+	if u, err := url.Parse(c.StatsServiceURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("stats_service_url %q is not an absolute URL", c.StatsServiceURL))
+	}
+	if len(c.Kafka.Brokers) == 0 {
+		errs = append(errs, errors.New("kafka.brokers is empty"))
+	}
+	if c.Kafka.StatsTopic == "" {
+		errs = append(errs, errors.New("kafka.stats_topic is empty"))
+	}
+
+	switch c.RateLimit.Backend {
+	case "memory":
+	case "redis":
+		if c.RateLimit.RedisAddress == "" {
+			errs = append(errs, errors.New("rate_limit.redis_address is empty"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown rate_limit.backend %q", c.RateLimit.Backend))
+	}
+	for _, cidr := range c.RateLimit.TrustedProxies {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			errs = append(errs, fmt.Errorf("rate_limit.trusted_proxies: %q is not a CIDR", cidr))
+		}
+	}
+	limits := []struct {
+		name  string
+		limit Limit
+	}{
+		{"default", c.RateLimit.Default},
+		{"auth", c.RateLimit.Auth},
+		{"comments", c.RateLimit.Comments},
+		{"clicks", c.RateLimit.Clicks},
+	}
+	for _, l := range limits {
+		if l.limit.PerMinute < 0 || (l.limit.PerMinute > 0 && l.limit.Burst < 1) {
+			errs = append(errs, fmt.Errorf("rate_limit.%s needs a non-negative per_minute and a positive burst", l.name))
+		}
+	}
+	return errors.Join(errs...)
+}
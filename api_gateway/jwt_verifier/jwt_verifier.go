@@ -0,0 +1,163 @@
+package jwtverifier
+
+import (
+	protoauth "apigateway/proto/auth"
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	Issuer = "auth-service"
+
+	keysTTL            = 5 * time.Minute
+	minRefreshInterval = 30 * time.Second
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+type Claims struct {
+	UserID                 string
+	TokenID                string
+	SessionID              string
+	IsCompany              bool
+	Role                   string
+	EmailVerified          bool
+	TwoFactorSetupRequired bool
+	OrganisationID         string
+	IssuedAt               time.Time
+	ExpiresAt              time.Time
+	Raw                    jwt.MapClaims
+}
+
+type KeyFetcher func(ctx context.Context) (*protoauth.JWKS, error)
+
+// Verifier checks RS256 tokens issued by the auth service against its JWKS.
+// Keys are cached and refetched periodically or when a token carries an
+// unknown kid, which happens right after the auth service rotates its key.
+type Verifier struct {
+	fetch     KeyFetcher
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	mx        sync.RWMutex
+}
+
+func NewVerifier(fetch KeyFetcher) *Verifier {
+	return &Verifier{fetch: fetch, keys: make(map[string]*rsa.PublicKey)}
+}
+
+func (v *Verifier) Verify(tokenRaw string) (Claims, error) {
+	token, err := jwt.Parse(tokenRaw, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodRS256 {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return v.getKey(kid)
+	})
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return Claims{}, ErrInvalidToken
+	}
+	if !mapClaims.VerifyIssuer(Issuer, true) {
+		return Claims{}, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if _, ok := mapClaims["purpose"]; ok {
+		return Claims{}, fmt.Errorf("%w: not an access token", ErrInvalidToken)
+	}
+	exp, ok := mapClaims["exp"].(float64)
+	if !ok {
+		return Claims{}, fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	claims := Claims{ExpiresAt: time.Unix(int64(exp), 0), Raw: mapClaims}
+	if iat, ok := mapClaims["iat"].(float64); ok {
+		claims.IssuedAt = time.Unix(int64(iat), 0)
+	}
+	claims.UserID, _ = mapClaims["user_id"].(string)
+	claims.TokenID, _ = mapClaims["jti"].(string)
+	claims.SessionID, _ = mapClaims["sid"].(string)
+	claims.IsCompany, _ = mapClaims["is_company"].(bool)
+	claims.Role, _ = mapClaims["role"].(string)
+	claims.EmailVerified, _ = mapClaims["email_verified"].(bool)
+	claims.TwoFactorSetupRequired, _ = mapClaims["two_factor_setup_required"].(bool)
+	claims.OrganisationID, _ = mapClaims["org_id"].(string)
+	if claims.UserID == "" || claims.TokenID == "" {
+		return Claims{}, fmt.Errorf("%w: missing user_id or jti", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+func (v *Verifier) getKey(kid string) (*rsa.PublicKey, error) {
+	v.mx.RLock()
+	key, ok := v.keys[kid]
+	stale := time.Since(v.fetchedAt) > keysTTL
+	canRefresh := time.Since(v.fetchedAt) > minRefreshInterval
+	v.mx.RUnlock()
+	if ok && !stale {
+		return key, nil
+	}
+	if !ok && !canRefresh {
+		return nil, ErrUnknownKey
+	}
+	if err := v.Refresh(); err != nil {
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+	v.mx.RLock()
+	defer v.mx.RUnlock()
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+func (v *Verifier) Refresh() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	jwks, err := v.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		key, err := parseJWK(jwk)
+		if err != nil {
+			return err
+		}
+		keys[jwk.Kid] = key
+	}
+	v.mx.Lock()
+	defer v.mx.Unlock()
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	return nil
+}
+
+func parseJWK(jwk *protoauth.JWK) (*rsa.PublicKey, error) {
+	if jwk.Kty != "RSA" {
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus of key %q: %w", jwk.Kid, err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent of key %q: %w", jwk.Kid, err)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
@@ -11,9 +11,10 @@ import (
 
 var writer *kafka.Writer
 
-func initKafka(brokerAddress string, topic string) {
+// Init must be called before SendStat, which drops the events until then.
+func Init(brokers []string, topic string) {
 	writer = &kafka.Writer{
-		Addr:     kafka.TCP(brokerAddress),
+		Addr:     kafka.TCP(brokers...),
 		Topic:    topic,
 		Balancer: &kafka.LeastBytes{},
 	}
@@ -21,7 +22,8 @@ func initKafka(brokerAddress string, topic string) {
 
 func SendStat(eventType string, userID string, objectID string) {
 	if writer == nil {
-		initKafka("kafka:9092", "stats")
+		log.Printf("Kafka producer is not initialised, dropping %s event\n", eventType)
+		return
 	}
 
 	message := map[string]interface{}{
@@ -0,0 +1,243 @@
+package proxy
+
+import (
+	jwtverifier "apigateway/jwt_verifier"
+	protoauth "apigateway/proto/auth"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	accesspolicy "shared/access_policy"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+type contextKey string
+
+const (
+	claimsContextKey       contextKey = "claims"
+	credentialsContextKey  contextKey = "credentials"
+	apiKeyScopesContextKey contextKey = "api_key_scopes"
+	clientIPContextKey     contextKey = "client_ip"
+	verifiedContextKey     contextKey = "verified_claims"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+const bearerPrefix = "Bearer "
+
+var errMissingCredentials = errors.New("missing credentials")
+
+// jwtFromRequest takes the token from the "Authorization: Bearer" header used
+// by API clients and falls back to the Authorization cookie set for browsers.
+func jwtFromRequest(r *http.Request) (string, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if len(header) <= len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+			return "", errors.New("unsupported authorization scheme")
+		}
+		return strings.TrimSpace(header[len(bearerPrefix):]), nil
+	}
+	cookie, err := r.Cookie("Authorization")
+	if err != nil || cookie.Value == "" {
+		return "", errMissingCredentials
+	}
+	return cookie.Value, nil
+}
+
+// verifyJWT checks the signature of the JWT once per request, for the rate
+// limiter and authenticate. Requests without a valid JWT pass unchanged.
+func (g *GrpcClients) verifyJWT(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if jwt, err := jwtFromRequest(r); err == nil {
+			if claims, err := g.verifier.Verify(jwt); err == nil {
+				r = r.WithContext(context.WithValue(r.Context(), verifiedContextKey, claims))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func verifiedClaims(ctx context.Context) (jwtverifier.Claims, bool) {
+	claims, ok := ctx.Value(verifiedContextKey).(jwtverifier.Claims)
+	return claims, ok
+}
+
+// authMiddleware accepts a JWT or an API key. A request made with an API key
+// acts as the company owning it, limited to the scopes of the key, so every
+// route behind it must check its permission with requirePermission.
+func (g *GrpcClients) authMiddleware(next http.Handler) http.Handler {
+	return g.authenticate(next, true)
+}
+
+// jwtAuthMiddleware is authMiddleware for routes without a permission to
+// scope API keys to, it rejects them.
+func (g *GrpcClients) jwtAuthMiddleware(next http.Handler) http.Handler {
+	return g.authenticate(next, false)
+}
+
+func (g *GrpcClients) authenticate(next http.Handler, acceptAPIKeys bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(apiKeyHeader); key != "" {
+			if !acceptAPIKeys {
+				http.Error(w, "Forbidden: API keys are not accepted here", http.StatusForbidden)
+				return
+			}
+			g.serveWithAPIKey(w, r, next, key)
+			return
+		}
+		jwt, err := jwtFromRequest(r)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+			return
+		}
+		claims, ok := verifiedClaims(r.Context())
+		if !ok {
+			// Verified again only to tell why the token is rejected.
+			if claims, err = g.verifier.Verify(jwt); err != nil {
+				http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+				return
+			}
+		}
+		introspection, err := g.introspect(jwt, claims)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Unauthorized: %v", err), grpcErrorToHTTP(err))
+			return
+		}
+		switch {
+		case introspection.Suspended:
+			http.Error(w, "Forbidden: the account is suspended", http.StatusForbidden)
+			return
+		case !introspection.Active:
+			http.Error(w, "Unauthorized: the token is no longer valid", http.StatusUnauthorized)
+			return
+		}
+		// The role and flags in the token are as old as the token.
+		claims.Role = introspection.Role
+		claims.IsCompany = introspection.IsCompany
+		claims.EmailVerified = introspection.EmailVerified
+		claims.TwoFactorSetupRequired = introspection.TwoFactorSetupRequired
+		claims.OrganisationID = introspection.OrganisationId
+		ctx := context.WithValue(r.Context(), claimsContextKey, claims)
+		ctx = context.WithValue(ctx, credentialsContextKey, accesspolicy.Credentials{JWT: jwt})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// introspect makes logouts, revoked sessions, new token generations and
+// suspensions take effect before the access token expires. Answers are kept by
+// token ID for the cache_ttl_seconds the auth service gives, so most requests
+// make no call to it.
+func (g *GrpcClients) introspect(jwt string, claims jwtverifier.Claims) (*protoauth.TokenIntrospection, error) {
+	now := time.Now()
+	introspection, generation, ok := g.introspections.Get(claims.TokenID, now)
+	if ok {
+		return introspection, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	introspection, err := g.authClient.IntrospectToken(ctx, &protoauth.IntrospectTokenRequest{Jwt: jwt})
+	if err != nil {
+		return nil, err
+	}
+	g.introspections.Put(generation, claims.TokenID, introspection, time.Duration(introspection.CacheTtlSeconds)*time.Second, now)
+	return introspection, nil
+}
+
+// forgetIntrospections runs after requests that can revoke tokens or change the
+// role and flags of a user, the caller or the one in the {id} URL parameter,
+// and drops the cached answers about them so the change is seen at once.
+func (g *GrpcClients) forgetIntrospections(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		claims, _ := verifiedClaims(r.Context())
+		target := chi.URLParam(r, "id")
+		g.introspections.Forget(func(_ string, introspection *protoauth.TokenIntrospection) bool {
+			user := introspection.UserId
+			return user != "" && (user == claims.UserID || user == target)
+		})
+	})
+}
+
+func (g *GrpcClients) serveWithAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	principal, err := g.authClient.AuthenticateAPIKey(ctx, &protoauth.AuthenticateAPIKeyRequest{Key: key})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), grpcErrorToHTTP(err))
+		return
+	}
+	claims := jwtverifier.Claims{
+		UserID:         principal.UserId,
+		TokenID:        principal.KeyId,
+		IsCompany:      accesspolicy.IsCompanyRole(accesspolicy.Role(principal.Role)),
+		Role:           principal.Role,
+		EmailVerified:  principal.EmailVerified,
+		OrganisationID: principal.OrganisationId,
+	}
+	scopes := make([]accesspolicy.Permission, 0, len(principal.Scopes))
+	for _, scope := range principal.Scopes {
+		scopes = append(scopes, accesspolicy.Permission(scope))
+	}
+	reqCtx := context.WithValue(r.Context(), claimsContextKey, claims)
+	reqCtx = context.WithValue(reqCtx, credentialsContextKey, accesspolicy.Credentials{APIKey: key})
+	reqCtx = context.WithValue(reqCtx, apiKeyScopesContextKey, scopes)
+	next.ServeHTTP(w, r.WithContext(reqCtx))
+}
+
+// requirePermission lets the request through if the role of the caller has
+// any of the permissions and, for API keys, the key is scoped to it. It must
+// run after authMiddleware.
+func requirePermission(permissions ...accesspolicy.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, _ := ClaimsFromContext(r.Context())
+			if !accesspolicy.AllowsAny(accesspolicy.Role(claims.Role), permissions...) {
+				http.Error(w, fmt.Sprintf("Forbidden: role %q is not allowed to do this", claims.Role), http.StatusForbidden)
+				return
+			}
+			if scopes, ok := r.Context().Value(apiKeyScopesContextKey).([]accesspolicy.Permission); ok &&
+				!slices.ContainsFunc(permissions, func(p accesspolicy.Permission) bool { return slices.Contains(scopes, p) }) {
+				http.Error(w, "Forbidden: the API key is not scoped for this", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// promoContext forwards the credentials of the caller to the loyalty service,
+// which authenticates them again and applies the same access policy.
+func promoContext(r *http.Request) (context.Context, context.CancelFunc) {
+	credentials, _ := r.Context().Value(credentialsContextKey).(accesspolicy.Credentials)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	return accesspolicy.AppendCredentials(ctx, credentials), cancel
+}
+
+func ClaimsFromContext(ctx context.Context) (jwtverifier.Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey).(jwtverifier.Claims)
+	return claims, ok
+}
+
+func UserIDFromContext(ctx context.Context) string {
+	claims, _ := ClaimsFromContext(ctx)
+	return claims.UserID
+}
+
+// companyChangeForbidden rejects promo changes from company sessions that have
+// not verified the email or not set up two-factor authentication required by
+// the auth service policy.
+func companyChangeForbidden(w http.ResponseWriter, r *http.Request) bool {
+	claims, _ := ClaimsFromContext(r.Context())
+	switch {
+	case accesspolicy.IsCompanyRole(accesspolicy.Role(claims.Role)) && !claims.EmailVerified:
+		http.Error(w, "Forbidden: verify your email before publishing promos", http.StatusForbidden)
+	case claims.TwoFactorSetupRequired:
+		http.Error(w, "Forbidden: set up two-factor authentication before publishing promos", http.StatusForbidden)
+	default:
+		return false
+	}
+	return true
+}
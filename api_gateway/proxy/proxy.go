@@ -1,123 +1,1098 @@
 package proxy
 
 import (
+	gatewayconfig "apigateway/gateway_config"
+	jwtverifier "apigateway/jwt_verifier"
 	kafka "apigateway/kafka_producer"
 	protoauth "apigateway/proto/auth"
 	protopromo "apigateway/proto/promo"
-	"bytes"
+	ratelimiter "apigateway/rate_limiter"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"log"
+	"math"
+	"net"
 	"net/http"
+	accesspolicy "shared/access_policy"
+	ttlcache "shared/ttl_cache"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
-const targetBase = "http://nginx:8081"
+const (
+	clientIPMetadataKey  = "x-client-ip"
+	userAgentMetadataKey = "x-user-agent"
+)
+
+func grpcErrorToHTTP(err error) int {
+	st, ok := status.FromError(err)
+	if !ok {
+		return http.StatusInternalServerError
+	}
+
+	var httpStatus int
+
+	switch st.Code() {
+	case codes.InvalidArgument:
+		httpStatus = http.StatusBadRequest
+	case codes.NotFound:
+		httpStatus = http.StatusNotFound
+	case codes.AlreadyExists:
+		httpStatus = http.StatusConflict
+	case codes.PermissionDenied:
+		httpStatus = http.StatusForbidden
+	case codes.FailedPrecondition:
+		httpStatus = http.StatusConflict
+	case codes.Unauthenticated:
+		httpStatus = http.StatusUnauthorized
+	case codes.Unavailable:
+		httpStatus = http.StatusServiceUnavailable
+	case codes.ResourceExhausted:
+		httpStatus = http.StatusTooManyRequests
+	case codes.Unimplemented:
+		httpStatus = http.StatusNotImplemented
+	case codes.Internal:
+		httpStatus = http.StatusInternalServerError
+	default:
+		httpStatus = http.StatusInternalServerError
+	}
+
+	return httpStatus
+}
+
+// setRetryAfter copies the RetryInfo detail of a gRPC error into the
+// Retry-After header, rounded up to whole seconds.
+func setRetryAfter(w http.ResponseWriter, err error) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return
+	}
+	for _, detail := range st.Details() {
+		if retryInfo, ok := detail.(*errdetails.RetryInfo); ok {
+			seconds := math.Ceil(retryInfo.GetRetryDelay().AsDuration().Seconds())
+			w.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+			return
+		}
+	}
+}
+
+type fieldViolation struct {
+	Field       string `json:"field"`
+	Reason      string `json:"reason"`
+	Description string `json:"description"`
+}
+
+type validationErrorResponse struct {
+	Error           string           `json:"error"`
+	FieldViolations []fieldViolation `json:"field_violations"`
+}
+
+// writeGRPCError answers with the status of a failed call. Errors carrying
+// BadRequest details are returned as JSON, so the frontend can point at the
+// invalid inputs.
+func writeGRPCError(w http.ResponseWriter, err error) {
+	st, ok := status.FromError(err)
+	if ok {
+		for _, detail := range st.Details() {
+			badRequest, ok := detail.(*errdetails.BadRequest)
+			if !ok {
+				continue
+			}
+			resp := validationErrorResponse{Error: st.Message()}
+			for _, violation := range badRequest.GetFieldViolations() {
+				resp.FieldViolations = append(resp.FieldViolations, fieldViolation{
+					Field:       violation.GetField(),
+					Reason:      violation.GetReason(),
+					Description: violation.GetDescription(),
+				})
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.WriteHeader(grpcErrorToHTTP(err))
+			if err := json.NewEncoder(w).Encode(resp); err != nil {
+				log.Printf("Failed to encode validation error: %v\n", err)
+			}
+			return
+		}
+	}
+	http.Error(w, err.Error(), grpcErrorToHTTP(err))
+}
+
+// clientIPMiddleware resolves the client IP once for the rate limiter and the
+// auth service, so both count attempts against the same address.
+func clientIPMiddleware(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), clientIPContextKey, ratelimiter.ClientIP(r, trustedProxies))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// clientIP is the address resolved by clientIPMiddleware. Forwarded headers
+// are only trusted from trusted proxies, they would let a client pick its own
+// lockout counter.
+func clientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPContextKey).(string); ok {
+		return ip
+	}
+	return ratelimiter.ClientIP(r, nil)
+}
+
+// withClientMetadata describes the client to the auth service, which records
+// it in the session started by a login and in the audit log.
+func withClientMetadata(ctx context.Context, r *http.Request) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, clientIPMetadataKey, clientIP(r), userAgentMetadataKey, r.UserAgent())
+}
+
+type GrpcClients struct {
+	authClient      protoauth.AuthServiceClient
+	promoClient     protopromo.PromoServiceClient
+	verifier        *jwtverifier.Verifier
+	introspections  *ttlcache.Cache[*protoauth.TokenIntrospection]
+	statsServiceURL string
+}
+
+const maxIntrospectionCacheSize = 100000
+
+func NewGrpcClients(cfg gatewayconfig.Config) (*GrpcClients, error) {
+	connAuth, err := grpc.Dial(cfg.AuthServiceAddress, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to auth service: %v", err)
+	}
+	connPromo, err := grpc.Dial(cfg.PromoServiceAddress, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to promo service: %v", err)
+	}
+	authClient := protoauth.NewAuthServiceClient(connAuth)
+	verifier := jwtverifier.NewVerifier(func(ctx context.Context) (*protoauth.JWKS, error) {
+		return authClient.GetJWKS(ctx, &protoauth.JWKSRequest{})
+	})
+	if err := verifier.Refresh(); err != nil {
+		log.Printf("Failed to prefetch JWKS, will retry on first request: %v\n", err)
+	}
+	return &GrpcClients{authClient: authClient, promoClient: protopromo.NewPromoServiceClient(connPromo), verifier: verifier,
+		introspections: ttlcache.New[*protoauth.TokenIntrospection](maxIntrospectionCacheSize), statsServiceURL: cfg.StatsServiceURL}, nil
+}
+
+func (g *GrpcClients) registerUserHandler(w http.ResponseWriter, r *http.Request) {
+	var userCreds protoauth.UserCreds
+	if err := json.NewDecoder(r.Body).Decode(&userCreds); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	ctx = withClientMetadata(ctx, r)
+	user, err := g.authClient.Register(ctx, &userCreds)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+
+	kafka.SendStat("user_registered", user.Id, user.Login)
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (g *GrpcClients) loginUserHandler(w http.ResponseWriter, r *http.Request) {
+	var userCreds protoauth.UserCreds
+	if err := json.NewDecoder(r.Body).Decode(&userCreds); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	ctx = withClientMetadata(ctx, r)
+	loginResponse, err := g.authClient.Login(ctx, &userCreds)
+	if err != nil {
+		setRetryAfter(w, err)
+		writeGRPCError(w, err)
+		return
+	}
+	if loginResponse.TwoFactorChallenge != "" {
+		writeTwoFactorChallenge(w, loginResponse.TwoFactorChallenge)
+		return
+	}
+
+	writeTokens(w, r, loginResponse)
+}
+
+type twoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	Challenge         string `json:"challenge"`
+}
+
+// writeTwoFactorChallenge answers a correct password of an account with 2FA.
+// The client finishes the login at /api/v1/login/two_factor.
+func writeTwoFactorChallenge(w http.ResponseWriter, challenge string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusAccepted)
+	err := json.NewEncoder(w).Encode(twoFactorChallengeResponse{TwoFactorRequired: true, Challenge: challenge})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func (g *GrpcClients) loginTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	var twoFactorLoginRequest protoauth.TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&twoFactorLoginRequest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	ctx = withClientMetadata(ctx, r)
+
+	loginResponse, err := g.authClient.LoginTwoFactor(ctx, &twoFactorLoginRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+
+	writeTokens(w, r, loginResponse)
+}
+
+type tokenResponse struct {
+	AccessToken            string `json:"access_token"`
+	TokenType              string `json:"token_type"`
+	ExpiresAt              string `json:"expires_at"`
+	RefreshToken           string `json:"refresh_token"`
+	RefreshTokenExpiresAt  string `json:"refresh_token_expires_at"`
+	TwoFactorSetupRequired bool   `json:"two_factor_setup_required,omitempty"`
+}
+
+// writeTokens sets the token cookies and, when the client asks for it with
+// ?return_token=true, also returns the tokens in the body for clients that
+// send them as "Authorization: Bearer" headers.
+func writeTokens(w http.ResponseWriter, r *http.Request, loginResponse *protoauth.LoginResponse) {
+	setTokenCookies(w, loginResponse)
+	if r.URL.Query().Get("return_token") != "true" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	err := json.NewEncoder(w).Encode(tokenResponse{
+		AccessToken:            loginResponse.Jwt,
+		TokenType:              "Bearer",
+		ExpiresAt:              loginResponse.JwtExpiresAt,
+		RefreshToken:           loginResponse.RefreshToken,
+		RefreshTokenExpiresAt:  loginResponse.RefreshTokenExpiresAt,
+		TwoFactorSetupRequired: loginResponse.TwoFactorSetupRequired,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// refreshTokenFromRequest reads the refresh token cookie or, for bearer
+// clients, the refresh_token field of the JSON body.
+func refreshTokenFromRequest(r *http.Request) string {
+	if cookie, err := r.Cookie("RefreshToken"); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	var req protoauth.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return ""
+	}
+	return req.RefreshToken
+}
+
+func parseExpiration(raw string) time.Time {
+	expires, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}
+	}
+	return expires
+}
+
+func setTokenCookies(w http.ResponseWriter, loginResponse *protoauth.LoginResponse) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "Authorization",
+		Value:    loginResponse.Jwt,
+		HttpOnly: true,
+		Secure:   true,
+		Path:     "/",
+		Expires:  parseExpiration(loginResponse.JwtExpiresAt),
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     "RefreshToken",
+		Value:    loginResponse.RefreshToken,
+		HttpOnly: true,
+		Secure:   true,
+		Path:     "/api/v1/",
+		Expires:  parseExpiration(loginResponse.RefreshTokenExpiresAt),
+	})
+}
+
+func clearTokenCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "Authorization",
+		Value:    "",
+		HttpOnly: true,
+		Secure:   true,
+		Path:     "/",
+		MaxAge:   -1,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     "RefreshToken",
+		Value:    "",
+		HttpOnly: true,
+		Secure:   true,
+		Path:     "/api/v1/",
+		MaxAge:   -1,
+	})
+}
+
+func (g *GrpcClients) refreshHandler(w http.ResponseWriter, r *http.Request) {
+	refreshToken := refreshTokenFromRequest(r)
+	if refreshToken == "" {
+		http.Error(w, "Unauthorized: missing refresh token", http.StatusUnauthorized)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	ctx = withClientMetadata(ctx, r)
+
+	loginResponse, err := g.authClient.Refresh(ctx, &protoauth.RefreshRequest{RefreshToken: refreshToken})
+	if err != nil {
+		clearTokenCookies(w)
+		writeGRPCError(w, err)
+		return
+	}
+
+	writeTokens(w, r, loginResponse)
+}
+
+func (g *GrpcClients) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	logoutRequest := protoauth.LogoutRequest{Jwt: jwt, RefreshToken: refreshTokenFromRequest(r)}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	_, err = g.authClient.Logout(ctx, &logoutRequest)
+	clearTokenCookies(w)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (g *GrpcClients) getProfileHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	user, err := g.authClient.GetProfile(ctx, &protoauth.AuthRequest{Jwt: jwt})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(user)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (g *GrpcClients) updateProfileHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	updateProfileRequest := protoauth.UpdateProfileRequest{NewInfo: &protoauth.User{}}
+	if err := json.NewDecoder(r.Body).Decode(updateProfileRequest.NewInfo); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	updateProfileRequest.Jwt = jwt
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	ctx = withClientMetadata(ctx, r)
+
+	user, err := g.authClient.UpdateProfile(ctx, &updateProfileRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(user)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (g *GrpcClients) getUserInfoHandler(w http.ResponseWriter, r *http.Request) {
+	userIdRaw := chi.URLParam(r, "id")
+	userId, err := uuid.Parse(userIdRaw)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Bad request: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	user, err := g.authClient.GetUserById(ctx, &protoauth.UserIdRequest{Id: userId.String()})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(user)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (g *GrpcClients) verifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "Bad request: missing token", http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	user, err := g.authClient.VerifyEmail(ctx, &protoauth.VerifyEmailRequest{Token: token})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(user)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (g *GrpcClients) resendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	_, err = g.authClient.ResendVerification(ctx, &protoauth.AuthRequest{Jwt: jwt})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (g *GrpcClients) requestPhoneVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	_, err = g.authClient.RequestPhoneVerification(ctx, &protoauth.AuthRequest{Jwt: jwt})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (g *GrpcClients) confirmPhoneNumberHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	var confirmPhoneNumberRequest protoauth.ConfirmPhoneNumberRequest
+	if err := json.NewDecoder(r.Body).Decode(&confirmPhoneNumberRequest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	confirmPhoneNumberRequest.Jwt = jwt
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	user, err := g.authClient.ConfirmPhoneNumber(ctx, &confirmPhoneNumberRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(user)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (g *GrpcClients) changePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	var changePasswordRequest protoauth.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&changePasswordRequest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	changePasswordRequest.Jwt = jwt
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	loginResponse, err := g.authClient.ChangePassword(ctx, &changePasswordRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+
+	writeTokens(w, r, loginResponse)
+}
+
+func (g *GrpcClients) requestPasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	var passwordResetRequest protoauth.PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&passwordResetRequest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	_, err := g.authClient.RequestPasswordReset(ctx, &passwordResetRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (g *GrpcClients) resetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var resetPasswordRequest protoauth.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&resetPasswordRequest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	// The link in the reset email carries the token in the query.
+	if resetPasswordRequest.Token == "" {
+		resetPasswordRequest.Token = r.URL.Query().Get("token")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	_, err := g.authClient.ResetPassword(ctx, &resetPasswordRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	clearTokenCookies(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type deleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+func (g *GrpcClients) deleteAccountHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	var req deleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	_, err = g.authClient.DeleteAccount(ctx, &protoauth.DeleteAccountRequest{Jwt: jwt, Password: req.Password})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	clearTokenCookies(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type accountArchive struct {
+	Account     *protoauth.AccountExport `json:"account"`
+	Promos      []*protopromo.Promo      `json:"promos"`
+	Comments    []*protopromo.Comment    `json:"comments"`
+	StatsEvents []json.RawMessage        `json:"stats_events"`
+}
+
+func (g *GrpcClients) exportMyDataHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	ctx, cancel := promoContext(r)
+	defer cancel()
+
+	account, err := g.authClient.ExportMyData(ctx, &protoauth.AuthRequest{Jwt: jwt})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	authorData, err := g.promoClient.ExportAuthorData(ctx, &protopromo.ExportAuthorDataRequest{AuthorId: account.User.Id})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	statsEvents, err := g.fetchUserStats(ctx, jwt, account.User.Id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get stats: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="account-export.json"`)
+	w.Header().Set("Cache-Control", "no-store")
+	err = json.NewEncoder(w).Encode(accountArchive{
+		Account:     account,
+		Promos:      authorData.Promos,
+		Comments:    authorData.Comments,
+		StatsEvents: statsEvents,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// fetchUserStats reads the statistics of the user as that user, the stats
+// service introspects the JWT.
+func (g *GrpcClients) fetchUserStats(ctx context.Context, jwt, userID string) ([]json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.statsServiceURL+"/api/v1/stats/user/"+userID, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", bearerPrefix+jwt)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("stats service returned %s", resp.Status)
+	}
+	var events []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (g *GrpcClients) enrollTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	enrollment, err := g.authClient.EnrollTwoFactor(ctx, &protoauth.AuthRequest{Jwt: jwt})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	err = json.NewEncoder(w).Encode(enrollment)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (g *GrpcClients) confirmTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	var twoFactorCodeRequest protoauth.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&twoFactorCodeRequest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	twoFactorCodeRequest.Jwt = jwt
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	ctx = withClientMetadata(ctx, r)
+
+	confirmation, err := g.authClient.ConfirmTwoFactor(ctx, &twoFactorCodeRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+
+	setTokenCookies(w, confirmation.Tokens)
+	w.Header().Set("Cache-Control", "no-store")
+	err = json.NewEncoder(w).Encode(map[string][]string{"recovery_codes": confirmation.RecoveryCodes})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (g *GrpcClients) disableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	var twoFactorCodeRequest protoauth.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&twoFactorCodeRequest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	twoFactorCodeRequest.Jwt = jwt
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	ctx = withClientMetadata(ctx, r)
+
+	_, err = g.authClient.DisableTwoFactor(ctx, &twoFactorCodeRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (g *GrpcClients) createAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	var createAPIKeyRequest protoauth.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&createAPIKeyRequest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	createAPIKeyRequest.Jwt = jwt
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	resp, err := g.authClient.CreateAPIKey(ctx, &createAPIKeyRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func (g *GrpcClients) listAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	resp, err := g.authClient.ListAPIKeys(ctx, &protoauth.AuthRequest{Jwt: jwt})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func (g *GrpcClients) revokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	_, err = g.authClient.RevokeAPIKey(ctx, &protoauth.RevokeAPIKeyRequest{Jwt: jwt, KeyId: chi.URLParam(r, "id")})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (g *GrpcClients) listSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	resp, err := g.authClient.ListSessions(ctx, &protoauth.AuthRequest{Jwt: jwt})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func (g *GrpcClients) revokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	_, err = g.authClient.RevokeSession(ctx, &protoauth.RevokeSessionRequest{Jwt: jwt, SessionId: chi.URLParam(r, "id")})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (g *GrpcClients) listAuditEventsHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil && query.Get("limit") != "" {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	resp, err := g.authClient.ListAuditEvents(ctx, &protoauth.ListAuditEventsRequest{
+		Jwt:    jwt,
+		UserId: query.Get("user_id"),
+		From:   query.Get("from"),
+		To:     query.Get("to"),
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+	}
+}
 
-const promoServiceAddress = "loyalty-service:8083"
-const authServiceAddress = "auth-service:8080"
+func (g *GrpcClients) setUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	var setUserRoleRequest protoauth.SetUserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&setUserRoleRequest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	setUserRoleRequest.Jwt = jwt
+	setUserRoleRequest.UserId = chi.URLParam(r, "id")
 
-func grpcErrorToHTTP(err error) int {
-	st, ok := status.FromError(err)
-	if !ok {
-		return http.StatusInternalServerError
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	user, err := g.authClient.SetUserRole(ctx, &setUserRoleRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
 	}
 
-	var httpStatus int
+	err = json.NewEncoder(w).Encode(user)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
 
-	switch st.Code() {
-	case codes.InvalidArgument:
-		httpStatus = http.StatusBadRequest
-	case codes.NotFound:
-		httpStatus = http.StatusNotFound
-	case codes.AlreadyExists:
-		httpStatus = http.StatusConflict
-	case codes.PermissionDenied:
-		httpStatus = http.StatusForbidden
-	case codes.Unauthenticated:
-		httpStatus = http.StatusUnauthorized
-	case codes.Unavailable:
-		httpStatus = http.StatusServiceUnavailable
-	case codes.Internal:
-		httpStatus = http.StatusInternalServerError
-	default:
-		httpStatus = http.StatusInternalServerError
+func (g *GrpcClients) searchUsersHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	query := r.URL.Query()
+	searchUsersRequest := protoauth.SearchUsersRequest{
+		Jwt:         jwt,
+		Login:       query.Get("login"),
+		Email:       query.Get("email"),
+		CreatedFrom: query.Get("created_from"),
+		CreatedTo:   query.Get("created_to"),
+	}
+	offset, err := strconv.Atoi(query.Get("offset"))
+	if err != nil && query.Get("offset") != "" {
+		http.Error(w, "Invalid offset", http.StatusBadRequest)
+		return
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil && query.Get("limit") != "" {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+	searchUsersRequest.Offset, searchUsersRequest.Limit = int32(offset), int32(limit)
+	if query.Get("is_company") != "" {
+		isCompany, err := strconv.ParseBool(query.Get("is_company"))
+		if err != nil {
+			http.Error(w, "Invalid is_company", http.StatusBadRequest)
+			return
+		}
+		searchUsersRequest.IsCompany = &isCompany
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
 
-	return httpStatus
+	resp, err := g.authClient.SearchUsers(ctx, &searchUsersRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+	}
 }
 
-type GrpcClients struct {
-	authClient  protoauth.AuthServiceClient
-	promoClient protopromo.PromoServiceClient
+func (g *GrpcClients) adminGetUserHandler(w http.ResponseWriter, r *http.Request) {
+	g.adminUserAction(w, r, g.authClient.AdminGetUser)
+}
+
+func (g *GrpcClients) suspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	g.adminUserAction(w, r, g.authClient.SuspendUser)
 }
 
-func NewGrpcClients() (*GrpcClients, error) {
-	connAuth, err := grpc.Dial(authServiceAddress, grpc.WithInsecure(), grpc.WithBlock())
+func (g *GrpcClients) unsuspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	g.adminUserAction(w, r, g.authClient.UnsuspendUser)
+}
+
+// adminUserAction calls an admin RPC taking the user in the URL and returns
+// the updated user.
+func (g *GrpcClients) adminUserAction(w http.ResponseWriter, r *http.Request,
+	call func(ctx context.Context, in *protoauth.AdminUserRequest, opts ...grpc.CallOption) (*protoauth.User, error)) {
+	jwt, err := jwtFromRequest(r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to auth service: %v", err)
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
 	}
-	connPromo, err := grpc.Dial(promoServiceAddress, grpc.WithInsecure(), grpc.WithBlock())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	user, err := call(ctx, &protoauth.AdminUserRequest{Jwt: jwt, UserId: chi.URLParam(r, "id")})
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to promo service: %v", err)
+		writeGRPCError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
 	}
-	return &GrpcClients{authClient: protoauth.NewAuthServiceClient(connAuth), promoClient: protopromo.NewPromoServiceClient(connPromo)}, nil
 }
 
-func (g *GrpcClients) registerUserHandler(w http.ResponseWriter, r *http.Request) {
-	var userCreds protoauth.UserCreds
-	if err := json.NewDecoder(r.Body).Decode(&userCreds); err != nil {
+func (g *GrpcClients) adminUpdateUserHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	adminUpdateUserRequest := protoauth.AdminUpdateUserRequest{NewInfo: &protoauth.User{}}
+	if err := json.NewDecoder(r.Body).Decode(adminUpdateUserRequest.NewInfo); err != nil {
 		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
 		return
 	}
+	adminUpdateUserRequest.Jwt = jwt
+	adminUpdateUserRequest.UserId = chi.URLParam(r, "id")
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
-	user, err := g.authClient.Register(ctx, &userCreds)
+
+	user, err := g.authClient.AdminUpdateUser(ctx, &adminUpdateUserRequest)
 	if err != nil {
-		http.Error(w, err.Error(), grpcErrorToHTTP(err))
+		writeGRPCError(w, err)
 		return
 	}
-
-	kafka.SendStat("user_registered", user.Id, user.Login)
-
-	w.WriteHeader(http.StatusCreated)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+	}
 }
 
-func (g *GrpcClients) loginUserHandler(w http.ResponseWriter, r *http.Request) {
-	var userCreds protoauth.UserCreds
-	if err := json.NewDecoder(r.Body).Decode(&userCreds); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+func (g *GrpcClients) forceLogoutHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
-	loginResponse, err := g.authClient.Login(ctx, &userCreds)
+
+	_, err = g.authClient.ForceLogout(ctx, &protoauth.AdminUserRequest{Jwt: jwt, UserId: chi.URLParam(r, "id")})
 	if err != nil {
-		http.Error(w, err.Error(), grpcErrorToHTTP(err))
+		writeGRPCError(w, err)
 		return
 	}
-
-	http.SetCookie(w, &http.Cookie{
-		Name:     "Authorization",
-		Value:    loginResponse.Jwt,
-		HttpOnly: true,
-		Secure:   true,
-		Path:     "/",
-		Expires:  time.Now().Add(72 * time.Hour),
-	})
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (g *GrpcClients) getProfileHandler(w http.ResponseWriter, r *http.Request) {
-	jwt, err := r.Cookie("Authorization")
+func (g *GrpcClients) getOrganisationHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
 		return
@@ -125,142 +1100,210 @@ func (g *GrpcClients) getProfileHandler(w http.ResponseWriter, r *http.Request)
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
-	user, err := g.authClient.GetProfile(ctx, &protoauth.AuthRequest{Jwt: jwt.Value})
+	organisation, err := g.authClient.GetOrganisation(ctx, &protoauth.AuthRequest{Jwt: jwt})
 	if err != nil {
-		http.Error(w, err.Error(), grpcErrorToHTTP(err))
+		writeGRPCError(w, err)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(organisation); err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+	}
+}
 
-	err = json.NewEncoder(w).Encode(user)
+func (g *GrpcClients) renameOrganisationHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusBadRequest)
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
+	var renameOrganisationRequest protoauth.RenameOrganisationRequest
+	if err := json.NewDecoder(r.Body).Decode(&renameOrganisationRequest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	renameOrganisationRequest.Jwt = jwt
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	organisation, err := g.authClient.RenameOrganisation(ctx, &renameOrganisationRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(organisation); err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+	}
 }
 
-func (g *GrpcClients) updateProfileHandler(w http.ResponseWriter, r *http.Request) {
-	jwt, err := r.Cookie("Authorization")
+func (g *GrpcClients) inviteMemberHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
 		return
 	}
-	updateProfileRequest := protoauth.UpdateProfileRequest{NewInfo: &protoauth.User{}}
-	if err := json.NewDecoder(r.Body).Decode(updateProfileRequest.NewInfo); err != nil {
+	var inviteMemberRequest protoauth.InviteMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&inviteMemberRequest); err != nil {
 		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
 		return
 	}
-	updateProfileRequest.Jwt = jwt.Value
+	inviteMemberRequest.Jwt = jwt
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
-	user, err := g.authClient.UpdateProfile(ctx, &updateProfileRequest)
+	invitation, err := g.authClient.InviteMember(ctx, &inviteMemberRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(invitation); err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func (g *GrpcClients) cancelInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), grpcErrorToHTTP(err))
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
 		return
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
 
-	err = json.NewEncoder(w).Encode(user)
+	_, err = g.authClient.CancelInvitation(ctx, &protoauth.CancelInvitationRequest{Jwt: jwt, InvitationId: chi.URLParam(r, "id")})
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusBadRequest)
+		writeGRPCError(w, err)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (g *GrpcClients) getUserInfoHandler(w http.ResponseWriter, r *http.Request) {
-	userIdRaw := chi.URLParam(r, "id")
-	userId, err := uuid.Parse(userIdRaw)
+// acceptInvitationHandler returns new tokens, the ones used for the call are
+// revoked once the account joins the organisation.
+func (g *GrpcClients) acceptInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Bad request: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	var acceptInvitationRequest protoauth.AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&acceptInvitationRequest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
 		return
 	}
+	acceptInvitationRequest.Jwt = jwt
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
-	user, err := g.authClient.GetUserById(ctx, &protoauth.UserIdRequest{Id: userId.String()})
+	loginResponse, err := g.authClient.AcceptInvitation(withClientMetadata(ctx, r), &acceptInvitationRequest)
 	if err != nil {
-		http.Error(w, err.Error(), grpcErrorToHTTP(err))
+		writeGRPCError(w, err)
 		return
 	}
 
-	err = json.NewEncoder(w).Encode(user)
+	writeTokens(w, r, loginResponse)
+}
+
+func (g *GrpcClients) setMemberRoleHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusBadRequest)
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
-}
-
-func (g *GrpcClients) getUserID(r *http.Request) (string, error) {
-	jwtToken := ""
-	cookie, err := r.Cookie("Authorization")
-	if err == nil {
-		jwtToken = cookie.Value
+	var setMemberRoleRequest protoauth.SetMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&setMemberRoleRequest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
 	}
+	setMemberRoleRequest.Jwt = jwt
+	setMemberRoleRequest.UserId = chi.URLParam(r, "id")
 
-	if jwtToken == "" {
-		bodyBytes, err := io.ReadAll(r.Body)
-		if err != nil {
-			return "", fmt.Errorf("failed to read request body: %v", err)
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
 
-		r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	member, err := g.authClient.SetMemberRole(ctx, &setMemberRoleRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(member); err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+	}
+}
 
-		var loginData struct {
-			Login    string `json:"login"`
-			Password string `json:"password"`
-		}
+func (g *GrpcClients) removeMemberHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
 
-		if err := json.Unmarshal(bodyBytes, &loginData); err != nil {
-			return "", fmt.Errorf("failed to decode login data: %v", err)
-		}
+	_, err = g.authClient.RemoveMember(ctx, &protoauth.RemoveMemberRequest{Jwt: jwt, UserId: chi.URLParam(r, "id")})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
 
-		loginURL := fmt.Sprintf("%s/api/v1/login", targetBase)
-		loginResp, err := http.Post(loginURL, "application/json", bytes.NewBuffer(bodyBytes))
-		if err != nil || loginResp.StatusCode != http.StatusOK {
-			return "", fmt.Errorf("failed to authenticate")
-		}
-		defer loginResp.Body.Close()
+func (g *GrpcClients) transferOwnershipHandler(w http.ResponseWriter, r *http.Request) {
+	jwt, err := jwtFromRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		return
+	}
+	var transferOwnershipRequest protoauth.TransferOwnershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&transferOwnershipRequest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	transferOwnershipRequest.Jwt = jwt
 
-		for _, cookie := range loginResp.Cookies() {
-			if cookie.Name == "Authorization" {
-				jwtToken = cookie.Value
-				break
-			}
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
 
-		if jwtToken == "" {
-			return "", fmt.Errorf("JWT not found in response")
-		}
+	organisation, err := g.authClient.TransferOwnership(ctx, &transferOwnershipRequest)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(organisation); err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+	}
+}
 
-	profileURL := fmt.Sprintf("%s/api/v1/profile", targetBase)
-	req, _ := http.NewRequest("GET", profileURL, nil)
-	req.Header.Set("Cookie", "Authorization="+jwtToken)
+func (g *GrpcClients) jwksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
 
-	profileResp, err := http.DefaultClient.Do(req)
-	if err != nil || profileResp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to get user profile")
+	jwks, err := g.authClient.GetJWKS(ctx, &protoauth.JWKSRequest{})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
 	}
-	defer profileResp.Body.Close()
 
-	var profile struct {
-		ID string `json:"id"`
-	}
-	if err := json.NewDecoder(profileResp.Body).Decode(&profile); err != nil {
-		return "", fmt.Errorf("failed to decode user profile response")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	err = json.NewEncoder(w).Encode(jwks)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+		return
 	}
-
-	return profile.ID, nil
 }
 
 func (g *GrpcClients) createPromoHandler(w http.ResponseWriter, r *http.Request) {
-	userID, err := g.getUserID(r)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+	userID := UserIDFromContext(r.Context())
+	if companyChangeForbidden(w, r) {
 		return
 	}
 
@@ -271,12 +1314,12 @@ func (g *GrpcClients) createPromoHandler(w http.ResponseWriter, r *http.Request)
 	}
 	req.AuthorId = userID
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := promoContext(r)
 	defer cancel()
 
 	resp, err := g.promoClient.CreatePromo(ctx, &req)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to create promo: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to create promo: %v", err), grpcErrorToHTTP(err))
 		return
 	}
 
@@ -284,14 +1327,10 @@ func (g *GrpcClients) createPromoHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func (g *GrpcClients) getPromoHandler(w http.ResponseWriter, r *http.Request) {
-	userID, err := g.getUserID(r)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
-		return
-	}
+	userID := UserIDFromContext(r.Context())
 
 	id := chi.URLParam(r, "id")
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := promoContext(r)
 	defer cancel()
 
 	resp, err := g.promoClient.GetPromo(ctx, &protopromo.GetPromoRequest{Id: id})
@@ -306,9 +1345,8 @@ func (g *GrpcClients) getPromoHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (g *GrpcClients) updatePromoHandler(w http.ResponseWriter, r *http.Request) {
-	userID, err := g.getUserID(r)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+	userID := UserIDFromContext(r.Context())
+	if companyChangeForbidden(w, r) {
 		return
 	}
 
@@ -319,12 +1357,12 @@ func (g *GrpcClients) updatePromoHandler(w http.ResponseWriter, r *http.Request)
 	}
 	req.AuthorId = userID
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := promoContext(r)
 	defer cancel()
 
 	resp, err := g.promoClient.UpdatePromo(ctx, &req)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to update promo: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to update promo: %v", err), grpcErrorToHTTP(err))
 		return
 	}
 
@@ -332,19 +1370,18 @@ func (g *GrpcClients) updatePromoHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func (g *GrpcClients) deletePromoHandler(w http.ResponseWriter, r *http.Request) {
-	userID, err := g.getUserID(r)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+	userID := UserIDFromContext(r.Context())
+	if companyChangeForbidden(w, r) {
 		return
 	}
 
 	id := chi.URLParam(r, "id")
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := promoContext(r)
 	defer cancel()
 
-	_, err = g.promoClient.DeletePromo(ctx, &protopromo.DeletePromoRequest{Id: id, AuthorId: userID})
+	_, err := g.promoClient.DeletePromo(ctx, &protopromo.DeletePromoRequest{Id: id, AuthorId: userID})
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to delete promo: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to delete promo: %v", err), grpcErrorToHTTP(err))
 		return
 	}
 
@@ -352,13 +1389,7 @@ func (g *GrpcClients) deletePromoHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func (g *GrpcClients) listPromosHandler(w http.ResponseWriter, r *http.Request) {
-	_, err := g.getUserID(r)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
-		return
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := promoContext(r)
 	defer cancel()
 
 	resp, err := g.promoClient.ListPromos(ctx, &protopromo.ListPromosRequest{})
@@ -370,11 +1401,7 @@ func (g *GrpcClients) listPromosHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func (g *GrpcClients) addCommentHandler(w http.ResponseWriter, r *http.Request) {
-	userID, err := g.getUserID(r)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
-		return
-	}
+	userID := UserIDFromContext(r.Context())
 
 	var req protopromo.AddCommentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -383,7 +1410,7 @@ func (g *GrpcClients) addCommentHandler(w http.ResponseWriter, r *http.Request)
 	}
 	req.AuthorId = userID
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := promoContext(r)
 	defer cancel()
 
 	resp, err := g.promoClient.AddComment(ctx, &req)
@@ -398,14 +1425,10 @@ func (g *GrpcClients) addCommentHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func (g *GrpcClients) getCommentHandler(w http.ResponseWriter, r *http.Request) {
-	userID, err := g.getUserID(r)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
-		return
-	}
+	userID := UserIDFromContext(r.Context())
 
 	id := chi.URLParam(r, "id")
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := promoContext(r)
 	defer cancel()
 
 	resp, err := g.promoClient.GetComment(ctx, &protopromo.GetCommentRequest{CommentId: id})
@@ -420,11 +1443,7 @@ func (g *GrpcClients) getCommentHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func (g *GrpcClients) listCommentsHandler(w http.ResponseWriter, r *http.Request) {
-	userID, err := g.getUserID(r)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
-		return
-	}
+	userID := UserIDFromContext(r.Context())
 
 	promoId := chi.URLParam(r, "promo_id")
 	page := 1
@@ -436,7 +1455,7 @@ func (g *GrpcClients) listCommentsHandler(w http.ResponseWriter, r *http.Request
 		fmt.Sscanf(ps, "%d", &pageSize)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := promoContext(r)
 	defer cancel()
 
 	resp, err := g.promoClient.ListComments(ctx, &protopromo.ListCommentsRequest{
@@ -456,13 +1475,25 @@ func (g *GrpcClients) listCommentsHandler(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(resp)
 }
 
-func (g *GrpcClients) promoOnClickHandler(w http.ResponseWriter, r *http.Request) {
-	userID, err := g.getUserID(r)
+func (g *GrpcClients) deleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	userID := UserIDFromContext(r.Context())
+
+	id := chi.URLParam(r, "id")
+	ctx, cancel := promoContext(r)
+	defer cancel()
+
+	_, err := g.promoClient.DeleteComment(ctx, &protopromo.DeleteCommentRequest{CommentId: id, AuthorId: userID})
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
+		http.Error(w, fmt.Sprintf("Failed to delete comment: %v", err), grpcErrorToHTTP(err))
 		return
 	}
 
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (g *GrpcClients) promoOnClickHandler(w http.ResponseWriter, r *http.Request) {
+	userID := UserIDFromContext(r.Context())
+
 	promoId := chi.URLParam(r, "promo_id")
 
 	kafka.SendStat("promo_click", userID, promoId)
@@ -470,26 +1501,88 @@ func (g *GrpcClients) promoOnClickHandler(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusOK)
 }
 
-func NewRouter(g *GrpcClients) *chi.Mux {
+func NewRouter(g *GrpcClients, limits *RateLimits) *chi.Mux {
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
-
-	r.Post("/api/v1/register", g.registerUserHandler)
-	r.Post("/api/v1/login", g.loginUserHandler)
+	r.Use(clientIPMiddleware(limits.trustedProxies))
+	r.Use(g.verifyJWT)
+	r.Use(limits.middleware("default", limits.config.Default))
+	// authLimit covers the routes that guess credentials or codes, or send
+	// emails and SMS.
+	authLimit := limits.middleware("auth", limits.config.Auth)
+	forget := g.forgetIntrospections
+
+	r.With(authLimit).Post("/api/v1/register", g.registerUserHandler)
+	r.With(authLimit).Post("/api/v1/login", g.loginUserHandler)
+	r.With(authLimit).Post("/api/v1/login/two_factor", g.loginTwoFactorHandler)
+	r.With(authLimit).Post("/api/v1/refresh", g.refreshHandler)
+	r.With(forget).Post("/api/v1/logout", g.logoutHandler)
 	r.Get("/api/v1/profile", g.getProfileHandler)
-	r.Post("/api/v1/profile", g.updateProfileHandler)
+	r.With(forget).Post("/api/v1/profile", g.updateProfileHandler)
+	r.With(forget).Get("/api/v1/verify_email", g.verifyEmailHandler)
+	r.With(authLimit).Post("/api/v1/profile/resend_verification", g.resendVerificationHandler)
+	r.With(authLimit).Post("/api/v1/profile/phone/verification", g.requestPhoneVerificationHandler)
+	r.With(authLimit).Post("/api/v1/profile/phone/confirm", g.confirmPhoneNumberHandler)
+	r.With(authLimit, forget).Post("/api/v1/profile/password", g.changePasswordHandler)
+	r.With(authLimit).Post("/api/v1/password/reset_request", g.requestPasswordResetHandler)
+	r.With(authLimit).Post("/api/v1/password/reset", g.resetPasswordHandler)
+	r.Post("/api/v1/profile/two_factor", g.enrollTwoFactorHandler)
+	r.With(authLimit, forget).Post("/api/v1/profile/two_factor/confirm", g.confirmTwoFactorHandler)
+	r.With(authLimit, forget).Post("/api/v1/profile/two_factor/disable", g.disableTwoFactorHandler)
+	r.Post("/api/v1/profile/api_keys", g.createAPIKeyHandler)
+	r.Get("/api/v1/profile/api_keys", g.listAPIKeysHandler)
+	r.Delete("/api/v1/profile/api_keys/{id}", g.revokeAPIKeyHandler)
+	r.Get("/api/v1/profile/sessions", g.listSessionsHandler)
+	r.With(forget).Delete("/api/v1/profile/sessions/{id}", g.revokeSessionHandler)
+	r.Get("/api/v1/organisation", g.getOrganisationHandler)
+	r.Post("/api/v1/organisation", g.renameOrganisationHandler)
+	r.Post("/api/v1/organisation/invitations", g.inviteMemberHandler)
+	r.Delete("/api/v1/organisation/invitations/{id}", g.cancelInvitationHandler)
+	r.With(forget).Post("/api/v1/organisation/invitations/accept", g.acceptInvitationHandler)
+	r.With(forget).Post("/api/v1/organisation/members/{id}/role", g.setMemberRoleHandler)
+	r.With(forget).Delete("/api/v1/organisation/members/{id}", g.removeMemberHandler)
+	r.With(forget).Post("/api/v1/organisation/owner", g.transferOwnershipHandler)
+	r.Get("/api/v1/audit_events", g.listAuditEventsHandler)
 	r.Get("/api/v1/user/{id}", g.getUserInfoHandler)
+	r.Get("/.well-known/jwks.json", g.jwksHandler)
+
+	// API keys are limited to their scopes, so they are only accepted on the
+	// routes that declare their permission with requirePermission.
+	r.Group(func(r chi.Router) {
+		r.Use(g.authMiddleware)
+
+		r.With(requirePermission(accesspolicy.PromosWrite)).Post("/api/v1/promos", g.createPromoHandler)
+		r.With(requirePermission(accesspolicy.PromosWrite)).Put("/api/v1/promos/{id}", g.updatePromoHandler)
+		r.With(requirePermission(accesspolicy.PromosWrite, accesspolicy.PromosModerate)).Delete("/api/v1/promos/{id}", g.deletePromoHandler)
+
+		r.With(requirePermission(accesspolicy.CommentsWrite), limits.middleware("comments", limits.config.Comments)).
+			Post("/api/v1/comments", g.addCommentHandler)
+		r.With(requirePermission(accesspolicy.CommentsWrite, accesspolicy.CommentsModerate)).Delete("/api/v1/comments/{id}", g.deleteCommentHandler)
+
+		r.Route("/api/v1/admin/users", func(r chi.Router) {
+			r.Use(requirePermission(accesspolicy.UsersManage))
+			r.Get("/", g.searchUsersHandler)
+			r.Get("/{id}", g.adminGetUserHandler)
+			r.With(forget).Post("/{id}", g.adminUpdateUserHandler)
+			r.With(forget).Post("/{id}/role", g.setUserRoleHandler)
+			r.With(forget).Post("/{id}/suspend", g.suspendUserHandler)
+			r.With(forget).Post("/{id}/unsuspend", g.unsuspendUserHandler)
+			r.With(forget).Post("/{id}/logout", g.forceLogoutHandler)
+		})
+	})
 
-	r.Post("/api/v1/promos", g.createPromoHandler)
-	r.Get("/api/v1/promos/{id}", g.getPromoHandler)
-	r.Put("/api/v1/promos/{id}", g.updatePromoHandler)
-	r.Delete("/api/v1/promos/{id}", g.deletePromoHandler)
-	r.Get("/api/v1/promos", g.listPromosHandler)
+	r.Group(func(r chi.Router) {
+		r.Use(g.jwtAuthMiddleware)
 
-	r.Post("/api/v1/comments", g.addCommentHandler)
-	r.Get("/api/v1/comments/{id}", g.getCommentHandler)
-	r.Get("/api/v1/comments/promo/{promo_id}", g.listCommentsHandler)
+		r.Get("/api/v1/promos/{id}", g.getPromoHandler)
+		r.Get("/api/v1/promos", g.listPromosHandler)
+		r.Get("/api/v1/comments/{id}", g.getCommentHandler)
+		r.Get("/api/v1/comments/promo/{promo_id}", g.listCommentsHandler)
 
-	r.Post("/api/v1/on_click/{promo_id}", g.promoOnClickHandler)
+		r.With(forget).Delete("/api/v1/profile", g.deleteAccountHandler)
+		r.Get("/api/v1/profile/export", g.exportMyDataHandler)
+
+		r.With(limits.middleware("clicks", limits.config.Clicks)).Post("/api/v1/on_click/{promo_id}", g.promoOnClickHandler)
+	})
 	return r
 }
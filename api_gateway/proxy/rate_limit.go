@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	gatewayconfig "apigateway/gateway_config"
+	ratelimiter "apigateway/rate_limiter"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimits applies the rate_limit section of the config to the routes.
+type RateLimits struct {
+	limiter        *ratelimiter.Limiter
+	config         gatewayconfig.RateLimit
+	trustedProxies []*net.IPNet
+}
+
+func NewRateLimits(cfg gatewayconfig.RateLimit) (*RateLimits, error) {
+	trustedProxies, err := ratelimiter.ParseNetworks(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	var store ratelimiter.Store
+	switch cfg.Backend {
+	case "memory":
+		store = ratelimiter.NewMemoryStore()
+	case "redis":
+		client := redis.NewClient(&redis.Options{Addr: cfg.RedisAddress, Password: cfg.RedisPassword, DB: cfg.RedisDB})
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			return nil, fmt.Errorf("failed to connect to rate limit Redis: %v", err)
+		}
+		store = ratelimiter.NewRedisStore(client)
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.Backend)
+	}
+	return &RateLimits{limiter: ratelimiter.NewLimiter(store), config: cfg, trustedProxies: trustedProxies}, nil
+}
+
+func (l *RateLimits) middleware(group string, limit gatewayconfig.Limit) func(http.Handler) http.Handler {
+	return l.limiter.Middleware(group, ratelimiter.PerMinute(limit.PerMinute, limit.Burst), clientKey)
+}
+
+// clientKey prefers the user of a JWT verified by verifyJWT, so users behind
+// one NAT do not share a bucket, and falls back to the client IP. Routes
+// outside authMiddleware check the JWT themselves.
+func clientKey(r *http.Request) string {
+	if claims, ok := ClaimsFromContext(r.Context()); ok {
+		return "user:" + claims.UserID
+	}
+	if claims, ok := verifiedClaims(r.Context()); ok {
+		return "user:" + claims.UserID
+	}
+	return "ip:" + clientIP(r)
+}
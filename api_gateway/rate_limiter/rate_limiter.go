@@ -0,0 +1,184 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const keyPrefix = "ratelimit:"
+
+// storeTimeout bounds a Store call, a slow Redis must not hold up requests.
+const storeTimeout = 500 * time.Millisecond
+
+const sweepInterval = time.Minute
+
+// Limit is a token bucket holding up to Burst tokens and refilled with Rate
+// tokens per second. Every request takes a token. A zero Rate disables it.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func PerMinute(requests, burst int) Limit {
+	return Limit{Rate: float64(requests) / 60, Burst: burst}
+}
+
+// Store keeps the buckets. Take removes a token from the bucket at key and
+// otherwise reports how long until one is available.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+		b.updated = now
+	}
+}
+
+// MemoryStore keeps the buckets of a single gateway replica.
+type MemoryStore struct {
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+	mx        sync.Mutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), lastSweep: time.Now(), now: time.Now}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	now := s.now()
+	s.sweep(now)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	return false, time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second)), nil
+}
+
+// sweep drops the full buckets, which are no different from missing ones.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if b.refill(now); b.tokens >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Limiter applies the limits of route groups to the requests.
+type Limiter struct {
+	store Store
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store}
+}
+
+// Middleware answers 429 with Retry-After once the client identified by key
+// runs out of tokens in group. Requests are let through when the Store fails,
+// losing the limits is better than losing the API.
+func (l *Limiter) Middleware(group string, limit Limit, key func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit.Rate <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), storeTimeout)
+			allowed, retryAfter, err := l.store.Take(ctx, keyPrefix+group+":"+key(r), limit)
+			cancel()
+			if err != nil {
+				log.Printf("Rate limiter failed, letting the request through: %v\n", err)
+			} else if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				http.Error(w, "Too many requests, retry later", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ParseNetworks parses the CIDRs of trusted proxies.
+func ParseNetworks(cidrs []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", cidr, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// ClientIP takes the address from X-Real-IP, then from X-Forwarded-For, only
+// for requests coming from trusted proxies. Anyone else could set the headers
+// to get a fresh bucket per request. X-Forwarded-For is read from the right,
+// skipping the trusted proxies, since a client can prepend any hops it likes.
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !isTrusted(net.ParseIP(remote), trustedProxies) {
+		return remote
+	}
+	if real := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); real != nil {
+		return real.String()
+	}
+	client := remote
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		client = hop.String()
+		if !isTrusted(hop, trustedProxies) {
+			break
+		}
+	}
+	return client
+}
+
+func isTrusted(ip net.IP, trustedProxies []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+var testLimit = Limit{Rate: 1, Burst: 2}
+
+// testClock is a clock for MemoryStore that only moves when told to.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func newTestMemoryStore() (*MemoryStore, *testClock) {
+	clock := &testClock{now: time.Unix(1700000000, 0)}
+	store := NewMemoryStore()
+	store.now, store.lastSweep = clock.Now, clock.now
+	return store, clock
+}
+
+func take(t *testing.T, store Store, key string) (bool, time.Duration) {
+	t.Helper()
+	allowed, retryAfter, err := store.Take(context.Background(), key, testLimit)
+	if err != nil {
+		t.Fatalf("Take failed: %v", err)
+	}
+	return allowed, retryAfter
+}
+
+// testBucket runs the same refill sequence against a store, advance moves
+// its clock.
+func testBucket(t *testing.T, store Store, advance func(time.Duration)) {
+	for i := 0; i < testLimit.Burst; i++ {
+		if allowed, _ := take(t, store, "bucket"); !allowed {
+			t.Fatalf("request %d within the burst was refused", i)
+		}
+	}
+	if allowed, retryAfter := take(t, store, "bucket"); allowed || retryAfter != time.Second {
+		t.Errorf("empty bucket = %v, retry after %v; want refused for 1s", allowed, retryAfter)
+	}
+	if allowed, _ := take(t, store, "other"); !allowed {
+		t.Errorf("buckets are shared between keys")
+	}
+
+	advance(500 * time.Millisecond)
+	if allowed, retryAfter := take(t, store, "bucket"); allowed || retryAfter != 500*time.Millisecond {
+		t.Errorf("half refilled bucket = %v, retry after %v; want refused for 500ms", allowed, retryAfter)
+	}
+	advance(500 * time.Millisecond)
+	if allowed, _ := take(t, store, "bucket"); !allowed {
+		t.Errorf("refilled token was refused")
+	}
+
+	advance(time.Hour)
+	for i := 0; i < testLimit.Burst; i++ {
+		if allowed, _ := take(t, store, "bucket"); !allowed {
+			t.Fatalf("request %d after an idle hour was refused", i)
+		}
+	}
+	if allowed, _ := take(t, store, "bucket"); allowed {
+		t.Errorf("bucket refilled beyond its burst")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store, clock := newTestMemoryStore()
+	testBucket(t, store, func(d time.Duration) { clock.now = clock.now.Add(d) })
+
+	clock.now = clock.now.Add(time.Hour)
+	take(t, store, "new")
+	if _, ok := store.buckets["other"]; ok {
+		t.Errorf("full bucket was not swept")
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	now := time.Unix(1700000000, 0)
+	server.SetTime(now)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	store := NewRedisStore(client)
+
+	testBucket(t, store, func(d time.Duration) {
+		now = now.Add(d)
+		server.SetTime(now)
+	})
+	if ttl := server.TTL("bucket"); ttl <= 0 || ttl > 3*time.Second {
+		t.Errorf("bucket expires in %v; want once it is full again", ttl)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit) (bool, time.Duration, error) {
+	return false, 0, errors.New("store is down")
+}
+
+func TestMiddleware(t *testing.T) {
+	store, _ := newTestMemoryStore()
+	handler := func(store Store, limit Limit) http.Handler {
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+		key := func(r *http.Request) string { return r.Header.Get("X-Test-Key") }
+		return NewLimiter(store).Middleware("test", limit, key)(next)
+	}
+	serve := func(h http.Handler, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Test-Key", key)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	limited := handler(store, Limit{Rate: 0.5, Burst: 1})
+	if w := serve(limited, "alice"); w.Code != http.StatusNoContent {
+		t.Fatalf("first request = %d", w.Code)
+	}
+	w := serve(limited, "alice")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" {
+		t.Errorf("limited request = %d, Retry-After %q; want 429 after 2s", w.Code, w.Header().Get("Retry-After"))
+	}
+	if _, ok := store.buckets[keyPrefix+"test:alice"]; !ok {
+		t.Errorf("bucket is not keyed by group and client")
+	}
+	if w := serve(limited, "bob"); w.Code != http.StatusNoContent {
+		t.Errorf("another client was limited: %d", w.Code)
+	}
+
+	disabled := handler(store, Limit{Rate: 0, Burst: 1})
+	for i := 0; i < 3; i++ {
+		if w := serve(disabled, "alice"); w.Code != http.StatusNoContent {
+			t.Fatalf("request %d to a disabled group = %d", i, w.Code)
+		}
+	}
+	if w := serve(handler(failingStore{}, testLimit), "alice"); w.Code != http.StatusNoContent {
+		t.Errorf("request was refused while the store failed: %d", w.Code)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseNetworks([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("ParseNetworks failed: %v", err)
+	}
+	tests := []struct {
+		name, remote, realIP, forwardedFor, want string
+	}{
+		{"untrusted peer", "203.0.113.5:1234", "198.51.100.1", "198.51.100.2", "203.0.113.5"},
+		{"X-Real-IP", "10.0.0.2:1234", "198.51.100.1", "198.51.100.2", "198.51.100.1"},
+		{"last hop", "10.0.0.2:1234", "", "198.51.100.2", "198.51.100.2"},
+		{"spoofed hops", "10.0.0.2:1234", "", "192.0.2.9, 198.51.100.2", "198.51.100.2"},
+		{"trusted hops", "10.0.0.2:1234", "", "198.51.100.2, 10.0.0.7, 10.0.0.3", "198.51.100.2"},
+		{"only trusted hops", "10.0.0.2:1234", "", "10.0.0.7", "10.0.0.7"},
+		{"malformed hop", "10.0.0.2:1234", "", "198.51.100.2, junk", "10.0.0.2"},
+		{"no headers", "10.0.0.2:1234", "", "", "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remote
+		if tt.realIP != "" {
+			r.Header.Set("X-Real-IP", tt.realIP)
+		}
+		if tt.forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+		}
+		if got := ClientIP(r, trusted); got != tt.want {
+			t.Errorf("%s: ClientIP = %s; want %s", tt.name, got, tt.want)
+		}
+	}
+}
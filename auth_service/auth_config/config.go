@@ -0,0 +1,193 @@
+package authconfig
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	configloader "shared/config_loader"
+	"time"
+)
+
+// Config is the effective configuration of the auth service. Every field can
+// be set in the YAML file under its yaml key and overridden by the
+// environment variable in its env tag. Fields tagged secret are redacted when
+// the configuration is printed.
+type Config struct {
+	ListenAddress           string  `yaml:"listen_address" env:"LISTEN_ADDRESS"`
+	PublicURL               string  `yaml:"public_url" env:"PUBLIC_URL"`
+	PasswordHashAlgorithm   string  `yaml:"password_hash_algorithm" env:"PASSWORD_HASH_ALGORITHM"`
+	RequireCompanyTwoFactor bool    `yaml:"require_company_two_factor" env:"REQUIRE_COMPANY_TWO_FACTOR"`
+	TOTPSecretKeyFile       string  `yaml:"totp_secret_key_file" env:"TOTP_SECRET_KEY_FILE"`
+	Storage                 Storage `yaml:"storage"`
+	Kafka                   Kafka   `yaml:"kafka"`
+	Mailer                  Mailer  `yaml:"mailer"`
+	SMS                     SMS     `yaml:"sms"`
+}
+
+type Storage struct {
+	Backend    string   `yaml:"backend" env:"STORAGE_BACKEND"`
+	SQLitePath string   `yaml:"sqlite_path" env:"SQLITE_PATH"`
+	Postgres   Postgres `yaml:"postgres"`
+}
+
+// Postgres takes the user, password and database name missing here from
+// CredentialsFile, which is how they were provided before the config existed.
+type Postgres struct {
+	Host            string        `yaml:"host" env:"POSTGRES_HOST"`
+	Port            int           `yaml:"port" env:"POSTGRES_PORT"`
+	User            string        `yaml:"user" env:"POSTGRES_USER"`
+	Password        string        `yaml:"password" env:"POSTGRES_PASSWORD" secret:"true"`
+	DBName          string        `yaml:"db_name" env:"POSTGRES_DB"`
+	SSLMode         string        `yaml:"ssl_mode" env:"POSTGRES_SSLMODE"`
+	CredentialsFile string        `yaml:"credentials_file" env:"POSTGRES_CREDENTIALS_FILE"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout" env:"POSTGRES_CONNECT_TIMEOUT"`
+}
+
+type Kafka struct {
+	Brokers    []string `yaml:"brokers" env:"KAFKA_BROKERS"`
+	AuditTopic string   `yaml:"audit_topic" env:"KAFKA_AUDIT_TOPIC"`
+	StatsTopic string   `yaml:"stats_topic" env:"KAFKA_STATS_TOPIC"`
+}
+
+type Mailer struct {
+	Kind         string `yaml:"kind" env:"MAILER"`
+	From         string `yaml:"from" env:"MAIL_FROM"`
+	Dir          string `yaml:"dir" env:"MAIL_DIR"`
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+// SMS leaves phone number verification disabled while Sender is empty.
+type SMS struct {
+	Sender       string `yaml:"sender" env:"SMS_SENDER"`
+	GatewayURL   string `yaml:"gateway_url" env:"SMS_GATEWAY_URL"`
+	GatewayToken string `yaml:"gateway_token" env:"SMS_GATEWAY_TOKEN" secret:"true"`
+}
+
+// Load starts from Default, applies the YAML file at path unless path is
+// empty, then the environment, and validates the result.
+func Load(path string) (Config, error) {
+	return configloader.Load(path, Default())
+}
+
+// Print writes the redacted configuration as YAML that Load accepts back.
+func Print(w io.Writer, c Config) error {
+	return configloader.Print(w, c)
+}
+
+// Default matches the docker-compose setup.
+func Default() Config {
+	return Config{
+		ListenAddress:         ":8080",
+		PublicURL:             "http://localhost:8081",
+		PasswordHashAlgorithm: "argon2id",
+		TOTPSecretKeyFile:     "credentials/totp_secret_key",
+		Storage: Storage{
+			Backend:    "postgres",
+			SQLitePath: "auth.db",
+			Postgres: Postgres{
+				Host:            "postgres",
+				Port:            5432,
+				SSLMode:         "disable",
+				CredentialsFile: "credentials/postgresql.json",
+				ConnectTimeout:  10 * time.Second,
+			},
+		},
+		Kafka: Kafka{
+			Brokers:    []string{"kafka:9092"},
+			AuditTopic: "auth-audit",
+			StatsTopic: "stats",
+		},
+		Mailer: Mailer{
+			Kind: "file",
+			From: "no-reply@loyalty.local",
+			Dir:  "mail",
+		},
+	}
+}
+
+// Validate reports every invalid value at once, so a broken deployment is
+// fixed in one go.
+func (c Config) Validate() error {
+	var errs []error
+	if c.ListenAddress == "" {
+		errs = append(errs, errors.New("listen_address is empty"))
+	}
+	if u, err := url.Parse(c.PublicURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("public_url %q is not an absolute URL", c.PublicURL))
+	}
+	switch c.PasswordHashAlgorithm {
+	case "argon2id", "bcrypt":
+	default:
+		errs = append(errs, fmt.Errorf("unknown password_hash_algorithm %q", c.PasswordHashAlgorithm))
+	}
+	if c.TOTPSecretKeyFile == "" {
+		errs = append(errs, errors.New("totp_secret_key_file is empty"))
+	}
+
+	switch c.Storage.Backend {
+	case "postgres":
+		pg := c.Storage.Postgres
+		if pg.Host == "" {
+			errs = append(errs, errors.New("storage.postgres.host is empty"))
+		}
+		if pg.Port < 1 || pg.Port > 65535 {
+			errs = append(errs, fmt.Errorf("storage.postgres.port %d is out of range", pg.Port))
+		}
+		if pg.User == "" && pg.CredentialsFile == "" {
+			errs = append(errs, errors.New("storage.postgres needs a user or a credentials_file"))
+		}
+		if pg.ConnectTimeout <= 0 {
+			errs = append(errs, errors.New("storage.postgres.connect_timeout must be positive"))
+		}
+	case "sqlite":
+		if c.Storage.SQLitePath == "" {
+			errs = append(errs, errors.New("storage.sqlite_path is empty"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown storage.backend %q", c.Storage.Backend))
+	}
+
+	if len(c.Kafka.Brokers) == 0 {
+		errs = append(errs, errors.New("kafka.brokers is empty"))
+	}
+	if c.Kafka.AuditTopic == "" {
+		errs = append(errs, errors.New("kafka.audit_topic is empty"))
+	}
+	if c.Kafka.StatsTopic == "" {
+		errs = append(errs, errors.New("kafka.stats_topic is empty"))
+	}
+
+	if c.Mailer.From == "" {
+		errs = append(errs, errors.New("mailer.from is empty"))
+	}
+	switch c.Mailer.Kind {
+	case "file":
+		if c.Mailer.Dir == "" {
+			errs = append(errs, errors.New("mailer.dir is empty"))
+		}
+	case "smtp":
+		if c.Mailer.SMTPHost == "" {
+			errs = append(errs, errors.New("mailer.smtp_host is empty"))
+		}
+		if c.Mailer.SMTPPort < 1 || c.Mailer.SMTPPort > 65535 {
+			errs = append(errs, fmt.Errorf("mailer.smtp_port %d is out of range", c.Mailer.SMTPPort))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown mailer.kind %q", c.Mailer.Kind))
+	}
+
+	switch c.SMS.Sender {
+	case "", "log":
+	case "http":
+		if u, err := url.Parse(c.SMS.GatewayURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("sms.gateway_url %q is not an absolute URL", c.SMS.GatewayURL))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown sms.sender %q", c.SMS.Sender))
+	}
+	return errors.Join(errs...)
+}
@@ -1,15 +1,26 @@
 package authhandlers
 
 import (
+	userkeys "authservice/auth_storage/user_keys"
 	usermodel "authservice/auth_storage/user_model"
 	pb "authservice/proto/auth"
 	"context"
-	"fmt"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ClientIPMetadataKey and UserAgentMetadataKey are set by the gateway to
+// describe the HTTP client.
+const (
+	ClientIPMetadataKey  = "x-client-ip"
+	UserAgentMetadataKey = "x-user-agent"
 )
 
 // var storageManager usermodel.StorageManager
@@ -41,9 +52,19 @@ func ConvertUserToProto(src usermodel.User) *pb.User {
 	if src.Email != "" {
 		dst.Email = src.Email
 	}
+	dst.EmailVerified = src.EmailVerified
+	dst.TwoFactorEnabled = src.TwoFactorEnabled
 	if src.PhoneNumber != "" {
+		dst.PhoneNumber = src.PhoneNumber
+		dst.PhoneRegion = src.PhoneRegion
 	}
+	dst.PhoneNumberVerified = src.PhoneNumberVerified
 	dst.IsCompany = src.IsCompany
+	if src.OrganisationID != uuid.Nil {
+		dst.OrganisationId = src.OrganisationID.String()
+	}
+	dst.Role = string(src.Role)
+	dst.Suspended = src.Suspended
 	if !src.CreationDate.IsZero() {
 		dst.CreationDate = src.CreationDate.Format(time.RFC3339)
 	}
@@ -90,6 +111,9 @@ func ConvertProtoToUser(src *pb.User) usermodel.User {
 	if src.PhoneNumber != "" {
 		dst.PhoneNumber = src.PhoneNumber
 	}
+	if src.PhoneRegion != "" {
+		dst.PhoneRegion = src.PhoneRegion
+	}
 
 	dst.IsCompany = src.IsCompany
 
@@ -113,28 +137,132 @@ func ConvertProtoToUser(src *pb.User) usermodel.User {
 }
 
 func (s *AuthServer) Register(ctx context.Context, creds *pb.UserCreds) (*pb.User, error) {
-	user, err := s.storageManager.CreateUser(creds.Login, creds.Password, creds.Email, creds.IsCompany)
-	if err != nil {
+	user, err := s.storageManager.CreateUser(creds.Login, creds.Password, creds.Email, creds.IsCompany, clientInfo(ctx))
+	var validationErr *usermodel.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return nil, invalidArgumentStatus(validationErr)
+	case errors.Is(err, usermodel.ErrUserExists):
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	case err != nil:
 		return nil, status.Errorf(codes.Internal, "could not create user: %v", err)
 	}
-	if user.Login == "" {
-		return nil, status.Error(codes.AlreadyExists, "user already exists or invalid credentials")
-	}
 	return ConvertUserToProto(user), nil
 }
 
+// invalidArgumentStatus carries every invalid field as a BadRequest field
+// violation, the gateway returns them to the client as JSON.
+func invalidArgumentStatus(validationErr *usermodel.ValidationError) error {
+	st := status.New(codes.InvalidArgument, validationErr.Error())
+	badRequest := &errdetails.BadRequest{}
+	for _, violation := range validationErr.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       violation.Field,
+			Reason:      violation.Reason,
+			Description: violation.Description,
+		})
+	}
+	detailed, err := st.WithDetails(badRequest)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// Login takes the login or the email of the account in the login field, or
+// the email in the email field.
 func (s *AuthServer) Login(ctx context.Context, creds *pb.UserCreds) (*pb.LoginResponse, error) {
-	if creds.Login == "" || creds.Password == "" {
+	identifier := creds.Login
+	if identifier == "" {
+		identifier = creds.Email
+	}
+	if identifier == "" || creds.Password == "" {
 		return nil, status.Error(codes.Unauthenticated, "missing login or password")
 	}
-	jwt, err := s.storageManager.GetJWTByCredentials(creds.Login, creds.Password)
+	tokens, err := s.storageManager.GetJWTByCredentials(identifier, creds.Password, clientInfo(ctx))
+	var lockedErr *usermodel.LoginLockedError
+	if errors.As(err, &lockedErr) {
+		return nil, loginLockedStatus(lockedErr)
+	}
+	if errors.Is(err, usermodel.ErrAccountSuspended) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get JWT: %v", err)
 	}
-	if jwt == "" {
+	if tokens.TwoFactorChallenge != "" {
+		return &pb.LoginResponse{TwoFactorChallenge: tokens.TwoFactorChallenge}, nil
+	}
+	if tokens.AccessToken == "" {
 		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
 	}
-	return &pb.LoginResponse{Jwt: jwt}, nil
+	return ConvertTokensToProto(tokens), nil
+}
+
+func metadataValue(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func clientInfo(ctx context.Context) usermodel.ClientInfo {
+	return usermodel.ClientInfo{
+		IP:        metadataValue(ctx, ClientIPMetadataKey),
+		UserAgent: metadataValue(ctx, UserAgentMetadataKey),
+	}
+}
+
+// loginLockedStatus carries the lockout duration as RetryInfo, the gateway
+// turns it into a Retry-After header.
+func loginLockedStatus(lockedErr *usermodel.LoginLockedError) error {
+	st := status.New(codes.ResourceExhausted, lockedErr.Error())
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(lockedErr.RetryAfter)})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func ConvertTokensToProto(src usermodel.TokenPair) *pb.LoginResponse {
+	return &pb.LoginResponse{
+		Jwt:                    src.AccessToken,
+		JwtExpiresAt:           src.AccessExpiresAt.Format(timeLayout),
+		RefreshToken:           src.RefreshToken,
+		RefreshTokenExpiresAt:  src.RefreshExpiresAt.Format(timeLayout),
+		TwoFactorSetupRequired: src.TwoFactorSetupRequired,
+	}
+}
+
+func (s *AuthServer) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.LoginResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing refresh token")
+	}
+	tokens, err := s.storageManager.RefreshJWT(req.RefreshToken, clientInfo(ctx))
+	if errors.Is(err, usermodel.ErrAccountSuspended) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to refresh JWT: %v", err)
+	}
+	if tokens.AccessToken == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+	}
+	return ConvertTokensToProto(tokens), nil
+}
+
+func (s *AuthServer) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	ok, err := s.storageManager.Logout(req.Jwt, req.RefreshToken)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to logout: %v", err)
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return &pb.LogoutResponse{}, nil
 }
 
 func (s *AuthServer) GetProfile(ctx context.Context, req *pb.AuthRequest) (*pb.User, error) {
@@ -151,19 +279,60 @@ func (s *AuthServer) GetProfile(ctx context.Context, req *pb.AuthRequest) (*pb.U
 	return ConvertUserToProto(user), nil
 }
 
+// IntrospectToken answers OK for every token, an invalid one is reported as
+// inactive. Unavailable means the answer could not be looked up and the call
+// may be retried.
+func (s *AuthServer) IntrospectToken(ctx context.Context, req *pb.IntrospectTokenRequest) (*pb.TokenIntrospection, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing JWT")
+	}
+	result, err := s.storageManager.IntrospectToken(req.Jwt)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to introspect token: %v", err)
+	}
+	if result.UserID == uuid.Nil {
+		return &pb.TokenIntrospection{}, nil
+	}
+	dst := &pb.TokenIntrospection{
+		Active:                 result.Active,
+		Revoked:                result.Revoked,
+		UserId:                 result.UserID.String(),
+		TokenId:                result.TokenID.String(),
+		Role:                   string(result.Role),
+		IsCompany:              result.IsCompany,
+		EmailVerified:          result.EmailVerified,
+		TwoFactorSetupRequired: result.TwoFactorSetupRequired,
+		Suspended:              result.Suspended,
+		IssuedAt:               result.IssuedAt.Format(timeLayout),
+		ExpiresAt:              result.ExpiresAt.Format(timeLayout),
+		CacheTtlSeconds:        int32(result.CacheTTL / time.Second),
+	}
+	if result.SessionID != uuid.Nil {
+		dst.SessionId = result.SessionID.String()
+	}
+	if result.OrganisationID != uuid.Nil {
+		dst.OrganisationId = result.OrganisationID.String()
+	}
+	return dst, nil
+}
+
 func (s *AuthServer) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.User, error) {
 	if req.Jwt == "" {
 		return nil, status.Error(codes.Unauthenticated, "missing JWT")
 	}
-	user := ConvertProtoToUser(req.NewInfo)
-	fmt.Println(user)
-	user, err := s.storageManager.UpdateUserByJWT(req.Jwt, user)
-	fmt.Println(user)
+	user, err := s.storageManager.UpdateUserByJWT(req.Jwt, ConvertProtoToUser(req.NewInfo), clientInfo(ctx))
+	var validationErr *usermodel.ValidationError
+	if errors.As(err, &validationErr) {
+		return nil, invalidArgumentStatus(validationErr)
+	}
+	if errors.Is(err, usermodel.ErrUserExists) {
+		return nil, status.Error(codes.AlreadyExists, "email is registered to another user")
+	}
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to update user: %v", err)
 	}
 	if user.Login == "" {
-		return nil, status.Error(codes.InvalidArgument, "invalid user data")
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
 	}
 	return ConvertUserToProto(user), nil
 }
@@ -182,3 +351,833 @@ func (s *AuthServer) GetUserById(ctx context.Context, req *pb.UserIdRequest) (*p
 	}
 	return ConvertUserToProto(user), nil
 }
+
+func (s *AuthServer) GetJWKS(ctx context.Context, req *pb.JWKSRequest) (*pb.JWKS, error) {
+	keys := userkeys.GetJWKS()
+	jwks := &pb.JWKS{Keys: make([]*pb.JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwks.Keys = append(jwks.Keys, &pb.JWK{
+			Kty: key.Kty,
+			Use: key.Use,
+			Alg: key.Alg,
+			Kid: key.Kid,
+			N:   key.N,
+			E:   key.E,
+		})
+	}
+	return jwks, nil
+}
+
+func (s *AuthServer) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.User, error) {
+	if req.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing verification token")
+	}
+	user, err := s.storageManager.VerifyEmail(req.Token)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to verify email: %v", err)
+	}
+	if user.Login == "" {
+		return nil, status.Error(codes.InvalidArgument, "invalid or expired verification token")
+	}
+	return ConvertUserToProto(user), nil
+}
+
+func (s *AuthServer) ResendVerification(ctx context.Context, req *pb.AuthRequest) (*pb.ResendVerificationResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	user, err := s.storageManager.ResendVerification(req.Jwt)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to resend verification: %v", err)
+	}
+	if user.Login == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	if user.EmailVerified {
+		return nil, status.Error(codes.FailedPrecondition, "email already verified")
+	}
+	return &pb.ResendVerificationResponse{}, nil
+}
+
+func (s *AuthServer) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.LoginResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	tokens, err := s.storageManager.ChangePassword(req.Jwt, req.CurrentPassword, req.NewPassword)
+	if errors.Is(err, usermodel.ErrWrongPassword) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	var validationErr *usermodel.ValidationError
+	if errors.As(err, &validationErr) {
+		return nil, invalidArgumentStatus(validationErr)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to change password: %v", err)
+	}
+	if tokens.AccessToken == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return ConvertTokensToProto(tokens), nil
+}
+
+func (s *AuthServer) RequestPasswordReset(ctx context.Context, req *pb.PasswordResetRequest) (*pb.PasswordResetResponse, error) {
+	if req.Login == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing login")
+	}
+	s.storageManager.RequestPasswordReset(req.Login)
+	return &pb.PasswordResetResponse{}, nil
+}
+
+func (s *AuthServer) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.PasswordResetResponse, error) {
+	if req.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing reset token")
+	}
+	ok, err := s.storageManager.ResetPassword(req.Token, req.NewPassword)
+	var validationErr *usermodel.ValidationError
+	if errors.As(err, &validationErr) {
+		return nil, invalidArgumentStatus(validationErr)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to reset password: %v", err)
+	}
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid or expired reset token")
+	}
+	return &pb.PasswordResetResponse{}, nil
+}
+
+func twoFactorErrorToStatus(err error, action string) error {
+	var lockedErr *usermodel.LoginLockedError
+	switch {
+	case errors.As(err, &lockedErr):
+		return loginLockedStatus(lockedErr)
+	case errors.Is(err, usermodel.ErrWrongTwoFactorCode):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, usermodel.ErrTwoFactorUnavailable),
+		errors.Is(err, usermodel.ErrTwoFactorAlreadyEnabled),
+		errors.Is(err, usermodel.ErrTwoFactorNotEnrolled),
+		errors.Is(err, usermodel.ErrTwoFactorRequired):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Errorf(codes.Internal, "failed to %s: %v", action, err)
+}
+
+func (s *AuthServer) LoginTwoFactor(ctx context.Context, req *pb.TwoFactorLoginRequest) (*pb.LoginResponse, error) {
+	if req.Challenge == "" || req.Code == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing challenge or code")
+	}
+	tokens, err := s.storageManager.LoginTwoFactor(req.Challenge, req.Code, clientInfo(ctx))
+	var lockedErr *usermodel.LoginLockedError
+	if errors.As(err, &lockedErr) {
+		return nil, loginLockedStatus(lockedErr)
+	}
+	if errors.Is(err, usermodel.ErrAccountSuspended) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get JWT: %v", err)
+	}
+	if tokens.AccessToken == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid challenge or code")
+	}
+	return ConvertTokensToProto(tokens), nil
+}
+
+func (s *AuthServer) EnrollTwoFactor(ctx context.Context, req *pb.AuthRequest) (*pb.EnrollTwoFactorResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	enrollment, err := s.storageManager.EnrollTwoFactor(req.Jwt)
+	if err != nil {
+		return nil, twoFactorErrorToStatus(err, "enroll two-factor authentication")
+	}
+	if enrollment.Secret == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return &pb.EnrollTwoFactorResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningUri: enrollment.ProvisioningURI,
+	}, nil
+}
+
+func (s *AuthServer) ConfirmTwoFactor(ctx context.Context, req *pb.TwoFactorCodeRequest) (*pb.ConfirmTwoFactorResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	recoveryCodes, tokens, err := s.storageManager.ConfirmTwoFactor(req.Jwt, req.Code, clientInfo(ctx))
+	if err != nil {
+		return nil, twoFactorErrorToStatus(err, "confirm two-factor authentication")
+	}
+	if tokens.AccessToken == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return &pb.ConfirmTwoFactorResponse{
+		RecoveryCodes: recoveryCodes,
+		Tokens:        ConvertTokensToProto(tokens),
+	}, nil
+}
+
+func (s *AuthServer) DisableTwoFactor(ctx context.Context, req *pb.TwoFactorCodeRequest) (*pb.DisableTwoFactorResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	ok, err := s.storageManager.DisableTwoFactor(req.Jwt, req.Code, clientInfo(ctx))
+	if err != nil {
+		return nil, twoFactorErrorToStatus(err, "disable two-factor authentication")
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return &pb.DisableTwoFactorResponse{}, nil
+}
+
+func (s *AuthServer) SetUserRole(ctx context.Context, req *pb.SetUserRoleRequest) (*pb.User, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	id, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user id: %v", err)
+	}
+	user, err := s.storageManager.SetUserRole(req.Jwt, id, usermodel.Role(req.Role))
+	switch {
+	case errors.Is(err, usermodel.ErrForbidden):
+		return nil, status.Error(codes.PermissionDenied, "only platform admins can change roles")
+	case errors.Is(err, usermodel.ErrInvalidRole):
+		return nil, status.Errorf(codes.InvalidArgument, "invalid role %q", req.Role)
+	case errors.Is(err, usermodel.ErrUserNotFound):
+		return nil, status.Error(codes.NotFound, "user not found")
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "failed to set role: %v", err)
+	}
+	if user.Login == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return ConvertUserToProto(user), nil
+}
+
+func (s *AuthServer) DeleteAccount(ctx context.Context, req *pb.DeleteAccountRequest) (*pb.DeleteAccountResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	ok, organisationId, err := s.storageManager.DeleteAccount(req.Jwt, req.Password)
+	switch {
+	case errors.Is(err, usermodel.ErrWrongPassword):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, usermodel.ErrOrganisationOwner):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "failed to delete account: %v", err)
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	resp := &pb.DeleteAccountResponse{}
+	if organisationId != uuid.Nil {
+		resp.DeletedOrganisationId = organisationId.String()
+	}
+	return resp, nil
+}
+
+func (s *AuthServer) ExportMyData(ctx context.Context, req *pb.AuthRequest) (*pb.AccountExport, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	user, err := s.storageManager.GetUserByJWT(req.Jwt)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to export data: %v", err)
+	}
+	if user.Login == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	sessions, currentId, err := s.storageManager.ListSessions(req.Jwt)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to export data: %v", err)
+	}
+	export := &pb.AccountExport{
+		User:       ConvertUserToProto(user),
+		ExportedAt: time.Now().Format(timeLayout),
+	}
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, ConvertSessionToProto(session, currentId))
+	}
+	return export, nil
+}
+
+func ConvertAPIKeyToProto(src usermodel.APIKey) *pb.APIKey {
+	dst := &pb.APIKey{
+		Id:           src.ID.String(),
+		Name:         src.Name,
+		Scopes:       src.Scopes,
+		CreationDate: src.CreationDate.Format(timeLayout),
+	}
+	if !src.LastUsedDate.IsZero() {
+		dst.LastUsedDate = src.LastUsedDate.Format(timeLayout)
+	}
+	return dst
+}
+
+func (s *AuthServer) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	apiKey, key, err := s.storageManager.CreateAPIKey(req.Jwt, req.Name, req.Scopes)
+	switch {
+	case errors.Is(err, usermodel.ErrAPIKeysUnavailable):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, usermodel.ErrInvalidAPIKey):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "failed to create API key: %v", err)
+	}
+	if key == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return &pb.CreateAPIKeyResponse{ApiKey: ConvertAPIKeyToProto(apiKey), Key: key}, nil
+}
+
+func (s *AuthServer) ListAPIKeys(ctx context.Context, req *pb.AuthRequest) (*pb.ListAPIKeysResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	apiKeys, ok, err := s.storageManager.ListAPIKeys(req.Jwt)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list API keys: %v", err)
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	resp := &pb.ListAPIKeysResponse{}
+	for _, apiKey := range apiKeys {
+		resp.ApiKeys = append(resp.ApiKeys, ConvertAPIKeyToProto(apiKey))
+	}
+	return resp, nil
+}
+
+func (s *AuthServer) RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*pb.RevokeAPIKeyResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	keyId, err := uuid.Parse(req.KeyId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid API key ID")
+	}
+	ok, err := s.storageManager.RevokeAPIKey(req.Jwt, keyId)
+	switch {
+	case errors.Is(err, usermodel.ErrAPIKeyNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "failed to revoke API key: %v", err)
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return &pb.RevokeAPIKeyResponse{}, nil
+}
+
+func (s *AuthServer) AuthenticateAPIKey(ctx context.Context, req *pb.AuthenticateAPIKeyRequest) (*pb.APIKeyPrincipal, error) {
+	if req.Key == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing API key")
+	}
+	apiKey, user, err := s.storageManager.AuthenticateAPIKey(req.Key)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to authenticate API key: %v", err)
+	}
+	if user.Login == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+	principal := &pb.APIKeyPrincipal{
+		KeyId:         apiKey.ID.String(),
+		UserId:        user.ID.String(),
+		Role:          string(user.Role),
+		Scopes:        apiKey.Scopes,
+		EmailVerified: user.EmailVerified,
+	}
+	if user.OrganisationID != uuid.Nil {
+		principal.OrganisationId = user.OrganisationID.String()
+	}
+	return principal, nil
+}
+
+func ConvertSessionToProto(src usermodel.Session, currentId uuid.UUID) *pb.Session {
+	return &pb.Session{
+		Id:           src.ID.String(),
+		UserAgent:    src.UserAgent,
+		Ip:           src.IP,
+		CreationDate: src.CreationDate.Format(timeLayout),
+		LastSeenDate: src.LastSeenDate.Format(timeLayout),
+		Current:      src.ID == currentId,
+	}
+}
+
+func (s *AuthServer) ListSessions(ctx context.Context, req *pb.AuthRequest) (*pb.ListSessionsResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	sessions, currentId, err := s.storageManager.ListSessions(req.Jwt)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list sessions: %v", err)
+	}
+	if sessions == nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	resp := &pb.ListSessionsResponse{}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, ConvertSessionToProto(session, currentId))
+	}
+	return resp, nil
+}
+
+func (s *AuthServer) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	sessionId, err := uuid.Parse(req.SessionId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid session ID")
+	}
+	ok, err := s.storageManager.RevokeSession(req.Jwt, sessionId)
+	switch {
+	case errors.Is(err, usermodel.ErrSessionNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "failed to revoke session: %v", err)
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return &pb.RevokeSessionResponse{}, nil
+}
+
+func ConvertAuditEventToProto(src usermodel.AuditEvent) *pb.AuditEvent {
+	dst := &pb.AuditEvent{
+		Id:           src.ID.String(),
+		Login:        src.Login,
+		Action:       string(src.Action),
+		Ip:           src.IP,
+		Outcome:      string(src.Outcome),
+		CreationDate: src.CreationDate.Format(timeLayout),
+	}
+	if src.ActorID != uuid.Nil {
+		dst.ActorId = src.ActorID.String()
+	}
+	if src.UserID != uuid.Nil {
+		dst.UserId = src.UserID.String()
+	}
+	for _, change := range src.Changes {
+		dst.Changes = append(dst.Changes, &pb.FieldChange{Field: change.Field, Old: change.Old, New: change.New})
+	}
+	return dst
+}
+
+func parseAuditFilter(req *pb.ListAuditEventsRequest) (usermodel.AuditFilter, error) {
+	filter := usermodel.AuditFilter{Limit: int(req.Limit)}
+	var err error
+	if req.UserId != "" {
+		if filter.UserID, err = uuid.Parse(req.UserId); err != nil {
+			return filter, errors.New("invalid user ID")
+		}
+	}
+	if req.From != "" {
+		if filter.From, err = time.Parse(timeLayout, req.From); err != nil {
+			return filter, errors.New("from must be an RFC 3339 time")
+		}
+	}
+	if req.To != "" {
+		if filter.To, err = time.Parse(timeLayout, req.To); err != nil {
+			return filter, errors.New("to must be an RFC 3339 time")
+		}
+	}
+	return filter, nil
+}
+
+func (s *AuthServer) ListAuditEvents(ctx context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	filter, err := parseAuditFilter(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	events, ok, err := s.storageManager.ListAuditEvents(req.Jwt, filter)
+	switch {
+	case errors.Is(err, usermodel.ErrForbidden):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "failed to list audit events: %v", err)
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	resp := &pb.ListAuditEventsResponse{}
+	for _, event := range events {
+		resp.Events = append(resp.Events, ConvertAuditEventToProto(event))
+	}
+	return resp, nil
+}
+
+func (s *AuthServer) RequestPhoneVerification(ctx context.Context, req *pb.AuthRequest) (*pb.RequestPhoneVerificationResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	ok, err := s.storageManager.RequestPhoneVerification(req.Jwt)
+	switch {
+	case errors.Is(err, usermodel.ErrPhoneVerificationUnavailable):
+		return nil, status.Error(codes.Unimplemented, err.Error())
+	case errors.Is(err, usermodel.ErrNoPhoneNumber), errors.Is(err, usermodel.ErrPhoneNumberVerified):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, usermodel.ErrPhoneCodeRecentlySent):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "failed to send verification code: %v", err)
+	case !ok:
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return &pb.RequestPhoneVerificationResponse{}, nil
+}
+
+func (s *AuthServer) ConfirmPhoneNumber(ctx context.Context, req *pb.ConfirmPhoneNumberRequest) (*pb.User, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	user, err := s.storageManager.ConfirmPhoneNumber(req.Jwt, req.Code)
+	switch {
+	case errors.Is(err, usermodel.ErrWrongPhoneCode):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, usermodel.ErrPhoneNumberTaken):
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "failed to confirm phone number: %v", err)
+	case user.Login == "":
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return ConvertUserToProto(user), nil
+}
+
+func ConvertOrganisationMemberToProto(src usermodel.OrganisationMember) *pb.OrganisationMember {
+	return &pb.OrganisationMember{
+		UserId:       src.UserID.String(),
+		Login:        src.Login,
+		Email:        src.Email,
+		Role:         string(src.Role),
+		CreationDate: src.CreationDate.Format(timeLayout),
+	}
+}
+
+func ConvertOrganisationInvitationToProto(src usermodel.OrganisationInvitation) *pb.OrganisationInvitation {
+	return &pb.OrganisationInvitation{
+		Id:           src.ID.String(),
+		Email:        src.Email,
+		Role:         string(src.Role),
+		InvitedBy:    src.InvitedBy.String(),
+		ExpiresAt:    src.ExpiresAt.Format(timeLayout),
+		CreationDate: src.CreationDate.Format(timeLayout),
+	}
+}
+
+func ConvertOrganisationToProto(src usermodel.Organisation) *pb.Organisation {
+	dst := &pb.Organisation{
+		Id:           src.ID.String(),
+		Name:         src.Name,
+		CreationDate: src.CreationDate.Format(timeLayout),
+		UpdateDate:   src.UpdateDate.Format(timeLayout),
+	}
+	for _, member := range src.Members {
+		dst.Members = append(dst.Members, ConvertOrganisationMemberToProto(member))
+	}
+	for _, invitation := range src.Invitations {
+		dst.Invitations = append(dst.Invitations, ConvertOrganisationInvitationToProto(invitation))
+	}
+	return dst
+}
+
+func organisationErrorToStatus(err error, action string) error {
+	var validationErr *usermodel.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return invalidArgumentStatus(validationErr)
+	case errors.Is(err, usermodel.ErrForbidden), errors.Is(err, usermodel.ErrInvitationForOtherEmail):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, usermodel.ErrNotInOrganisation),
+		errors.Is(err, usermodel.ErrAlreadyInOrganisation),
+		errors.Is(err, usermodel.ErrOrganisationOwner):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, usermodel.ErrMemberNotFound), errors.Is(err, usermodel.ErrInvitationNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, usermodel.ErrInvalidMemberRole), errors.Is(err, usermodel.ErrInvalidOrganisationName):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Errorf(codes.Internal, "failed to %s: %v", action, err)
+}
+
+func (s *AuthServer) GetOrganisation(ctx context.Context, req *pb.AuthRequest) (*pb.Organisation, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	organisation, err := s.storageManager.GetOrganisation(req.Jwt)
+	if err != nil {
+		return nil, organisationErrorToStatus(err, "get organisation")
+	}
+	if organisation.ID == uuid.Nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return ConvertOrganisationToProto(organisation), nil
+}
+
+func (s *AuthServer) RenameOrganisation(ctx context.Context, req *pb.RenameOrganisationRequest) (*pb.Organisation, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	organisation, err := s.storageManager.RenameOrganisation(req.Jwt, req.Name)
+	if err != nil {
+		return nil, organisationErrorToStatus(err, "rename organisation")
+	}
+	if organisation.ID == uuid.Nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return ConvertOrganisationToProto(organisation), nil
+}
+
+func (s *AuthServer) InviteMember(ctx context.Context, req *pb.InviteMemberRequest) (*pb.OrganisationInvitation, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	invitation, err := s.storageManager.InviteMember(req.Jwt, req.Email, usermodel.OrganisationRole(req.Role))
+	if err != nil {
+		return nil, organisationErrorToStatus(err, "invite member")
+	}
+	if invitation.ID == uuid.Nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return ConvertOrganisationInvitationToProto(invitation), nil
+}
+
+func (s *AuthServer) CancelInvitation(ctx context.Context, req *pb.CancelInvitationRequest) (*pb.CancelInvitationResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	invitationId, err := uuid.Parse(req.InvitationId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid invitation ID")
+	}
+	ok, err := s.storageManager.CancelInvitation(req.Jwt, invitationId)
+	if err != nil {
+		return nil, organisationErrorToStatus(err, "cancel invitation")
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return &pb.CancelInvitationResponse{}, nil
+}
+
+func (s *AuthServer) AcceptInvitation(ctx context.Context, req *pb.AcceptInvitationRequest) (*pb.LoginResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	if req.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing invitation token")
+	}
+	tokens, err := s.storageManager.AcceptInvitation(req.Jwt, req.Token, clientInfo(ctx))
+	if err != nil {
+		return nil, organisationErrorToStatus(err, "accept invitation")
+	}
+	if tokens.AccessToken == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return ConvertTokensToProto(tokens), nil
+}
+
+func (s *AuthServer) SetMemberRole(ctx context.Context, req *pb.SetMemberRoleRequest) (*pb.OrganisationMember, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	userId, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user id: %v", err)
+	}
+	member, err := s.storageManager.SetMemberRole(req.Jwt, userId, usermodel.OrganisationRole(req.Role))
+	if err != nil {
+		return nil, organisationErrorToStatus(err, "set member role")
+	}
+	if member.UserID == uuid.Nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return ConvertOrganisationMemberToProto(member), nil
+}
+
+func (s *AuthServer) RemoveMember(ctx context.Context, req *pb.RemoveMemberRequest) (*pb.RemoveMemberResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	userId, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user id: %v", err)
+	}
+	ok, err := s.storageManager.RemoveMember(req.Jwt, userId)
+	if err != nil {
+		return nil, organisationErrorToStatus(err, "remove member")
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return &pb.RemoveMemberResponse{}, nil
+}
+
+func (s *AuthServer) TransferOwnership(ctx context.Context, req *pb.TransferOwnershipRequest) (*pb.Organisation, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	userId, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user id: %v", err)
+	}
+	organisation, err := s.storageManager.TransferOwnership(req.Jwt, userId)
+	if err != nil {
+		return nil, organisationErrorToStatus(err, "transfer ownership")
+	}
+	if organisation.ID == uuid.Nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return ConvertOrganisationToProto(organisation), nil
+}
+
+func adminErrorToStatus(err error, action string) error {
+	var validationErr *usermodel.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return invalidArgumentStatus(validationErr)
+	case errors.Is(err, usermodel.ErrForbidden):
+		return status.Error(codes.PermissionDenied, "only platform admins can manage users")
+	case errors.Is(err, usermodel.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, usermodel.ErrUserExists):
+		return status.Error(codes.AlreadyExists, "email is registered to another user")
+	case errors.Is(err, usermodel.ErrSuspendSelf):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Errorf(codes.Internal, "failed to %s: %v", action, err)
+}
+
+func parseUserFilter(req *pb.SearchUsersRequest) (usermodel.UserFilter, error) {
+	filter := usermodel.UserFilter{
+		Login:     req.Login,
+		Email:     req.Email,
+		IsCompany: req.IsCompany,
+		Offset:    int(req.Offset),
+		Limit:     int(req.Limit),
+	}
+	var err error
+	if req.CreatedFrom != "" {
+		if filter.CreatedFrom, err = time.Parse(timeLayout, req.CreatedFrom); err != nil {
+			return filter, errors.New("created_from must be an RFC 3339 time")
+		}
+	}
+	if req.CreatedTo != "" {
+		if filter.CreatedTo, err = time.Parse(timeLayout, req.CreatedTo); err != nil {
+			return filter, errors.New("created_to must be an RFC 3339 time")
+		}
+	}
+	return filter, nil
+}
+
+func (s *AuthServer) SearchUsers(ctx context.Context, req *pb.SearchUsersRequest) (*pb.SearchUsersResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	filter, err := parseUserFilter(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	users, ok, err := s.storageManager.SearchUsers(req.Jwt, filter)
+	if err != nil {
+		return nil, adminErrorToStatus(err, "search users")
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	resp := &pb.SearchUsersResponse{}
+	for _, user := range users {
+		resp.Users = append(resp.Users, ConvertUserToProto(user))
+	}
+	return resp, nil
+}
+
+func (s *AuthServer) AdminGetUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.User, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	id, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user id: %v", err)
+	}
+	user, err := s.storageManager.AdminGetUser(req.Jwt, id)
+	if err != nil {
+		return nil, adminErrorToStatus(err, "get user")
+	}
+	if user.Login == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return ConvertUserToProto(user), nil
+}
+
+func (s *AuthServer) AdminUpdateUser(ctx context.Context, req *pb.AdminUpdateUserRequest) (*pb.User, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	id, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user id: %v", err)
+	}
+	user, err := s.storageManager.AdminUpdateUser(req.Jwt, id, ConvertProtoToUser(req.NewInfo))
+	if err != nil {
+		return nil, adminErrorToStatus(err, "update user")
+	}
+	if user.Login == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return ConvertUserToProto(user), nil
+}
+
+func (s *AuthServer) setUserSuspended(req *pb.AdminUserRequest, suspended bool) (*pb.User, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	id, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user id: %v", err)
+	}
+	user, err := s.storageManager.SetUserSuspended(req.Jwt, id, suspended)
+	if err != nil {
+		return nil, adminErrorToStatus(err, "update suspension")
+	}
+	if user.Login == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return ConvertUserToProto(user), nil
+}
+
+func (s *AuthServer) SuspendUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.User, error) {
+	return s.setUserSuspended(req, true)
+}
+
+func (s *AuthServer) UnsuspendUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.User, error) {
+	return s.setUserSuspended(req, false)
+}
+
+func (s *AuthServer) ForceLogout(ctx context.Context, req *pb.AdminUserRequest) (*pb.ForceLogoutResponse, error) {
+	if req.Jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	id, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user id: %v", err)
+	}
+	ok, err := s.storageManager.ForceLogout(req.Jwt, id)
+	if err != nil {
+		return nil, adminErrorToStatus(err, "force logout")
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid JWT")
+	}
+	return &pb.ForceLogoutResponse{}, nil
+}
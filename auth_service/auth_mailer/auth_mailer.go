@@ -0,0 +1,142 @@
+package authmailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+func formatMessage(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPMailer{addr: net.JoinHostPort(host, fmt.Sprint(port)), from: from, auth: auth}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, formatMessage(m.from, msg))
+}
+
+// FileMailer writes every message as an .eml file for local development.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405"), uuid.New())
+	return os.WriteFile(filepath.Join(m.dir, name), formatMessage(m.from, msg), 0644)
+}
+
+type MemoryMailer struct {
+	messages []Message
+	mx       sync.Mutex
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, msg Message) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func (m *MemoryMailer) Messages() []Message {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// Notifier renders the emails sent by the auth service. Links point to the
+// public gateway address.
+type Notifier struct {
+	mailer  Mailer
+	baseURL string
+}
+
+func NewNotifier(mailer Mailer, baseURL string) *Notifier {
+	return &Notifier{mailer: mailer, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (n *Notifier) link(path, token string) string {
+	return fmt.Sprintf("%s%s?token=%s", n.baseURL, path, url.QueryEscape(token))
+}
+
+func (n *Notifier) SendEmailVerification(ctx context.Context, to, token string) error {
+	return n.mailer.Send(ctx, Message{
+		To:      to,
+		Subject: "Confirm your email address",
+		Body: "Please confirm your email address by opening the link below:\n\n" +
+			n.link("/api/v1/verify_email", token) + "\n\n" +
+			"If you did not create an account, ignore this message.\n",
+	})
+}
+
+func (n *Notifier) SendPasswordReset(ctx context.Context, to, token string) error {
+	return n.mailer.Send(ctx, Message{
+		To:      to,
+		Subject: "Reset your password",
+		Body: "A password reset was requested for your account. Open the link below to choose a new password:\n\n" +
+			n.link("/api/v1/password/reset", token) + "\n\n" +
+			"The link expires in one hour. If you did not request a reset, ignore this message.\n",
+	})
+}
+
+func (n *Notifier) SendOrganisationInvitation(ctx context.Context, to, organisation, token string) error {
+	return n.mailer.Send(ctx, Message{
+		To:      to,
+		Subject: fmt.Sprintf("You are invited to join %s", organisation),
+		Body: fmt.Sprintf("You are invited to manage the promos of %s. ", organisation) +
+			"Sign in with an account registered to this email address and open the link below to accept:\n\n" +
+			n.link("/accept_invitation", token) + "\n\n" +
+			"The link expires in seven days. If you do not know the organisation, ignore this message.\n",
+	})
+}
@@ -0,0 +1,148 @@
+package authoutbox
+
+import (
+	usermodel "authservice/auth_storage/user_model"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const batchSize = 100
+
+// Storage is the part of smimpl.Storage the relay reads the outbox through.
+type Storage interface {
+	GetOutboxMessages(limit int) ([]usermodel.OutboxMessage, error)
+	DeleteOutboxMessages(ids []int64) error
+}
+
+// Writer returns once the broker acknowledged every message, or fails and may
+// have delivered some of them.
+type Writer interface {
+	Write(ctx context.Context, messages []usermodel.OutboxMessage) error
+}
+
+// Relay publishes the outbox. A message is deleted only after it was
+// acknowledged, so messages of a failed batch are published again, possibly
+// twice; consumers must be idempotent. Several instances of the service may
+// publish the same message as well.
+type Relay struct {
+	storage  Storage
+	writer   Writer
+	interval time.Duration
+}
+
+func NewRelay(storage Storage, writer Writer, interval time.Duration) *Relay {
+	return &Relay{storage: storage, writer: writer, interval: interval}
+}
+
+// Flush publishes the outbox in batches until it is empty and returns how
+// many messages were published.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		messages, err := r.storage.GetOutboxMessages(batchSize)
+		if err != nil || len(messages) == 0 {
+			return published, err
+		}
+		if err := r.writer.Write(ctx, messages); err != nil {
+			return published, fmt.Errorf("publishing %d messages: %w", len(messages), err)
+		}
+		ids := make([]int64, 0, len(messages))
+		for _, message := range messages {
+			ids = append(ids, message.ID)
+		}
+		if err := r.storage.DeleteOutboxMessages(ids); err != nil {
+			return published, err
+		}
+		published += len(messages)
+	}
+}
+
+// Run flushes the outbox every interval until ctx is done, retrying failed
+// messages on the next tick.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.Flush(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to relay the outbox: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// KafkaWriter writes synchronously and waits for all in-sync replicas.
+type KafkaWriter struct {
+	writer *kafka.Writer
+	topics map[usermodel.OutboxTopic]string
+}
+
+func NewKafkaWriter(brokers []string, topics map[usermodel.OutboxTopic]string) *KafkaWriter {
+	return &KafkaWriter{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+		topics: topics,
+	}
+}
+
+func (w *KafkaWriter) Write(ctx context.Context, messages []usermodel.OutboxMessage) error {
+	kafkaMessages := make([]kafka.Message, 0, len(messages))
+	for _, message := range messages {
+		topic, ok := w.topics[message.Topic]
+		if !ok {
+			return fmt.Errorf("no Kafka topic configured for %q", message.Topic)
+		}
+		kafkaMessages = append(kafkaMessages, kafka.Message{Topic: topic, Key: []byte(message.Key), Value: message.Value})
+	}
+	return w.writer.WriteMessages(ctx, kafkaMessages...)
+}
+
+func (w *KafkaWriter) Close() error {
+	return w.writer.Close()
+}
+
+// MemoryWriter keeps the messages for tests and fails while an error is set.
+type MemoryWriter struct {
+	messages []usermodel.OutboxMessage
+	err      error
+	mx       sync.Mutex
+}
+
+func NewMemoryWriter() *MemoryWriter {
+	return &MemoryWriter{}
+}
+
+func (w *MemoryWriter) Write(ctx context.Context, messages []usermodel.OutboxMessage) error {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, messages...)
+	return nil
+}
+
+func (w *MemoryWriter) SetError(err error) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	w.err = err
+}
+
+func (w *MemoryWriter) Messages() []usermodel.OutboxMessage {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	return slices.Clone(w.messages)
+}
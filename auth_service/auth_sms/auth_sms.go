@@ -0,0 +1,103 @@
+package authsms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// HTTPSender posts every message as JSON to an SMS gateway, authenticated
+// with a bearer token.
+type HTTPSender struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewHTTPSender(url, token string) *HTTPSender {
+	return &HTTPSender{url: url, token: token, client: &http.Client{Timeout: time.Second * 10}}
+}
+
+func (s *HTTPSender) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("SMS gateway answered %s", resp.Status)
+	}
+	return nil
+}
+
+// LogSender writes every message to the service log for local development.
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("SMS to %s: %s", msg.To, msg.Text)
+	return nil
+}
+
+type MemorySender struct {
+	messages []Message
+	mx       sync.Mutex
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) Send(ctx context.Context, msg Message) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func (s *MemorySender) Messages() []Message {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Notifier renders the text messages sent by the auth service.
+type Notifier struct {
+	sender Sender
+}
+
+func NewNotifier(sender Sender) *Notifier {
+	return &Notifier{sender: sender}
+}
+
+func (n *Notifier) SendVerificationCode(ctx context.Context, to, code string) error {
+	return n.sender.Send(ctx, Message{
+		To:   to,
+		Text: fmt.Sprintf("Loyalty Platform verification code: %s. Do not share it with anyone.", code),
+	})
+}
@@ -0,0 +1,56 @@
+package loginthrottle
+
+import (
+	usermodel "authservice/auth_storage/user_model"
+	"time"
+)
+
+// Policy locks a key out after FreeAttempts consecutive failures. Every
+// further failure doubles the lockout, up to MaxLockout. Failures older than
+// ResetAfter are forgotten.
+type Policy struct {
+	LoginFreeAttempts int
+	IPFreeAttempts    int
+	BaseLockout       time.Duration
+	MaxLockout        time.Duration
+	ResetAfter        time.Duration
+}
+
+var DefaultPolicy = Policy{
+	LoginFreeAttempts: 5,
+	IPFreeAttempts:    50,
+	BaseLockout:       30 * time.Second,
+	MaxLockout:        15 * time.Minute,
+	ResetAfter:        24 * time.Hour,
+}
+
+func LoginKey(login string) string {
+	return "login:" + login
+}
+
+func IPKey(clientIP string) string {
+	return "ip:" + clientIP
+}
+
+// TwoFactorKey counts wrong second factors apart from passwords, since a
+// correct password resets the login key.
+func TwoFactorKey(userId string) string {
+	return "two_factor:" + userId
+}
+
+// RetryAfter returns how long the key stays locked out, zero if it is not.
+func (p Policy) RetryAfter(attempts usermodel.LoginAttempts, freeAttempts int, now time.Time) time.Duration {
+	if attempts.Failures == 0 || now.Sub(attempts.LastFailure) > p.ResetAfter {
+		return 0
+	}
+	over := attempts.Failures - freeAttempts
+	if over < 0 {
+		return 0
+	}
+	lockout := p.BaseLockout
+	for i := 0; i < over && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+	lockout = min(lockout, p.MaxLockout)
+	return max(attempts.LastFailure.Add(lockout).Sub(now), 0)
+}
@@ -2,31 +2,102 @@ package mockstorage
 
 import (
 	smimpl "authservice/auth_storage/storage_manager"
-	userkeys "authservice/auth_storage/user_keys"
 	usermodel "authservice/auth_storage/user_model"
+	"bytes"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type credentialsData struct {
-	Password [userkeys.Md5Len]byte
+	Password []byte
 	UserId   uuid.UUID
 }
 
 type MockStorage struct {
-	data        map[uuid.UUID]usermodel.User
-	credentials map[string]credentialsData
-	mx          sync.RWMutex
+	data             map[uuid.UUID]usermodel.User
+	credentials      map[string]credentialsData
+	refreshTokens    map[uuid.UUID]usermodel.RefreshToken
+	revokedTokens    map[uuid.UUID]time.Time
+	tokenGenerations map[uuid.UUID]int64
+	twoFactors       map[uuid.UUID]usermodel.TwoFactor
+	loginAttempts    map[string]usermodel.LoginAttempts
+	apiKeys          map[uuid.UUID]usermodel.APIKey
+	sessions         map[uuid.UUID]usermodel.Session
+	auditEvents      []usermodel.AuditEvent
+	outbox           []usermodel.OutboxMessage
+	outboxID         int64
+	phoneCodes       map[uuid.UUID]usermodel.PhoneVerification
+	organisations    map[uuid.UUID]usermodel.Organisation
+	members          map[uuid.UUID]usermodel.OrganisationMember
+	invitations      map[uuid.UUID]usermodel.OrganisationInvitation
+	mx               sync.RWMutex
+	txMx             sync.Mutex
 }
 
-func (ms *MockStorage) GetUserPasswordByLogin(login string) ([userkeys.Md5Len]byte, bool, error) {
+// Transaction runs units of work one at a time and restores a snapshot of the
+// storage if fn fails. Writes made outside transactions meanwhile are lost on
+// rollback, which is fine for tests.
+func (ms *MockStorage) Transaction(fn func(tx smimpl.Storage) error) error {
+	ms.txMx.Lock()
+	defer ms.txMx.Unlock()
+	ms.mx.RLock()
+	snapshot := &MockStorage{
+		data:             maps.Clone(ms.data),
+		credentials:      maps.Clone(ms.credentials),
+		refreshTokens:    maps.Clone(ms.refreshTokens),
+		revokedTokens:    maps.Clone(ms.revokedTokens),
+		tokenGenerations: maps.Clone(ms.tokenGenerations),
+		twoFactors:       maps.Clone(ms.twoFactors),
+		loginAttempts:    maps.Clone(ms.loginAttempts),
+		apiKeys:          maps.Clone(ms.apiKeys),
+		sessions:         maps.Clone(ms.sessions),
+		auditEvents:      slices.Clone(ms.auditEvents),
+		outbox:           slices.Clone(ms.outbox),
+		phoneCodes:       maps.Clone(ms.phoneCodes),
+		organisations:    maps.Clone(ms.organisations),
+		members:          maps.Clone(ms.members),
+		invitations:      maps.Clone(ms.invitations),
+	}
+	ms.mx.RUnlock()
+	err := fn(ms)
+	if err != nil {
+		ms.mx.Lock()
+		defer ms.mx.Unlock()
+		ms.data, ms.credentials = snapshot.data, snapshot.credentials
+		ms.refreshTokens, ms.revokedTokens, ms.tokenGenerations = snapshot.refreshTokens, snapshot.revokedTokens, snapshot.tokenGenerations
+		ms.twoFactors, ms.loginAttempts, ms.apiKeys = snapshot.twoFactors, snapshot.loginAttempts, snapshot.apiKeys
+		ms.sessions, ms.auditEvents, ms.phoneCodes = snapshot.sessions, snapshot.auditEvents, snapshot.phoneCodes
+		ms.organisations, ms.members, ms.invitations = snapshot.organisations, snapshot.members, snapshot.invitations
+		ms.outbox = snapshot.outbox
+	}
+	return err
+}
+
+func (ms *MockStorage) GetUserPasswordByLogin(login string) ([]byte, bool, error) {
 	ms.mx.RLock()
 	defer ms.mx.RUnlock()
 	cd, ok := ms.credentials[login]
 	return cd.Password, ok, nil
 }
 
+func (ms *MockStorage) UpdateUserPassword(login string, password []byte) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	cd, ok := ms.credentials[login]
+	if !ok {
+		return nil
+	}
+	cd.Password = password
+	ms.credentials[login] = cd
+	return nil
+}
+
 func (ms *MockStorage) GetUserById(userId uuid.UUID) (usermodel.User, error) {
 	ms.mx.RLock()
 	defer ms.mx.RUnlock()
@@ -43,6 +114,52 @@ func (ms *MockStorage) GetUserByLogin(login string) (usermodel.User, error) {
 	return ms.data[cd.UserId], nil
 }
 
+// GetUserByEmail matches emails ignoring case and prefers an exact match.
+func (ms *MockStorage) GetUserByEmail(email string) (usermodel.User, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	for _, user := range ms.data {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return usermodel.User{}, nil
+}
+
+func (ms *MockStorage) GetUserByVerifiedPhoneNumber(phoneNumber string) (usermodel.User, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	for _, user := range ms.data {
+		if user.PhoneNumberVerified && user.PhoneNumber == phoneNumber {
+			return user, nil
+		}
+	}
+	return usermodel.User{}, nil
+}
+
+func (ms *MockStorage) SearchUsers(filter usermodel.UserFilter) ([]usermodel.User, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	var users []usermodel.User
+	for _, user := range ms.data {
+		if strings.Contains(strings.ToLower(user.Login), strings.ToLower(filter.Login)) &&
+			strings.Contains(strings.ToLower(user.Email), strings.ToLower(filter.Email)) &&
+			(filter.IsCompany == nil || user.IsCompany == *filter.IsCompany) &&
+			(filter.CreatedFrom.IsZero() || !user.CreationDate.Before(filter.CreatedFrom)) &&
+			(filter.CreatedTo.IsZero() || user.CreationDate.Before(filter.CreatedTo)) {
+			users = append(users, user)
+		}
+	}
+	slices.SortFunc(users, func(a, b usermodel.User) int {
+		if order := a.CreationDate.Compare(b.CreationDate); order != 0 {
+			return order
+		}
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	users = users[min(filter.Offset, len(users)):]
+	return users[:min(filter.Limit, len(users))], nil
+}
+
 func (ms *MockStorage) GetNewUUID() uuid.UUID {
 	userId := uuid.New()
 	for ms.data[userId].Login != "" {
@@ -51,10 +168,18 @@ func (ms *MockStorage) GetNewUUID() uuid.UUID {
 	return userId
 }
 
-func (ms *MockStorage) AddUser(user usermodel.User, login string, password [userkeys.Md5Len]byte) (uuid.UUID, error) {
+func (ms *MockStorage) AddUser(user usermodel.User, login string, password []byte) (uuid.UUID, error) {
 	user.ID = ms.GetNewUUID()
 	ms.mx.Lock()
 	defer ms.mx.Unlock()
+	if _, ok := ms.credentials[login]; ok {
+		return uuid.UUID{}, usermodel.ErrUserExists
+	}
+	for _, existing := range ms.data {
+		if strings.EqualFold(existing.Email, user.Email) {
+			return uuid.UUID{}, usermodel.ErrUserExists
+		}
+	}
 	ms.credentials[login] = credentialsData{Password: password, UserId: user.ID}
 	ms.data[user.ID] = user
 	return user.ID, nil
@@ -63,13 +188,542 @@ func (ms *MockStorage) AddUser(user usermodel.User, login string, password [user
 func (ms *MockStorage) UpdateUser(user usermodel.User) error {
 	ms.mx.Lock()
 	defer ms.mx.Unlock()
+	for _, existing := range ms.data {
+		if existing.ID == user.ID {
+			continue
+		}
+		if strings.EqualFold(existing.Email, user.Email) ||
+			user.PhoneNumberVerified && existing.PhoneNumberVerified && existing.PhoneNumber == user.PhoneNumber {
+			return usermodel.ErrUserExists
+		}
+	}
 	ms.data[user.ID] = user
 	return nil
 }
 
+func (ms *MockStorage) DeleteUser(userId uuid.UUID) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	delete(ms.data, userId)
+	for login, cd := range ms.credentials {
+		if cd.UserId == userId {
+			delete(ms.credentials, login)
+		}
+	}
+	for id, token := range ms.refreshTokens {
+		if token.UserID == userId {
+			delete(ms.refreshTokens, id)
+		}
+	}
+	delete(ms.tokenGenerations, userId)
+	delete(ms.twoFactors, userId)
+	for id, apiKey := range ms.apiKeys {
+		if apiKey.UserID == userId {
+			delete(ms.apiKeys, id)
+		}
+	}
+	for id, session := range ms.sessions {
+		if session.UserID == userId {
+			delete(ms.sessions, id)
+		}
+	}
+	delete(ms.phoneCodes, userId)
+	delete(ms.members, userId)
+	return nil
+}
+
+func (ms *MockStorage) AddRefreshToken(token usermodel.RefreshToken) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	ms.refreshTokens[token.ID] = token
+	return nil
+}
+
+func (ms *MockStorage) GetRefreshToken(tokenHash []byte) (usermodel.RefreshToken, bool, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	for _, token := range ms.refreshTokens {
+		if string(token.TokenHash) == string(tokenHash) {
+			return token, true, nil
+		}
+	}
+	return usermodel.RefreshToken{}, false, nil
+}
+
+func (ms *MockStorage) RevokeRefreshToken(tokenId uuid.UUID) (bool, error) {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	token, ok := ms.refreshTokens[tokenId]
+	if !ok || !token.RevokedAt.IsZero() {
+		return false, nil
+	}
+	token.RevokedAt = time.Now()
+	ms.refreshTokens[tokenId] = token
+	return true, nil
+}
+
+func (ms *MockStorage) RevokeUserRefreshTokens(userId uuid.UUID) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	curTime := time.Now()
+	for id, token := range ms.refreshTokens {
+		if token.UserID == userId && token.RevokedAt.IsZero() {
+			token.RevokedAt = curTime
+			ms.refreshTokens[id] = token
+		}
+	}
+	return nil
+}
+
+func (ms *MockStorage) RevokeToken(tokenId uuid.UUID, expiresAt time.Time) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	ms.revokedTokens[tokenId] = expiresAt
+	return nil
+}
+
+func (ms *MockStorage) IsTokenRevoked(tokenId uuid.UUID) (bool, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	_, ok := ms.revokedTokens[tokenId]
+	return ok, nil
+}
+
+func (ms *MockStorage) RevokeUserTokens(userId uuid.UUID) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	ms.tokenGenerations[userId]++
+	return nil
+}
+
+func (ms *MockStorage) GetUserTokenGeneration(userId uuid.UUID) (int64, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	return ms.tokenGenerations[userId], nil
+}
+
+func (ms *MockStorage) GetTwoFactor(userId uuid.UUID) (usermodel.TwoFactor, bool, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	twoFactor, ok := ms.twoFactors[userId]
+	twoFactor.RecoveryCodes = slices.Clone(twoFactor.RecoveryCodes)
+	return twoFactor, ok, nil
+}
+
+func (ms *MockStorage) SaveTwoFactor(twoFactor usermodel.TwoFactor) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	twoFactor.RecoveryCodes = slices.Clone(twoFactor.RecoveryCodes)
+	ms.twoFactors[twoFactor.UserID] = twoFactor
+	return nil
+}
+
+func (ms *MockStorage) UseTwoFactorStep(userId uuid.UUID, step int64) (bool, error) {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	twoFactor, ok := ms.twoFactors[userId]
+	if !ok || twoFactor.LastUsedStep >= step {
+		return false, nil
+	}
+	twoFactor.LastUsedStep = step
+	ms.twoFactors[userId] = twoFactor
+	return true, nil
+}
+
+func (ms *MockStorage) UseRecoveryCode(userId uuid.UUID, codeHash string) (bool, error) {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	twoFactor, ok := ms.twoFactors[userId]
+	i := slices.Index(twoFactor.RecoveryCodes, codeHash)
+	if !ok || i == -1 {
+		return false, nil
+	}
+	twoFactor.RecoveryCodes = slices.Delete(slices.Clone(twoFactor.RecoveryCodes), i, i+1)
+	ms.twoFactors[userId] = twoFactor
+	return true, nil
+}
+
+func (ms *MockStorage) DeleteTwoFactor(userId uuid.UUID) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	delete(ms.twoFactors, userId)
+	return nil
+}
+
+func (ms *MockStorage) GetLoginAttempts(key string) (usermodel.LoginAttempts, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	return ms.loginAttempts[key], nil
+}
+
+func (ms *MockStorage) AddLoginFailure(key string, failedAt, resetBefore time.Time) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	attempts, ok := ms.loginAttempts[key]
+	if !ok || attempts.LastFailure.Before(resetBefore) {
+		attempts = usermodel.LoginAttempts{Key: key}
+	}
+	attempts.Failures++
+	attempts.LastFailure = failedAt
+	ms.loginAttempts[key] = attempts
+	return nil
+}
+
+func (ms *MockStorage) ResetLoginAttempts(key string) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	delete(ms.loginAttempts, key)
+	return nil
+}
+
+func (ms *MockStorage) DeleteLoginAttempts(resetBefore time.Time) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	maps.DeleteFunc(ms.loginAttempts, func(_ string, attempts usermodel.LoginAttempts) bool {
+		return attempts.LastFailure.Before(resetBefore)
+	})
+	return nil
+}
+
+func (ms *MockStorage) AddAPIKey(apiKey usermodel.APIKey) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	apiKey.Scopes = slices.Clone(apiKey.Scopes)
+	ms.apiKeys[apiKey.ID] = apiKey
+	return nil
+}
+
+func (ms *MockStorage) GetAPIKeyByHash(keyHash []byte) (usermodel.APIKey, bool, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	for _, apiKey := range ms.apiKeys {
+		if bytes.Equal(apiKey.KeyHash, keyHash) {
+			apiKey.Scopes = slices.Clone(apiKey.Scopes)
+			return apiKey, true, nil
+		}
+	}
+	return usermodel.APIKey{}, false, nil
+}
+
+func (ms *MockStorage) GetUserAPIKeys(userId uuid.UUID) ([]usermodel.APIKey, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	var apiKeys []usermodel.APIKey
+	for _, apiKey := range ms.apiKeys {
+		if apiKey.UserID == userId {
+			apiKey.Scopes = slices.Clone(apiKey.Scopes)
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+	slices.SortFunc(apiKeys, func(a, b usermodel.APIKey) int {
+		return a.CreationDate.Compare(b.CreationDate)
+	})
+	return apiKeys, nil
+}
+
+func (ms *MockStorage) DeleteAPIKey(userId, keyId uuid.UUID) (bool, error) {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	apiKey, ok := ms.apiKeys[keyId]
+	if !ok || apiKey.UserID != userId {
+		return false, nil
+	}
+	delete(ms.apiKeys, keyId)
+	return true, nil
+}
+
+func (ms *MockStorage) UpdateAPIKeyLastUsed(keyId uuid.UUID, usedAt time.Time) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	if apiKey, ok := ms.apiKeys[keyId]; ok {
+		apiKey.LastUsedDate = usedAt
+		ms.apiKeys[keyId] = apiKey
+	}
+	return nil
+}
+
+func (ms *MockStorage) AddSession(session usermodel.Session) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	ms.sessions[session.ID] = session
+	return nil
+}
+
+func (ms *MockStorage) GetSession(sessionId uuid.UUID) (usermodel.Session, bool, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	session, ok := ms.sessions[sessionId]
+	return session, ok, nil
+}
+
+func (ms *MockStorage) GetUserSessions(userId uuid.UUID) ([]usermodel.Session, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	var sessions []usermodel.Session
+	for _, session := range ms.sessions {
+		if session.UserID == userId {
+			sessions = append(sessions, session)
+		}
+	}
+	slices.SortFunc(sessions, func(a, b usermodel.Session) int {
+		return b.LastSeenDate.Compare(a.LastSeenDate)
+	})
+	return sessions, nil
+}
+
+func (ms *MockStorage) RevokeSession(userId, sessionId uuid.UUID, revokedAt time.Time) (bool, error) {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	session, ok := ms.sessions[sessionId]
+	if !ok || session.UserID != userId || !session.RevokedAt.IsZero() {
+		return false, nil
+	}
+	session.RevokedAt = revokedAt
+	ms.sessions[sessionId] = session
+	for id, token := range ms.refreshTokens {
+		if token.SessionID == sessionId && token.RevokedAt.IsZero() {
+			token.RevokedAt = revokedAt
+			ms.refreshTokens[id] = token
+		}
+	}
+	return true, nil
+}
+
+func (ms *MockStorage) UpdateSessionLastSeen(sessionId uuid.UUID, seenAt time.Time) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	if session, ok := ms.sessions[sessionId]; ok {
+		session.LastSeenDate = seenAt
+		ms.sessions[sessionId] = session
+	}
+	return nil
+}
+
+func (ms *MockStorage) AddAuditEvent(event usermodel.AuditEvent) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	event.Changes = slices.Clone(event.Changes)
+	ms.auditEvents = append(ms.auditEvents, event)
+	return nil
+}
+
+func (ms *MockStorage) GetAuditEvents(filter usermodel.AuditFilter) ([]usermodel.AuditEvent, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	var events []usermodel.AuditEvent
+	for i := len(ms.auditEvents) - 1; i >= 0 && len(events) < filter.Limit; i-- {
+		event := ms.auditEvents[i]
+		if (filter.UserID == uuid.Nil || event.UserID == filter.UserID) &&
+			(filter.From.IsZero() || !event.CreationDate.Before(filter.From)) &&
+			(filter.To.IsZero() || event.CreationDate.Before(filter.To)) {
+			event.Changes = slices.Clone(event.Changes)
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (ms *MockStorage) AddOutboxMessage(message usermodel.OutboxMessage) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	ms.outboxID++
+	message.ID = ms.outboxID
+	ms.outbox = append(ms.outbox, message)
+	return nil
+}
+
+func (ms *MockStorage) GetOutboxMessages(limit int) ([]usermodel.OutboxMessage, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	return slices.Clone(ms.outbox[:min(limit, len(ms.outbox))]), nil
+}
+
+func (ms *MockStorage) DeleteOutboxMessages(ids []int64) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	ms.outbox = slices.DeleteFunc(ms.outbox, func(message usermodel.OutboxMessage) bool {
+		return slices.Contains(ids, message.ID)
+	})
+	return nil
+}
+
+func (ms *MockStorage) GetPhoneVerification(userId uuid.UUID) (usermodel.PhoneVerification, bool, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	verification, ok := ms.phoneCodes[userId]
+	return verification, ok, nil
+}
+
+func (ms *MockStorage) SavePhoneVerification(verification usermodel.PhoneVerification) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	ms.phoneCodes[verification.UserID] = verification
+	return nil
+}
+
+func (ms *MockStorage) DeletePhoneVerification(userId uuid.UUID) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	delete(ms.phoneCodes, userId)
+	return nil
+}
+
+func (ms *MockStorage) AddOrganisation(organisation usermodel.Organisation) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	ms.organisations[organisation.ID] = organisation
+	return nil
+}
+
+func (ms *MockStorage) GetOrganisation(organisationId uuid.UUID) (usermodel.Organisation, bool, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	organisation, ok := ms.organisations[organisationId]
+	return organisation, ok, nil
+}
+
+func (ms *MockStorage) UpdateOrganisation(organisation usermodel.Organisation) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	if existing, ok := ms.organisations[organisation.ID]; ok {
+		existing.Name, existing.UpdateDate = organisation.Name, organisation.UpdateDate
+		ms.organisations[organisation.ID] = existing
+	}
+	return nil
+}
+
+func (ms *MockStorage) DeleteOrganisation(organisationId uuid.UUID) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	delete(ms.organisations, organisationId)
+	for userId, member := range ms.members {
+		if member.OrganisationID == organisationId {
+			delete(ms.members, userId)
+		}
+	}
+	for id, invitation := range ms.invitations {
+		if invitation.OrganisationID == organisationId {
+			delete(ms.invitations, id)
+		}
+	}
+	return nil
+}
+
+func (ms *MockStorage) GetMembership(userId uuid.UUID) (usermodel.OrganisationMember, bool, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	member, ok := ms.members[userId]
+	return member, ok, nil
+}
+
+func (ms *MockStorage) GetOrganisationMembers(organisationId uuid.UUID) ([]usermodel.OrganisationMember, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	var members []usermodel.OrganisationMember
+	for _, member := range ms.members {
+		if member.OrganisationID == organisationId {
+			members = append(members, member)
+		}
+	}
+	slices.SortFunc(members, func(a, b usermodel.OrganisationMember) int {
+		return a.CreationDate.Compare(b.CreationDate)
+	})
+	return members, nil
+}
+
+// SaveMembership enforces a single owner like the partial unique index of the
+// real storages.
+func (ms *MockStorage) SaveMembership(member usermodel.OrganisationMember) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	if _, ok := ms.organisations[member.OrganisationID]; !ok {
+		return errors.New("organisation does not exist")
+	}
+	if member.Role == usermodel.OrganisationRoleOwner {
+		for userId, existing := range ms.members {
+			if userId != member.UserID && existing.OrganisationID == member.OrganisationID &&
+				existing.Role == usermodel.OrganisationRoleOwner {
+				return errors.New("organisation already has an owner")
+			}
+		}
+	}
+	member.Login, member.Email = "", ""
+	ms.members[member.UserID] = member
+	return nil
+}
+
+func (ms *MockStorage) DeleteMembership(userId uuid.UUID) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	delete(ms.members, userId)
+	return nil
+}
+
+// AddInvitation also drops the expired invitations of the organisation.
+func (ms *MockStorage) AddInvitation(invitation usermodel.OrganisationInvitation) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	now := time.Now()
+	for id, existing := range ms.invitations {
+		if existing.OrganisationID == invitation.OrganisationID && existing.ExpiresAt.Before(now) {
+			delete(ms.invitations, id)
+		}
+	}
+	ms.invitations[invitation.ID] = invitation
+	return nil
+}
+
+func (ms *MockStorage) GetInvitationByHash(tokenHash []byte) (usermodel.OrganisationInvitation, bool, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	for _, invitation := range ms.invitations {
+		if bytes.Equal(invitation.TokenHash, tokenHash) {
+			return invitation, true, nil
+		}
+	}
+	return usermodel.OrganisationInvitation{}, false, nil
+}
+
+func (ms *MockStorage) GetOrganisationInvitations(organisationId uuid.UUID) ([]usermodel.OrganisationInvitation, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	var invitations []usermodel.OrganisationInvitation
+	for _, invitation := range ms.invitations {
+		if invitation.OrganisationID == organisationId {
+			invitations = append(invitations, invitation)
+		}
+	}
+	slices.SortFunc(invitations, func(a, b usermodel.OrganisationInvitation) int {
+		return a.CreationDate.Compare(b.CreationDate)
+	})
+	return invitations, nil
+}
+
+func (ms *MockStorage) DeleteInvitation(organisationId, invitationId uuid.UUID) (bool, error) {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	invitation, ok := ms.invitations[invitationId]
+	if !ok || invitation.OrganisationID != organisationId {
+		return false, nil
+	}
+	delete(ms.invitations, invitationId)
+	return true, nil
+}
+
 func NewStorage() smimpl.Storage {
 	return &MockStorage{
-		data:        make(map[uuid.UUID]usermodel.User),
-		credentials: make(map[string]credentialsData),
+		data:             make(map[uuid.UUID]usermodel.User),
+		credentials:      make(map[string]credentialsData),
+		refreshTokens:    make(map[uuid.UUID]usermodel.RefreshToken),
+		revokedTokens:    make(map[uuid.UUID]time.Time),
+		tokenGenerations: make(map[uuid.UUID]int64),
+		twoFactors:       make(map[uuid.UUID]usermodel.TwoFactor),
+		loginAttempts:    make(map[string]usermodel.LoginAttempts),
+		apiKeys:          make(map[uuid.UUID]usermodel.APIKey),
+		sessions:         make(map[uuid.UUID]usermodel.Session),
+		phoneCodes:       make(map[uuid.UUID]usermodel.PhoneVerification),
+		organisations:    make(map[uuid.UUID]usermodel.Organisation),
+		members:          make(map[uuid.UUID]usermodel.OrganisationMember),
+		invitations:      make(map[uuid.UUID]usermodel.OrganisationInvitation),
 	}
 }
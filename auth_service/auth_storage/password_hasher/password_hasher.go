@@ -0,0 +1,203 @@
+package passwordhasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	Argon2idAlgorithm = "argon2id"
+	BcryptAlgorithm   = "bcrypt"
+
+	argon2idPrefix = "$argon2id$"
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown password hash algorithm")
+	ErrMalformedHash    = errors.New("malformed password hash")
+)
+
+// Hasher produces self-describing password hashes (PHC string format for
+// argon2id, modular crypt format for bcrypt) with a random per-user salt.
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash reports whether the hash was produced by another algorithm
+	// or with weaker parameters than the hasher currently uses.
+	NeedsRehash(encoded string) bool
+}
+
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+const DefaultBcryptCost = 12
+
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+func NewArgon2idHasher(params Argon2idParams) *Argon2idHasher {
+	return &Argon2idHasher{params: params}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Verify(password, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(actual, key) == 1, nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, salt, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Memory < h.params.Memory || params.Iterations < h.params.Iterations ||
+		params.Parallelism < h.params.Parallelism || params.KeyLength < h.params.KeyLength ||
+		uint32(len(salt)) < h.params.SaltLength
+}
+
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != Argon2idAlgorithm {
+		return params, nil, nil, ErrMalformedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("%w: unsupported argon2 version %d", ErrMalformedHash, version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+type BcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{cost: cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < h.cost
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// MultiHasher hashes new passwords with the preferred algorithm but still
+// verifies hashes produced by any supported one, so switching the default
+// does not lock existing users out.
+type MultiHasher struct {
+	preferred string
+	argon2id  *Argon2idHasher
+	bcrypt    *BcryptHasher
+}
+
+func (h *MultiHasher) Hash(password string) (string, error) {
+	if h.preferred == BcryptAlgorithm {
+		return h.bcrypt.Hash(password)
+	}
+	return h.argon2id.Hash(password)
+}
+
+func (h *MultiHasher) Verify(password, encoded string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, argon2idPrefix):
+		return h.argon2id.Verify(password, encoded)
+	case isBcrypt(encoded):
+		return h.bcrypt.Verify(password, encoded)
+	}
+	return false, ErrUnknownAlgorithm
+}
+
+func (h *MultiHasher) NeedsRehash(encoded string) bool {
+	if h.preferred == BcryptAlgorithm {
+		return !isBcrypt(encoded) || h.bcrypt.NeedsRehash(encoded)
+	}
+	return !strings.HasPrefix(encoded, argon2idPrefix) || h.argon2id.NeedsRehash(encoded)
+}
+
+// NewHasher returns a hasher for the given algorithm name, defaulting to
+// argon2id when the name is empty.
+func NewHasher(algorithm string) (Hasher, error) {
+	switch algorithm {
+	case "", Argon2idAlgorithm:
+		algorithm = Argon2idAlgorithm
+	case BcryptAlgorithm:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAlgorithm, algorithm)
+	}
+	return &MultiHasher{
+		preferred: algorithm,
+		argon2id:  NewArgon2idHasher(DefaultArgon2idParams),
+		bcrypt:    NewBcryptHasher(DefaultBcryptCost),
+	}, nil
+}
@@ -0,0 +1,166 @@
+package pgstorage
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migrationLockID serializes migrations run from several instances through a
+// transaction level advisory lock.
+const migrationLockID = 4640517
+
+var ErrUnexpectedSchemaVersion = errors.New("unexpected schema version")
+
+// Migration is a pair of files NNNN_name.up.sql and NNNN_name.down.sql in
+// migrations/. Versions start at 1 and have no gaps.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// LoadMigrations reads the embedded migrations ordered by version.
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d needs both an up and a down file", migration.Version)
+		}
+	}
+	return migrations, nil
+}
+
+func ensureSchemaVersionTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+		version integer PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`).Error
+}
+
+// SchemaVersion returns the last applied migration, 0 for an empty database.
+// It only reads, so checking the version needs no DDL privileges.
+func SchemaVersion(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable("schema_version") {
+		return 0, nil
+	}
+	var version int
+	err := db.Raw("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version).Error
+	return version, err
+}
+
+// CheckSchemaVersion refuses to run against a database migrated to another
+// version than this binary was built with.
+func CheckSchemaVersion(db *gorm.DB) error {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return err
+	}
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if version != len(migrations) {
+		return fmt.Errorf("%w %d, expected %d", ErrUnexpectedSchemaVersion, version, len(migrations))
+	}
+	return nil
+}
+
+// Migrate applies up or down migrations until the schema is at target. Every
+// migration runs in its own transaction, so a failed one leaves the schema at
+// the previous version.
+func Migrate(db *gorm.DB, target int) error {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return err
+	}
+	if target < 0 || target > len(migrations) {
+		return fmt.Errorf("no schema version %d, the latest is %d", target, len(migrations))
+	}
+	if err := ensureSchemaVersionTable(db); err != nil {
+		return err
+	}
+	for {
+		done := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+				return err
+			}
+			version, err := SchemaVersion(tx)
+			if err != nil {
+				return err
+			}
+			switch {
+			case version == target:
+				done = true
+				return nil
+			case version > len(migrations):
+				return fmt.Errorf("%w %d, the latest known is %d", ErrUnexpectedSchemaVersion, version, len(migrations))
+			case version < target:
+				migration := migrations[version]
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+				}
+				return tx.Exec("INSERT INTO schema_version (version, name) VALUES (?, ?)", migration.Version, migration.Name).Error
+			default:
+				migration := migrations[version-1]
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return fmt.Errorf("reverting migration %d_%s: %w", migration.Version, migration.Name, err)
+				}
+				return tx.Exec("DELETE FROM schema_version WHERE version = ?", migration.Version).Error
+			}
+		})
+		if err != nil || done {
+			return err
+		}
+	}
+}
@@ -0,0 +1,21 @@
+-- Schema of the first release, which GORM AutoMigrate created before
+-- migrations were introduced. IF NOT EXISTS lets those databases adopt it.
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS user_info (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    first_name varchar(255),
+    second_name varchar(255),
+    birth_date date NOT NULL,
+    email varchar(255) NOT NULL CONSTRAINT uni_user_info_email UNIQUE,
+    phone_number varchar(20),
+    is_company boolean NOT NULL,
+    creation_date timestamptz,
+    update_date timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS user_credentials (
+    login varchar(255) PRIMARY KEY,
+    password bytea NOT NULL,
+    user_id uuid NOT NULL CONSTRAINT fk_user_credentials_user REFERENCES user_info (id) ON DELETE CASCADE
+);
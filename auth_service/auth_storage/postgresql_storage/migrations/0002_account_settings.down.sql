@@ -0,0 +1,10 @@
+-- Phone numbers stay in E.164 form, the national form is not restored.
+DROP TABLE IF EXISTS phone_verification;
+DROP INDEX IF EXISTS idx_user_info_lower_email;
+DROP INDEX IF EXISTS idx_user_info_verified_phone_number;
+ALTER TABLE user_credentials DROP COLUMN IF EXISTS tokens_revoked_at;
+ALTER TABLE user_info
+    DROP COLUMN IF EXISTS phone_number_verified,
+    DROP COLUMN IF EXISTS phone_region,
+    DROP COLUMN IF EXISTS role,
+    DROP COLUMN IF EXISTS email_verified;
@@ -0,0 +1,25 @@
+ALTER TABLE user_info
+    ADD COLUMN IF NOT EXISTS email_verified boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS role varchar(32),
+    ADD COLUMN IF NOT EXISTS phone_region varchar(2),
+    ADD COLUMN IF NOT EXISTS phone_number_verified boolean NOT NULL DEFAULT false;
+ALTER TABLE user_credentials ADD COLUMN IF NOT EXISTS tokens_revoked_at timestamptz;
+
+UPDATE user_info SET role = CASE WHEN is_company THEN 'company_admin' ELSE 'customer' END WHERE role IS NULL;
+
+-- Phone numbers used to be Russian only, in the national 8XXXXXXXXXX form.
+UPDATE user_info SET phone_number = '+7' || substr(phone_number, 2), phone_region = 'RU'
+    WHERE phone_number ~ '^8[0-9]{10}$';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_info_verified_phone_number ON user_info (phone_number)
+    WHERE phone_number_verified;
+CREATE INDEX IF NOT EXISTS idx_user_info_lower_email ON user_info (lower(email));
+
+CREATE TABLE IF NOT EXISTS phone_verification (
+    user_id uuid PRIMARY KEY CONSTRAINT fk_phone_verification_user REFERENCES user_info (id) ON DELETE CASCADE,
+    phone_number varchar(20) NOT NULL,
+    code_hash bytea NOT NULL,
+    attempts bigint NOT NULL DEFAULT 0,
+    expires_at timestamptz NOT NULL,
+    creation_date timestamptz NOT NULL
+);
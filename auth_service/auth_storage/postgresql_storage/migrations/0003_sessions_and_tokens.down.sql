@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS revoked_token;
+DROP TABLE IF EXISTS refresh_token;
+DROP TABLE IF EXISTS session;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS session (
+    id uuid PRIMARY KEY,
+    user_id uuid NOT NULL CONSTRAINT fk_session_user REFERENCES user_info (id) ON DELETE CASCADE,
+    user_agent varchar(512),
+    ip varchar(64),
+    creation_date timestamptz NOT NULL,
+    last_seen_date timestamptz NOT NULL,
+    revoked_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_session_user_id ON session (user_id);
+
+CREATE TABLE IF NOT EXISTS refresh_token (
+    id uuid PRIMARY KEY,
+    user_id uuid NOT NULL CONSTRAINT fk_refresh_token_user REFERENCES user_info (id) ON DELETE CASCADE,
+    session_id uuid,
+    token_hash bytea NOT NULL,
+    expires_at timestamptz NOT NULL,
+    revoked_at timestamptz,
+    creation_date timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_token_user_id ON refresh_token (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_token_session_id ON refresh_token (session_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_token_token_hash ON refresh_token (token_hash);
+
+CREATE TABLE IF NOT EXISTS revoked_token (
+    id uuid PRIMARY KEY,
+    expires_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_token_expires_at ON revoked_token (expires_at);
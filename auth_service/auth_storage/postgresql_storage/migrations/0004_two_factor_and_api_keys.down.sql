@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS api_key;
+DROP TABLE IF EXISTS login_attempt;
+DROP TABLE IF EXISTS two_factor;
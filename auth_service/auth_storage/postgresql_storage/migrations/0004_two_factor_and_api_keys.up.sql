@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS two_factor (
+    user_id uuid PRIMARY KEY CONSTRAINT fk_two_factor_user REFERENCES user_info (id) ON DELETE CASCADE,
+    secret bytea NOT NULL,
+    enabled boolean NOT NULL DEFAULT false,
+    recovery_codes jsonb,
+    last_used_step bigint NOT NULL DEFAULT 0,
+    creation_date timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS login_attempt (
+    key varchar(300) PRIMARY KEY,
+    failures bigint NOT NULL,
+    last_failure timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_login_attempt_last_failure ON login_attempt (last_failure);
+
+CREATE TABLE IF NOT EXISTS api_key (
+    id uuid PRIMARY KEY,
+    user_id uuid NOT NULL CONSTRAINT fk_api_key_user REFERENCES user_info (id) ON DELETE CASCADE,
+    name varchar(100) NOT NULL,
+    key_hash bytea NOT NULL,
+    scopes jsonb NOT NULL,
+    creation_date timestamptz,
+    last_used_date timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_api_key_user_id ON api_key (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_key_key_hash ON api_key (key_hash);
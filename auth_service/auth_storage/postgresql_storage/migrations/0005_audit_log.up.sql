@@ -0,0 +1,25 @@
+-- Audit events have no foreign keys, the log outlives deleted accounts.
+CREATE TABLE IF NOT EXISTS audit_event (
+    id uuid PRIMARY KEY,
+    actor_id uuid,
+    user_id uuid,
+    login varchar(255),
+    action varchar(32) NOT NULL,
+    ip varchar(64),
+    outcome varchar(16) NOT NULL,
+    changes jsonb,
+    creation_date timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_event_creation_date ON audit_event (creation_date);
+CREATE INDEX IF NOT EXISTS idx_audit_event_user_date ON audit_event (user_id, creation_date);
+
+-- Rejects updates and deletes at the database level, not only in the code.
+CREATE OR REPLACE FUNCTION audit_event_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_event is append-only';
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_event_append_only ON audit_event;
+CREATE TRIGGER audit_event_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_event
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_event_append_only();
@@ -0,0 +1,4 @@
+-- Members who joined through invitations keep their company role.
+DROP TABLE IF EXISTS organisation_invitation;
+DROP TABLE IF EXISTS organisation_member;
+DROP TABLE IF EXISTS organisation;
@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS organisation (
+    id uuid PRIMARY KEY,
+    name varchar(100) NOT NULL,
+    creation_date timestamptz NOT NULL,
+    update_date timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS organisation_member (
+    user_id uuid PRIMARY KEY CONSTRAINT fk_organisation_member_user REFERENCES user_info (id) ON DELETE CASCADE,
+    organisation_id uuid NOT NULL
+        CONSTRAINT fk_organisation_member_organisation REFERENCES organisation (id) ON DELETE CASCADE,
+    role varchar(16) NOT NULL,
+    creation_date timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_organisation_member_organisation_id ON organisation_member (organisation_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organisation_member_owner ON organisation_member (organisation_id)
+    WHERE role = 'owner';
+
+-- Invitations keep the inviter without a foreign key, they stay valid if the
+-- inviting account is deleted.
+CREATE TABLE IF NOT EXISTS organisation_invitation (
+    id uuid PRIMARY KEY,
+    organisation_id uuid NOT NULL
+        CONSTRAINT fk_organisation_invitation_organisation REFERENCES organisation (id) ON DELETE CASCADE,
+    email varchar(255) NOT NULL,
+    role varchar(16) NOT NULL,
+    invited_by uuid NOT NULL,
+    token_hash bytea NOT NULL,
+    expires_at timestamptz NOT NULL,
+    creation_date timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_organisation_invitation_organisation_id ON organisation_invitation (organisation_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organisation_invitation_token_hash ON organisation_invitation (token_hash);
+
+-- Every existing company account becomes the owner of an organisation with
+-- the same ID. The loyalty service relies on it for promos created before
+-- organisations, which only carry the author_id.
+INSERT INTO organisation (id, name, creation_date, update_date)
+    SELECT user_info.id, user_credentials.login, now(), now()
+    FROM user_info JOIN user_credentials ON user_credentials.user_id = user_info.id
+    WHERE user_info.is_company
+    ON CONFLICT (id) DO NOTHING;
+INSERT INTO organisation_member (user_id, organisation_id, role, creation_date)
+    SELECT id, id, 'owner', now() FROM user_info WHERE is_company
+    ON CONFLICT (user_id) DO NOTHING;
@@ -0,0 +1,3 @@
+ALTER TABLE user_info ADD COLUMN IF NOT EXISTS suspended boolean NOT NULL DEFAULT false;
+-- Admin user search pages through users by creation date.
+CREATE INDEX IF NOT EXISTS idx_user_info_creation_date ON user_info (creation_date);
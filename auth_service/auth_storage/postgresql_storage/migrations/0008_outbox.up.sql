@@ -0,0 +1,9 @@
+-- Kafka messages written in the transaction of the change they announce. The
+-- relay publishes them in id order and deletes them once acknowledged.
+CREATE TABLE IF NOT EXISTS outbox_message (
+    id bigserial PRIMARY KEY,
+    topic varchar(32) NOT NULL,
+    message_key varchar(255),
+    value bytea NOT NULL,
+    creation_date timestamptz NOT NULL
+);
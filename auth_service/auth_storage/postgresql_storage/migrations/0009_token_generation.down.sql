@@ -0,0 +1,6 @@
+ALTER TABLE user_credentials ADD COLUMN IF NOT EXISTS tokens_revoked_at timestamptz;
+-- Which sessions started after the last sign-out is lost, users that were
+-- ever signed out everywhere are signed out again.
+UPDATE user_credentials SET tokens_revoked_at = now() WHERE token_generation > 0;
+ALTER TABLE session DROP COLUMN IF EXISTS token_generation;
+ALTER TABLE user_credentials DROP COLUMN IF EXISTS token_generation;
@@ -0,0 +1,15 @@
+-- Signing a user out everywhere starts a new token generation instead of
+-- storing a cut-off time, which could only be compared with the second
+-- precision of the iat claim.
+ALTER TABLE user_credentials ADD COLUMN IF NOT EXISTS token_generation bigint NOT NULL DEFAULT 0;
+ALTER TABLE session ADD COLUMN IF NOT EXISTS token_generation bigint NOT NULL DEFAULT 0;
+-- Users signed out before move to the second generation. Their sessions
+-- started since stay active, access tokens without the gen claim are of the
+-- first generation and have to be refreshed.
+UPDATE user_credentials SET token_generation = 1 WHERE tokens_revoked_at IS NOT NULL;
+UPDATE session SET token_generation = 1
+FROM user_credentials
+WHERE session.user_id = user_credentials.user_id
+  AND user_credentials.tokens_revoked_at IS NOT NULL
+  AND session.creation_date >= user_credentials.tokens_revoked_at;
+ALTER TABLE user_credentials DROP COLUMN IF EXISTS tokens_revoked_at;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_user_info_lower_email ON user_info (lower(email));
+DROP INDEX IF EXISTS uni_user_info_lower_email;
@@ -0,0 +1,11 @@
+-- Emails differing only in case belong to the same mailbox. The service
+-- already refuses them at registration, the index also stops registrations
+-- racing past that check. Existing duplicates have to be resolved by hand.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM user_info GROUP BY lower(email) HAVING count(*) > 1) THEN
+        RAISE EXCEPTION 'user_info has emails differing only in case, resolve them before migrating';
+    END IF;
+END $$;
+CREATE UNIQUE INDEX IF NOT EXISTS uni_user_info_lower_email ON user_info (lower(email));
+DROP INDEX IF EXISTS idx_user_info_lower_email;
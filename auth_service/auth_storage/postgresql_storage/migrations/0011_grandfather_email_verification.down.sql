@@ -0,0 +1 @@
+-- Which accounts were marked verified is not recorded, they stay verified.
@@ -0,0 +1,5 @@
+-- Accounts registered before email verification was introduced, on
+-- 2026-10-17, count as verified so existing companies keep publishing promos.
+UPDATE user_info SET email_verified = true
+WHERE NOT email_verified
+  AND creation_date < '2026-10-17 17:40:13+00';
@@ -1,15 +1,17 @@
 package pgstorage
 
 import (
+	authconfig "authservice/auth_config"
 	smimpl "authservice/auth_storage/storage_manager"
-	userkeys "authservice/auth_storage/user_keys"
+	twofactor "authservice/auth_storage/two_factor"
 	usermodel "authservice/auth_storage/user_model"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"os"
-	"sync"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,25 +20,156 @@ import (
 	"gorm.io/gorm/schema"
 )
 
-const PGCredentialsPath = "credentials/postgresql.json"
+const userColumns = "user_info.id, user_info.first_name, user_info.second_name, user_info.birth_date, " +
+	"user_info.email, user_info.email_verified, user_info.phone_number, user_info.phone_region, " +
+	"user_info.phone_number_verified, user_info.is_company, user_info.role, user_info.suspended, " +
+	"user_info.creation_date, user_info.update_date, user_credentials.login"
 
+// UserInfo keeps phone numbers in E.164 form. A verified number belongs to
+// one user only. IDs are generated by AddUser rather than the database, so the
+// models stay usable with SQLite.
 type UserInfo struct {
-	ID           uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	FirstName    string    `gorm:"type:varchar(255)"`
-	SecondName   string    `gorm:"type:varchar(255)"`
-	BirthDate    time.Time `gorm:"type:date;not null"`
-	Email        string    `gorm:"type:varchar(255);unique;not null"`
-	PhoneNumber  string    `gorm:"type:varchar(20)"`
-	IsCompany    bool      `gorm:"not null"`
-	CreationDate time.Time `gorm:"autoCreateTime"`
-	UpdateDate   time.Time `gorm:"autoUpdateTime"`
+	ID                  uuid.UUID `gorm:"type:uuid;primaryKey"`
+	FirstName           string    `gorm:"type:varchar(255)"`
+	SecondName          string    `gorm:"type:varchar(255)"`
+	BirthDate           time.Time `gorm:"type:date;not null"`
+	Email               string    `gorm:"type:varchar(255);unique;not null"`
+	EmailVerified       bool      `gorm:"not null;default:false"`
+	PhoneNumber         string    `gorm:"type:varchar(20);uniqueIndex:idx_user_info_verified_phone_number,where:phone_number_verified"`
+	PhoneRegion         string    `gorm:"type:varchar(2)"`
+	PhoneNumberVerified bool      `gorm:"not null;default:false"`
+	IsCompany           bool      `gorm:"not null"`
+	Role                string    `gorm:"type:varchar(32)"`
+	Suspended           bool      `gorm:"not null;default:false"`
+	CreationDate        time.Time `gorm:"autoCreateTime;index"`
+	UpdateDate          time.Time `gorm:"autoUpdateTime"`
 }
 
 type UserCredentials struct {
-	Login    string    `gorm:"type:varchar(255);primaryKey"`
-	Password []byte    `gorm:"type:bytea;not null"`
-	UserID   uuid.UUID `gorm:"type:uuid;not null"`
-	User     UserInfo  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Login           string    `gorm:"type:varchar(255);primaryKey"`
+	Password        []byte    `gorm:"type:bytea;not null"`
+	UserID          uuid.UUID `gorm:"type:uuid;not null"`
+	TokenGeneration int64     `gorm:"not null;default:0"`
+	User            UserInfo  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+type RefreshToken struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index"`
+	SessionID    *uuid.UUID `gorm:"type:uuid;index"`
+	TokenHash    []byte     `gorm:"type:bytea;not null;uniqueIndex"`
+	ExpiresAt    time.Time  `gorm:"not null"`
+	RevokedAt    *time.Time
+	CreationDate time.Time `gorm:"autoCreateTime"`
+	User         UserInfo  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+type Session struct {
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID          uuid.UUID `gorm:"type:uuid;not null;index"`
+	UserAgent       string    `gorm:"type:varchar(512)"`
+	IP              string    `gorm:"type:varchar(64)"`
+	CreationDate    time.Time `gorm:"not null"`
+	LastSeenDate    time.Time `gorm:"not null"`
+	RevokedAt       *time.Time
+	TokenGeneration int64    `gorm:"not null;default:0"`
+	User            UserInfo `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+type RevokedToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+type TwoFactor struct {
+	UserID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Secret        []byte    `gorm:"type:bytea;not null"`
+	Enabled       bool      `gorm:"not null;default:false"`
+	RecoveryCodes []byte    `gorm:"type:jsonb"`
+	LastUsedStep  int64     `gorm:"not null;default:0"`
+	CreationDate  time.Time `gorm:"autoCreateTime"`
+	User          UserInfo  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+type PhoneVerification struct {
+	UserID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PhoneNumber  string    `gorm:"type:varchar(20);not null"`
+	CodeHash     []byte    `gorm:"type:bytea;not null"`
+	Attempts     int       `gorm:"not null;default:0"`
+	ExpiresAt    time.Time `gorm:"not null"`
+	CreationDate time.Time `gorm:"not null"`
+	User         UserInfo  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+type LoginAttempt struct {
+	Key         string    `gorm:"type:varchar(300);primaryKey"`
+	Failures    int       `gorm:"not null"`
+	LastFailure time.Time `gorm:"not null;index"`
+}
+
+type APIKey struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;index"`
+	Name         string    `gorm:"type:varchar(100);not null"`
+	KeyHash      []byte    `gorm:"type:bytea;not null;uniqueIndex"`
+	Scopes       []byte    `gorm:"type:jsonb;not null"`
+	CreationDate time.Time `gorm:"autoCreateTime"`
+	LastUsedDate *time.Time
+	User         UserInfo `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// AuditEvent has no foreign keys, the log outlives deleted accounts.
+type AuditEvent struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	ActorID      *uuid.UUID `gorm:"type:uuid"`
+	UserID       *uuid.UUID `gorm:"type:uuid;index:idx_audit_event_user_date,priority:1"`
+	Login        string     `gorm:"type:varchar(255)"`
+	Action       string     `gorm:"type:varchar(32);not null"`
+	IP           string     `gorm:"type:varchar(64)"`
+	Outcome      string     `gorm:"type:varchar(16);not null"`
+	Changes      []byte     `gorm:"type:jsonb"`
+	CreationDate time.Time  `gorm:"not null;index;index:idx_audit_event_user_date,priority:2"`
+}
+
+// OutboxMessage is ordered by its serial ID.
+type OutboxMessage struct {
+	ID           int64     `gorm:"primaryKey;autoIncrement"`
+	Topic        string    `gorm:"type:varchar(32);not null"`
+	MessageKey   string    `gorm:"type:varchar(255)"`
+	Value        []byte    `gorm:"type:bytea;not null"`
+	CreationDate time.Time `gorm:"not null"`
+}
+
+type Organisation struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name         string    `gorm:"type:varchar(100);not null"`
+	CreationDate time.Time `gorm:"not null"`
+	UpdateDate   time.Time `gorm:"not null"`
+}
+
+// OrganisationMember is keyed by the user, who belongs to one organisation at
+// most. The partial unique index allows a single owner per organisation.
+type OrganisationMember struct {
+	UserID         uuid.UUID    `gorm:"type:uuid;primaryKey"`
+	OrganisationID uuid.UUID    `gorm:"type:uuid;not null;index;uniqueIndex:idx_organisation_member_owner,where:role = 'owner'"`
+	Role           string       `gorm:"type:varchar(16);not null"`
+	CreationDate   time.Time    `gorm:"not null"`
+	User           UserInfo     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Organisation   Organisation `gorm:"foreignKey:OrganisationID;constraint:OnDelete:CASCADE"`
+}
+
+// OrganisationInvitation keeps the inviter without a foreign key, invitations
+// stay valid if the inviting account is deleted.
+type OrganisationInvitation struct {
+	ID             uuid.UUID    `gorm:"type:uuid;primaryKey"`
+	OrganisationID uuid.UUID    `gorm:"type:uuid;not null;index"`
+	Email          string       `gorm:"type:varchar(255);not null"`
+	Role           string       `gorm:"type:varchar(16);not null"`
+	InvitedBy      uuid.UUID    `gorm:"type:uuid;not null"`
+	TokenHash      []byte       `gorm:"type:bytea;not null;uniqueIndex"`
+	ExpiresAt      time.Time    `gorm:"not null"`
+	CreationDate   time.Time    `gorm:"not null"`
+	Organisation   Organisation `gorm:"foreignKey:OrganisationID;constraint:OnDelete:CASCADE"`
 }
 
 type UserWithLogin struct {
@@ -50,43 +183,48 @@ type PGCredentials struct {
 	DBName   string `json:"db_name"`
 }
 
-var (
-	pgCreds PGCredentials
-	once    sync.Once
-)
-
 type PGStorage struct {
-	db *gorm.DB
+	db           *gorm.DB
+	secretCipher *twofactor.SecretCipher
 }
 
 func GetUserInfoByUser(user usermodel.User) *UserInfo {
 	return &UserInfo{
-		ID:           user.ID,
-		FirstName:    user.FirstName,
-		SecondName:   user.SecondName,
-		BirthDate:    user.BirthDate,
-		Email:        user.Email,
-		PhoneNumber:  user.PhoneNumber,
-		IsCompany:    user.IsCompany,
-		CreationDate: user.CreationDate,
-		UpdateDate:   user.UpdateDate,
+		ID:                  user.ID,
+		FirstName:           user.FirstName,
+		SecondName:          user.SecondName,
+		BirthDate:           user.BirthDate,
+		Email:               user.Email,
+		EmailVerified:       user.EmailVerified,
+		PhoneNumber:         user.PhoneNumber,
+		PhoneRegion:         user.PhoneRegion,
+		PhoneNumberVerified: user.PhoneNumberVerified,
+		IsCompany:           user.IsCompany,
+		Role:                string(user.Role),
+		Suspended:           user.Suspended,
+		CreationDate:        user.CreationDate,
+		UpdateDate:          user.UpdateDate,
 	}
 }
 
-func (ps *PGStorage) GetUserPasswordByLogin(login string) ([userkeys.Md5Len]byte, bool, error) {
+func (ps *PGStorage) GetUserPasswordByLogin(login string) ([]byte, bool, error) {
 	var userCreds UserCredentials
 	err := ps.db.First(&userCreds, "login = ?", login).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return [userkeys.Md5Len]byte{}, false, nil
+		return nil, false, nil
 	}
-	return [userkeys.Md5Len]byte(userCreds.Password), userCreds.Login != "", err
+	return userCreds.Password, userCreds.Login != "", err
+}
+
+func (ps *PGStorage) UpdateUserPassword(login string, password []byte) error {
+	return ps.db.Model(&UserCredentials{}).Where("login = ?", login).Update("password", password).Error
 }
 
 func (ps *PGStorage) GetUserById(userId uuid.UUID) (usermodel.User, error) {
 	var user usermodel.User
 	err := ps.db.
 		Table("user_info").
-		Select("user_info.id, user_info.first_name, user_info.second_name, user_info.birth_date, user_info.email, user_info.phone_number, user_info.is_company, user_info.creation_date, user_info.update_date, user_credentials.login").
+		Select(userColumns).
 		Joins("JOIN user_credentials ON user_info.id = user_credentials.user_id").
 		Where("user_info.id = ?", userId).
 		Scan(&user).Error
@@ -103,7 +241,7 @@ func (ps *PGStorage) GetUserByLogin(login string) (usermodel.User, error) {
 	var user usermodel.User
 	err := ps.db.
 		Table("user_info").
-		Select("user_info.id, user_info.first_name, user_info.second_name, user_info.birth_date, user_info.email, user_info.phone_number, user_info.is_company, user_info.creation_date, user_info.update_date, user_credentials.login").
+		Select(userColumns).
 		Joins("JOIN user_credentials ON user_info.id = user_credentials.user_id").
 		Where("user_credentials.login = ?", login).
 		Scan(&user).Error
@@ -113,14 +251,93 @@ func (ps *PGStorage) GetUserByLogin(login string) (usermodel.User, error) {
 	return user, err
 }
 
-func (ps *PGStorage) AddUser(user usermodel.User, login string, password [userkeys.Md5Len]byte) (uuid.UUID, error) {
+// GetUserByEmail matches emails ignoring case, using the unique lower(email)
+// index.
+func (ps *PGStorage) GetUserByEmail(email string) (usermodel.User, error) {
+	var user usermodel.User
+	err := ps.db.
+		Table("user_info").
+		Select(userColumns).
+		Joins("JOIN user_credentials ON user_info.id = user_credentials.user_id").
+		Where("lower(user_info.email) = lower(?)", email).
+		Scan(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return usermodel.User{}, nil
+	}
+	return user, err
+}
+
+func (ps *PGStorage) GetUserByVerifiedPhoneNumber(phoneNumber string) (usermodel.User, error) {
+	var user usermodel.User
+	err := ps.db.
+		Table("user_info").
+		Select(userColumns).
+		Joins("JOIN user_credentials ON user_info.id = user_credentials.user_id").
+		Where("user_info.phone_number = ? AND user_info.phone_number_verified", phoneNumber).
+		Scan(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return usermodel.User{}, nil
+	}
+	return user, err
+}
+
+// likePattern returns a pattern for values containing fragment in any letter
+// case. Used as lower(column) LIKE ? ESCAPE '\' it works with SQLite as well.
+func likePattern(fragment string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.ToLower(fragment))
+	return "%" + escaped + "%"
+}
+
+func (ps *PGStorage) SearchUsers(filter usermodel.UserFilter) ([]usermodel.User, error) {
+	query := ps.db.
+		Table("user_info").
+		Select(userColumns).
+		Joins("JOIN user_credentials ON user_info.id = user_credentials.user_id").
+		Order("user_info.creation_date, user_info.id").
+		Offset(filter.Offset).
+		Limit(filter.Limit)
+	if filter.Login != "" {
+		query = query.Where(`lower(user_credentials.login) LIKE ? ESCAPE '\'`, likePattern(filter.Login))
+	}
+	if filter.Email != "" {
+		query = query.Where(`lower(user_info.email) LIKE ? ESCAPE '\'`, likePattern(filter.Email))
+	}
+	if filter.IsCompany != nil {
+		query = query.Where("user_info.is_company = ?", *filter.IsCompany)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where("user_info.creation_date >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		query = query.Where("user_info.creation_date < ?", filter.CreatedTo)
+	}
+	users := make([]usermodel.User, 0, filter.Limit)
+	if err := query.Scan(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (ps *PGStorage) Transaction(fn func(tx smimpl.Storage) error) error {
+	return ps.db.Transaction(func(db *gorm.DB) error {
+		return fn(&PGStorage{db: db, secretCipher: ps.secretCipher})
+	})
+}
+
+// AddUser inserts the profile and the credentials together, inside a
+// transaction of the caller it uses a savepoint.
+func (ps *PGStorage) AddUser(user usermodel.User, login string, password []byte) (uuid.UUID, error) {
 	userInfo := GetUserInfoByUser(user)
-	err := ps.db.Create(&userInfo).Error
-	if err != nil {
-		return uuid.UUID{}, err
+	userInfo.ID = uuid.New()
+	err := ps.db.Transaction(func(db *gorm.DB) error {
+		if err := db.Create(&userInfo).Error; err != nil {
+			return err
+		}
+		return db.Create(&UserCredentials{Login: login, Password: password, UserID: userInfo.ID}).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return uuid.UUID{}, usermodel.ErrUserExists
 	}
-	userCreds := UserCredentials{Login: login, Password: password[:], UserID: userInfo.ID}
-	err = ps.db.Create(&userCreds).Error
 	if err != nil {
 		return uuid.UUID{}, err
 	}
@@ -130,41 +347,720 @@ func (ps *PGStorage) AddUser(user usermodel.User, login string, password [userke
 func (ps *PGStorage) UpdateUser(user usermodel.User) error {
 	userInfo := GetUserInfoByUser(user)
 	err := ps.db.Save(&userInfo).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return usermodel.ErrUserExists
+	}
 	return err
 }
 
-func getPostgresCreds() *PGCredentials {
-	once.Do(func() {
-		file, err := os.Open(PGCredentialsPath)
-		if err != nil {
-			log.Fatalf("Error opening PostgreSQL credentials file: %v", err)
+// DeleteUser relies on the ON DELETE CASCADE constraints to remove the
+// credentials, refresh tokens, sessions and 2FA settings of the user.
+func (ps *PGStorage) DeleteUser(userId uuid.UUID) error {
+	return ps.db.Delete(&UserInfo{}, "id = ?", userId).Error
+}
+
+func (ps *PGStorage) AddRefreshToken(token usermodel.RefreshToken) error {
+	refreshToken := RefreshToken{
+		ID:           token.ID,
+		UserID:       token.UserID,
+		TokenHash:    token.TokenHash,
+		ExpiresAt:    token.ExpiresAt,
+		CreationDate: token.CreationDate,
+	}
+	if token.SessionID != uuid.Nil {
+		refreshToken.SessionID = &token.SessionID
+	}
+	return ps.db.Create(&refreshToken).Error
+}
+
+func (ps *PGStorage) GetRefreshToken(tokenHash []byte) (usermodel.RefreshToken, bool, error) {
+	var token RefreshToken
+	err := ps.db.First(&token, "token_hash = ?", tokenHash).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return usermodel.RefreshToken{}, false, nil
+	}
+	if err != nil {
+		return usermodel.RefreshToken{}, false, err
+	}
+	result := usermodel.RefreshToken{
+		ID:           token.ID,
+		UserID:       token.UserID,
+		TokenHash:    token.TokenHash,
+		ExpiresAt:    token.ExpiresAt,
+		CreationDate: token.CreationDate,
+	}
+	if token.SessionID != nil {
+		result.SessionID = *token.SessionID
+	}
+	if token.RevokedAt != nil {
+		result.RevokedAt = *token.RevokedAt
+	}
+	return result, true, nil
+}
+
+func (ps *PGStorage) RevokeRefreshToken(tokenId uuid.UUID) (bool, error) {
+	result := ps.db.Model(&RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", tokenId).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (ps *PGStorage) RevokeUserRefreshTokens(userId uuid.UUID) error {
+	return ps.db.Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (ps *PGStorage) RevokeToken(tokenId uuid.UUID, expiresAt time.Time) error {
+	err := ps.db.Where("expires_at < ?", time.Now()).Delete(&RevokedToken{}).Error
+	if err != nil {
+		return err
+	}
+	return ps.db.Save(&RevokedToken{ID: tokenId, ExpiresAt: expiresAt}).Error
+}
+
+func (ps *PGStorage) IsTokenRevoked(tokenId uuid.UUID) (bool, error) {
+	var count int64
+	err := ps.db.Model(&RevokedToken{}).Where("id = ?", tokenId).Count(&count).Error
+	return count > 0, err
+}
+
+func (ps *PGStorage) RevokeUserTokens(userId uuid.UUID) error {
+	return ps.db.Model(&UserCredentials{}).Where("user_id = ?", userId).
+		Update("token_generation", gorm.Expr("token_generation + 1")).Error
+}
+
+func (ps *PGStorage) GetUserTokenGeneration(userId uuid.UUID) (int64, error) {
+	var userCreds UserCredentials
+	err := ps.db.Select("token_generation").First(&userCreds, "user_id = ?", userId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return userCreds.TokenGeneration, err
+}
+
+func (ps *PGStorage) GetTwoFactor(userId uuid.UUID) (usermodel.TwoFactor, bool, error) {
+	var twoFactor TwoFactor
+	err := ps.db.First(&twoFactor, "user_id = ?", userId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return usermodel.TwoFactor{}, false, nil
+	}
+	if err != nil {
+		return usermodel.TwoFactor{}, false, err
+	}
+	secret, err := ps.secretCipher.Decrypt(twoFactor.Secret)
+	if err != nil {
+		return usermodel.TwoFactor{}, false, fmt.Errorf("decrypting totp secret: %w", err)
+	}
+	var recoveryCodes []string
+	if len(twoFactor.RecoveryCodes) > 0 {
+		if err := json.Unmarshal(twoFactor.RecoveryCodes, &recoveryCodes); err != nil {
+			return usermodel.TwoFactor{}, false, err
+		}
+	}
+	return usermodel.TwoFactor{
+		UserID:        twoFactor.UserID,
+		Secret:        secret,
+		Enabled:       twoFactor.Enabled,
+		RecoveryCodes: recoveryCodes,
+		LastUsedStep:  twoFactor.LastUsedStep,
+		CreationDate:  twoFactor.CreationDate,
+	}, true, nil
+}
+
+func (ps *PGStorage) SaveTwoFactor(twoFactor usermodel.TwoFactor) error {
+	secret, err := ps.secretCipher.Encrypt(twoFactor.Secret)
+	if err != nil {
+		return err
+	}
+	recoveryCodes, err := json.Marshal(twoFactor.RecoveryCodes)
+	if err != nil {
+		return err
+	}
+	return ps.db.Save(&TwoFactor{
+		UserID:        twoFactor.UserID,
+		Secret:        secret,
+		Enabled:       twoFactor.Enabled,
+		RecoveryCodes: recoveryCodes,
+		LastUsedStep:  twoFactor.LastUsedStep,
+		CreationDate:  twoFactor.CreationDate,
+	}).Error
+}
+
+func (ps *PGStorage) UseTwoFactorStep(userId uuid.UUID, step int64) (bool, error) {
+	result := ps.db.Model(&TwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userId, step).
+		Update("last_used_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UseRecoveryCode only writes the codes back if nobody changed them since
+// they were read, and reads them again otherwise.
+func (ps *PGStorage) UseRecoveryCode(userId uuid.UUID, codeHash string) (bool, error) {
+	for {
+		var twoFactor TwoFactor
+		err := ps.db.Select("recovery_codes").First(&twoFactor, "user_id = ?", userId).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
 		}
-		defer file.Close()
-		err = json.NewDecoder(file).Decode(&pgCreds)
 		if err != nil {
-			if err != nil {
-				log.Fatalf("Error parsing PostgreSQL credentials: %v", err)
+			return false, err
+		}
+		var recoveryCodes []string
+		if len(twoFactor.RecoveryCodes) > 0 {
+			if err := json.Unmarshal(twoFactor.RecoveryCodes, &recoveryCodes); err != nil {
+				return false, err
 			}
 		}
+		i := slices.Index(recoveryCodes, codeHash)
+		if i == -1 {
+			return false, nil
+		}
+		remaining, err := json.Marshal(slices.Delete(recoveryCodes, i, i+1))
+		if err != nil {
+			return false, err
+		}
+		result := ps.db.Model(&TwoFactor{}).
+			Where("user_id = ? AND recovery_codes = ?", userId, twoFactor.RecoveryCodes).
+			Update("recovery_codes", remaining)
+		if result.Error != nil || result.RowsAffected > 0 {
+			return result.Error == nil, result.Error
+		}
+	}
+}
+
+func (ps *PGStorage) DeleteTwoFactor(userId uuid.UUID) error {
+	return ps.db.Delete(&TwoFactor{}, "user_id = ?", userId).Error
+}
+
+func (ps *PGStorage) GetLoginAttempts(key string) (usermodel.LoginAttempts, error) {
+	var attempt LoginAttempt
+	err := ps.db.First(&attempt, "key = ?", key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return usermodel.LoginAttempts{}, nil
+	}
+	if err != nil {
+		return usermodel.LoginAttempts{}, err
+	}
+	return usermodel.LoginAttempts{
+		Key:         attempt.Key,
+		Failures:    attempt.Failures,
+		LastFailure: attempt.LastFailure,
+	}, nil
+}
+
+// AddLoginFailure increments the counter in a single statement, so parallel
+// attempts cannot overwrite each other's failures.
+func (ps *PGStorage) AddLoginFailure(key string, failedAt, resetBefore time.Time) error {
+	return ps.db.Exec(`INSERT INTO login_attempt (key, failures, last_failure) VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempt.last_failure < ? THEN 1 ELSE login_attempt.failures + 1 END,
+			last_failure = EXCLUDED.last_failure`,
+		key, failedAt, resetBefore).Error
+}
+
+func (ps *PGStorage) ResetLoginAttempts(key string) error {
+	return ps.db.Delete(&LoginAttempt{}, "key = ?", key).Error
+}
+
+func (ps *PGStorage) DeleteLoginAttempts(resetBefore time.Time) error {
+	return ps.db.Where("last_failure < ?", resetBefore).Delete(&LoginAttempt{}).Error
+}
+
+func (ps *PGStorage) AddAPIKey(apiKey usermodel.APIKey) error {
+	scopes, err := json.Marshal(apiKey.Scopes)
+	if err != nil {
+		return err
+	}
+	return ps.db.Create(&APIKey{
+		ID:           apiKey.ID,
+		UserID:       apiKey.UserID,
+		Name:         apiKey.Name,
+		KeyHash:      apiKey.KeyHash,
+		Scopes:       scopes,
+		CreationDate: apiKey.CreationDate,
+	}).Error
+}
+
+func convertAPIKey(apiKey APIKey) (usermodel.APIKey, error) {
+	var scopes []string
+	if err := json.Unmarshal(apiKey.Scopes, &scopes); err != nil {
+		return usermodel.APIKey{}, err
+	}
+	result := usermodel.APIKey{
+		ID:           apiKey.ID,
+		UserID:       apiKey.UserID,
+		Name:         apiKey.Name,
+		KeyHash:      apiKey.KeyHash,
+		Scopes:       scopes,
+		CreationDate: apiKey.CreationDate,
+	}
+	if apiKey.LastUsedDate != nil {
+		result.LastUsedDate = *apiKey.LastUsedDate
+	}
+	return result, nil
+}
+
+func (ps *PGStorage) GetAPIKeyByHash(keyHash []byte) (usermodel.APIKey, bool, error) {
+	var apiKey APIKey
+	err := ps.db.First(&apiKey, "key_hash = ?", keyHash).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return usermodel.APIKey{}, false, nil
+	}
+	if err != nil {
+		return usermodel.APIKey{}, false, err
+	}
+	result, err := convertAPIKey(apiKey)
+	return result, err == nil, err
+}
+
+func (ps *PGStorage) GetUserAPIKeys(userId uuid.UUID) ([]usermodel.APIKey, error) {
+	var apiKeys []APIKey
+	if err := ps.db.Where("user_id = ?", userId).Order("creation_date").Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+	result := make([]usermodel.APIKey, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		converted, err := convertAPIKey(apiKey)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, converted)
+	}
+	return result, nil
+}
+
+func (ps *PGStorage) DeleteAPIKey(userId, keyId uuid.UUID) (bool, error) {
+	result := ps.db.Delete(&APIKey{}, "id = ? AND user_id = ?", keyId, userId)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (ps *PGStorage) UpdateAPIKeyLastUsed(keyId uuid.UUID, usedAt time.Time) error {
+	return ps.db.Model(&APIKey{}).Where("id = ?", keyId).Update("last_used_date", usedAt).Error
+}
+
+func (ps *PGStorage) AddSession(session usermodel.Session) error {
+	return ps.db.Create(&Session{
+		ID:              session.ID,
+		UserID:          session.UserID,
+		UserAgent:       session.UserAgent,
+		IP:              session.IP,
+		CreationDate:    session.CreationDate,
+		LastSeenDate:    session.LastSeenDate,
+		TokenGeneration: session.TokenGeneration,
+	}).Error
+}
+
+func convertSession(session Session) usermodel.Session {
+	result := usermodel.Session{
+		ID:              session.ID,
+		UserID:          session.UserID,
+		UserAgent:       session.UserAgent,
+		IP:              session.IP,
+		CreationDate:    session.CreationDate,
+		LastSeenDate:    session.LastSeenDate,
+		TokenGeneration: session.TokenGeneration,
+	}
+	if session.RevokedAt != nil {
+		result.RevokedAt = *session.RevokedAt
+	}
+	return result
+}
+
+func (ps *PGStorage) GetSession(sessionId uuid.UUID) (usermodel.Session, bool, error) {
+	var session Session
+	err := ps.db.First(&session, "id = ?", sessionId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return usermodel.Session{}, false, nil
+	}
+	if err != nil {
+		return usermodel.Session{}, false, err
+	}
+	return convertSession(session), true, nil
+}
+
+func (ps *PGStorage) GetUserSessions(userId uuid.UUID) ([]usermodel.Session, error) {
+	var sessions []Session
+	if err := ps.db.Where("user_id = ?", userId).Order("last_seen_date DESC").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	result := make([]usermodel.Session, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, convertSession(session))
+	}
+	return result, nil
+}
+
+// RevokeSession marks the session and its refresh tokens as revoked in one
+// transaction.
+func (ps *PGStorage) RevokeSession(userId, sessionId uuid.UUID, revokedAt time.Time) (bool, error) {
+	revoked := false
+	err := ps.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Session{}).
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionId, userId).
+			Update("revoked_at", revokedAt)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		revoked = true
+		return tx.Model(&RefreshToken{}).
+			Where("session_id = ? AND revoked_at IS NULL", sessionId).
+			Update("revoked_at", revokedAt).Error
 	})
-	return &pgCreds
+	return revoked && err == nil, err
+}
+
+func (ps *PGStorage) UpdateSessionLastSeen(sessionId uuid.UUID, seenAt time.Time) error {
+	return ps.db.Model(&Session{}).Where("id = ?", sessionId).Update("last_seen_date", seenAt).Error
+}
+
+func nullableUUID(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
+
+func (ps *PGStorage) AddAuditEvent(event usermodel.AuditEvent) error {
+	var changes []byte
+	if len(event.Changes) > 0 {
+		var err error
+		if changes, err = json.Marshal(event.Changes); err != nil {
+			return err
+		}
+	}
+	return ps.db.Create(&AuditEvent{
+		ID:           event.ID,
+		ActorID:      nullableUUID(event.ActorID),
+		UserID:       nullableUUID(event.UserID),
+		Login:        event.Login,
+		Action:       string(event.Action),
+		IP:           event.IP,
+		Outcome:      string(event.Outcome),
+		Changes:      changes,
+		CreationDate: event.CreationDate,
+	}).Error
+}
+
+func (ps *PGStorage) GetAuditEvents(filter usermodel.AuditFilter) ([]usermodel.AuditEvent, error) {
+	query := ps.db.Order("creation_date DESC").Limit(filter.Limit)
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("creation_date >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("creation_date < ?", filter.To)
+	}
+	var events []AuditEvent
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	result := make([]usermodel.AuditEvent, 0, len(events))
+	for _, event := range events {
+		converted := usermodel.AuditEvent{
+			ID:           event.ID,
+			Login:        event.Login,
+			Action:       usermodel.AuditAction(event.Action),
+			IP:           event.IP,
+			Outcome:      usermodel.AuditOutcome(event.Outcome),
+			CreationDate: event.CreationDate,
+		}
+		if event.ActorID != nil {
+			converted.ActorID = *event.ActorID
+		}
+		if event.UserID != nil {
+			converted.UserID = *event.UserID
+		}
+		if len(event.Changes) > 0 {
+			if err := json.Unmarshal(event.Changes, &converted.Changes); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, converted)
+	}
+	return result, nil
+}
+
+func (ps *PGStorage) AddOutboxMessage(message usermodel.OutboxMessage) error {
+	return ps.db.Create(&OutboxMessage{
+		Topic:        string(message.Topic),
+		MessageKey:   message.Key,
+		Value:        message.Value,
+		CreationDate: message.CreationDate,
+	}).Error
+}
+
+func (ps *PGStorage) GetOutboxMessages(limit int) ([]usermodel.OutboxMessage, error) {
+	var messages []OutboxMessage
+	if err := ps.db.Order("id").Limit(limit).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	result := make([]usermodel.OutboxMessage, 0, len(messages))
+	for _, message := range messages {
+		result = append(result, usermodel.OutboxMessage{
+			ID:           message.ID,
+			Topic:        usermodel.OutboxTopic(message.Topic),
+			Key:          message.MessageKey,
+			Value:        message.Value,
+			CreationDate: message.CreationDate,
+		})
+	}
+	return result, nil
+}
+
+func (ps *PGStorage) DeleteOutboxMessages(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return ps.db.Delete(&OutboxMessage{}, ids).Error
+}
+
+func (ps *PGStorage) GetPhoneVerification(userId uuid.UUID) (usermodel.PhoneVerification, bool, error) {
+	var verification PhoneVerification
+	err := ps.db.First(&verification, "user_id = ?", userId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return usermodel.PhoneVerification{}, false, nil
+	}
+	if err != nil {
+		return usermodel.PhoneVerification{}, false, err
+	}
+	return usermodel.PhoneVerification{
+		UserID:       verification.UserID,
+		PhoneNumber:  verification.PhoneNumber,
+		CodeHash:     verification.CodeHash,
+		Attempts:     verification.Attempts,
+		ExpiresAt:    verification.ExpiresAt,
+		CreationDate: verification.CreationDate,
+	}, true, nil
 }
 
-func NewStorage() smimpl.Storage {
-	pgCreds := getPostgresCreds()
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		"postgres", 5432, pgCreds.User, pgCreds.Password, pgCreds.DBName, "disable")
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+func (ps *PGStorage) SavePhoneVerification(verification usermodel.PhoneVerification) error {
+	return ps.db.Save(&PhoneVerification{
+		UserID:       verification.UserID,
+		PhoneNumber:  verification.PhoneNumber,
+		CodeHash:     verification.CodeHash,
+		Attempts:     verification.Attempts,
+		ExpiresAt:    verification.ExpiresAt,
+		CreationDate: verification.CreationDate,
+	}).Error
+}
+
+func (ps *PGStorage) DeletePhoneVerification(userId uuid.UUID) error {
+	return ps.db.Delete(&PhoneVerification{}, "user_id = ?", userId).Error
+}
+
+func (ps *PGStorage) AddOrganisation(organisation usermodel.Organisation) error {
+	return ps.db.Create(&Organisation{
+		ID:           organisation.ID,
+		Name:         organisation.Name,
+		CreationDate: organisation.CreationDate,
+		UpdateDate:   organisation.UpdateDate,
+	}).Error
+}
+
+func (ps *PGStorage) GetOrganisation(organisationId uuid.UUID) (usermodel.Organisation, bool, error) {
+	var organisation Organisation
+	err := ps.db.First(&organisation, "id = ?", organisationId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return usermodel.Organisation{}, false, nil
+	}
+	if err != nil {
+		return usermodel.Organisation{}, false, err
+	}
+	return usermodel.Organisation{
+		ID:           organisation.ID,
+		Name:         organisation.Name,
+		CreationDate: organisation.CreationDate,
+		UpdateDate:   organisation.UpdateDate,
+	}, true, nil
+}
+
+func (ps *PGStorage) UpdateOrganisation(organisation usermodel.Organisation) error {
+	return ps.db.Model(&Organisation{}).Where("id = ?", organisation.ID).
+		Updates(map[string]interface{}{"name": organisation.Name, "update_date": organisation.UpdateDate}).Error
+}
+
+func (ps *PGStorage) DeleteOrganisation(organisationId uuid.UUID) error {
+	return ps.db.Delete(&Organisation{}, "id = ?", organisationId).Error
+}
+
+func convertMember(member OrganisationMember) usermodel.OrganisationMember {
+	return usermodel.OrganisationMember{
+		OrganisationID: member.OrganisationID,
+		UserID:         member.UserID,
+		Role:           usermodel.OrganisationRole(member.Role),
+		CreationDate:   member.CreationDate,
+	}
+}
+
+func (ps *PGStorage) GetMembership(userId uuid.UUID) (usermodel.OrganisationMember, bool, error) {
+	var member OrganisationMember
+	err := ps.db.First(&member, "user_id = ?", userId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return usermodel.OrganisationMember{}, false, nil
+	}
+	if err != nil {
+		return usermodel.OrganisationMember{}, false, err
+	}
+	return convertMember(member), true, nil
+}
+
+func (ps *PGStorage) GetOrganisationMembers(organisationId uuid.UUID) ([]usermodel.OrganisationMember, error) {
+	var members []OrganisationMember
+	if err := ps.db.Where("organisation_id = ?", organisationId).Order("creation_date").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	result := make([]usermodel.OrganisationMember, 0, len(members))
+	for _, member := range members {
+		result = append(result, convertMember(member))
+	}
+	return result, nil
+}
+
+func (ps *PGStorage) SaveMembership(member usermodel.OrganisationMember) error {
+	return ps.db.Save(&OrganisationMember{
+		UserID:         member.UserID,
+		OrganisationID: member.OrganisationID,
+		Role:           string(member.Role),
+		CreationDate:   member.CreationDate,
+	}).Error
+}
+
+func (ps *PGStorage) DeleteMembership(userId uuid.UUID) error {
+	return ps.db.Delete(&OrganisationMember{}, "user_id = ?", userId).Error
+}
+
+// AddInvitation also drops the expired invitations of the organisation.
+func (ps *PGStorage) AddInvitation(invitation usermodel.OrganisationInvitation) error {
+	err := ps.db.Where("organisation_id = ? AND expires_at < ?", invitation.OrganisationID, time.Now()).
+		Delete(&OrganisationInvitation{}).Error
+	if err != nil {
+		return err
+	}
+	return ps.db.Create(&OrganisationInvitation{
+		ID:             invitation.ID,
+		OrganisationID: invitation.OrganisationID,
+		Email:          invitation.Email,
+		Role:           string(invitation.Role),
+		InvitedBy:      invitation.InvitedBy,
+		TokenHash:      invitation.TokenHash,
+		ExpiresAt:      invitation.ExpiresAt,
+		CreationDate:   invitation.CreationDate,
+	}).Error
+}
+
+func convertInvitation(invitation OrganisationInvitation) usermodel.OrganisationInvitation {
+	return usermodel.OrganisationInvitation{
+		ID:             invitation.ID,
+		OrganisationID: invitation.OrganisationID,
+		Email:          invitation.Email,
+		Role:           usermodel.OrganisationRole(invitation.Role),
+		InvitedBy:      invitation.InvitedBy,
+		TokenHash:      invitation.TokenHash,
+		ExpiresAt:      invitation.ExpiresAt,
+		CreationDate:   invitation.CreationDate,
+	}
+}
+
+func (ps *PGStorage) GetInvitationByHash(tokenHash []byte) (usermodel.OrganisationInvitation, bool, error) {
+	var invitation OrganisationInvitation
+	err := ps.db.First(&invitation, "token_hash = ?", tokenHash).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return usermodel.OrganisationInvitation{}, false, nil
+	}
+	if err != nil {
+		return usermodel.OrganisationInvitation{}, false, err
+	}
+	return convertInvitation(invitation), true, nil
+}
+
+func (ps *PGStorage) GetOrganisationInvitations(organisationId uuid.UUID) ([]usermodel.OrganisationInvitation, error) {
+	var invitations []OrganisationInvitation
+	err := ps.db.Where("organisation_id = ?", organisationId).Order("creation_date").Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make([]usermodel.OrganisationInvitation, 0, len(invitations))
+	for _, invitation := range invitations {
+		result = append(result, convertInvitation(invitation))
+	}
+	return result, nil
+}
+
+func (ps *PGStorage) DeleteInvitation(organisationId, invitationId uuid.UUID) (bool, error) {
+	result := ps.db.Delete(&OrganisationInvitation{}, "id = ? AND organisation_id = ?", invitationId, organisationId)
+	return result.RowsAffected > 0, result.Error
+}
+
+func readCredentials(path string) (PGCredentials, error) {
+	var creds PGCredentials
+	file, err := os.Open(path)
+	if err != nil {
+		return creds, err
+	}
+	defer file.Close()
+	err = json.NewDecoder(file).Decode(&creds)
+	return creds, err
+}
+
+// DSN fills the user, password and database name missing from cfg in from
+// its credentials file.
+func DSN(cfg authconfig.Postgres) (string, error) {
+	if cfg.CredentialsFile != "" && (cfg.User == "" || cfg.Password == "" || cfg.DBName == "") {
+		creds, err := readCredentials(cfg.CredentialsFile)
+		if err != nil {
+			return "", fmt.Errorf("PostgreSQL credentials: %w", err)
+		}
+		if cfg.User == "" {
+			cfg.User = creds.User
+		}
+		if cfg.Password == "" {
+			cfg.Password = creds.Password
+		}
+		if cfg.DBName == "" {
+			cfg.DBName = creds.DBName
+		}
+	}
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s connect_timeout=%d",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode, int(cfg.ConnectTimeout.Seconds())), nil
+}
+
+func OpenDSN(dsn string) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{
 		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+		TranslateError: true,
 	})
+}
+
+// OpenDB connects to the database without checking its schema, the migrate
+// subcommand uses it directly.
+func OpenDB(cfg authconfig.Postgres) *gorm.DB {
+	dsn, err := DSN(cfg)
 	if err != nil {
-		log.Fatalf("Error running PostgreSQL: %v", err)
+		log.Fatalf("Error reading PostgreSQL config: %v", err)
 	}
-	db.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\";")
-	err = db.AutoMigrate(&UserInfo{}, &UserCredentials{})
+	db, err := OpenDSN(dsn)
 	if err != nil {
-		log.Fatalf("Failed setting up schema: %v", err)
+		log.Fatalf("Error running PostgreSQL: %v", err)
+	}
+	return db
+}
+
+// NewStorageWithDB uses a database whose schema is already in place. The
+// queries stick to SQL that SQLite understands too, sqlitestorage reuses them.
+func NewStorageWithDB(db *gorm.DB, secretCipher *twofactor.SecretCipher) smimpl.Storage {
+	return &PGStorage{db: db, secretCipher: secretCipher}
+}
+
+// NewStorage expects the schema to be migrated with "main migrate up"
+// beforehand.
+func NewStorage(cfg authconfig.Postgres, secretCipher *twofactor.SecretCipher) smimpl.Storage {
+	db := OpenDB(cfg)
+	if err := CheckSchemaVersion(db); err != nil {
+		log.Fatalf("Refusing to start: %v, run \"main migrate up\"", err)
 	}
 	fmt.Println("Established successful connection to PostgreSQL")
-	return &PGStorage{db: db}
+	return NewStorageWithDB(db, secretCipher)
 }
@@ -0,0 +1,67 @@
+//go:build sqlite
+
+package sqlitestorage
+
+import (
+	pgstorage "authservice/auth_storage/postgresql_storage"
+	smimpl "authservice/auth_storage/storage_manager"
+	twofactor "authservice/auth_storage/two_factor"
+	"fmt"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// schemaExtras adds what the model tags cannot express: the unique index on
+// emails ignoring case, also used by email logins, and the append-only guard
+// of the audit log.
+var schemaExtras = []string{
+	"CREATE UNIQUE INDEX IF NOT EXISTS uni_user_info_lower_email ON user_info (lower(email))",
+	"DROP INDEX IF EXISTS idx_user_info_lower_email",
+	`CREATE TRIGGER IF NOT EXISTS audit_event_no_update BEFORE UPDATE ON audit_event
+	BEGIN SELECT RAISE(ABORT, 'audit_event is append-only'); END`,
+	`CREATE TRIGGER IF NOT EXISTS audit_event_no_delete BEFORE DELETE ON audit_event
+	BEGIN SELECT RAISE(ABORT, 'audit_event is append-only'); END`,
+}
+
+// NewStorage opens the SQLite database at path, ":memory:" for a throwaway
+// one, and creates the schema from the PostgreSQL models. It is meant for
+// local development and tests, so there are no migrations: a database of an
+// older version is upgraded by AutoMigrate or recreated.
+//
+// The schema therefore follows the models and schemaExtras rather than the
+// migrations, which TestSQLiteSchemaMatchesMigrations checks for indexes and
+// foreign keys. Column types are the SQLite ones, there is no schema_version
+// table, and AutoMigrate neither drops columns nor runs the data changes of
+// the migrations, such as the backfills or the duplicate email check.
+func NewStorage(path string, secretCipher *twofactor.SecretCipher) (smimpl.Storage, error) {
+	db, err := gorm.Open(sqlite.Open(path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+		TranslateError: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	// SQLite has a single writer anyway. One connection also keeps an
+	// in-memory database alive and shared by every query.
+	sqlDB.SetMaxOpenConns(1)
+
+	err = db.AutoMigrate(&pgstorage.UserInfo{}, &pgstorage.UserCredentials{}, &pgstorage.Session{},
+		&pgstorage.RefreshToken{}, &pgstorage.RevokedToken{}, &pgstorage.TwoFactor{}, &pgstorage.LoginAttempt{},
+		&pgstorage.APIKey{}, &pgstorage.AuditEvent{}, &pgstorage.PhoneVerification{}, &pgstorage.Organisation{},
+		&pgstorage.OrganisationMember{}, &pgstorage.OrganisationInvitation{}, &pgstorage.OutboxMessage{})
+	if err != nil {
+		return nil, fmt.Errorf("creating schema: %w", err)
+	}
+	for _, statement := range schemaExtras {
+		if err := db.Exec(statement).Error; err != nil {
+			return nil, fmt.Errorf("creating schema: %w", err)
+		}
+	}
+	return pgstorage.NewStorageWithDB(db, secretCipher), nil
+}
@@ -0,0 +1,202 @@
+package smimpl
+
+import (
+	usermodel "authservice/auth_storage/user_model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultUserSearchLimit = 50
+	maxUserSearchLimit     = 500
+)
+
+// platformAdmin returns the caller if it is a platform admin. An invalid JWT
+// gives an empty user, like GetUserByJWT.
+func (sm *StorageManager) platformAdmin(jwt string) (usermodel.User, error) {
+	caller, err := sm.GetUserByJWT(jwt)
+	if err != nil || caller.Login == "" {
+		return usermodel.User{}, err
+	}
+	if caller.Role != usermodel.RolePlatformAdmin {
+		return usermodel.User{}, usermodel.ErrForbidden
+	}
+	return caller, nil
+}
+
+func (sm *StorageManager) existingUser(userId uuid.UUID) (usermodel.User, error) {
+	user, err := sm.storage.GetUserById(userId)
+	if err != nil {
+		return usermodel.User{}, err
+	}
+	if user.Login == "" {
+		return usermodel.User{}, usermodel.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// endSessions signs the user out everywhere and revokes its sessions, so that
+// the gateway stops accepting its access tokens right away.
+func (sm *StorageManager) endSessions(userId uuid.UUID) error {
+	if err := sm.signOutEverywhere(userId); err != nil {
+		return err
+	}
+	sessions, err := sm.storage.GetUserSessions(userId)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, session := range sessions {
+		if !session.RevokedAt.IsZero() {
+			continue
+		}
+		if _, err := sm.storage.RevokeSession(userId, session.ID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchUsers lets platform admins page through users matching the filter.
+// Every search is audited with its filter.
+func (sm *StorageManager) SearchUsers(jwt string, filter usermodel.UserFilter) ([]usermodel.User, bool, error) {
+	caller, err := sm.platformAdmin(jwt)
+	if err != nil || caller.Login == "" {
+		return nil, false, err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultUserSearchLimit
+	}
+	filter.Limit = min(filter.Limit, maxUserSearchLimit)
+	filter.Offset = max(filter.Offset, 0)
+	users, err := sm.storage.SearchUsers(filter)
+	sm.audit(usermodel.AuditEvent{
+		ActorID: caller.ID,
+		Action:  usermodel.AuditUserSearch,
+		Outcome: auditOutcome(err == nil),
+		Changes: usermodel.DescribeUserFilter(filter),
+	})
+	return users, err == nil, err
+}
+
+// AdminGetUser returns the whole profile of any user to platform admins,
+// unlike GetUserById. Every read is audited.
+func (sm *StorageManager) AdminGetUser(jwt string, userId uuid.UUID) (usermodel.User, error) {
+	caller, err := sm.platformAdmin(jwt)
+	if err != nil || caller.Login == "" {
+		return usermodel.User{}, err
+	}
+	user, err := sm.existingUser(userId)
+	if err != nil {
+		return usermodel.User{}, err
+	}
+	twoFactor, _, err := sm.storage.GetTwoFactor(user.ID)
+	if err == nil {
+		user.TwoFactorEnabled = twoFactor.Enabled
+		user.OrganisationID, err = sm.organisationId(user.ID)
+	}
+	sm.audit(usermodel.AuditEvent{
+		ActorID: caller.ID,
+		UserID:  user.ID,
+		Login:   user.Login,
+		Action:  usermodel.AuditUserView,
+		Outcome: auditOutcome(err == nil),
+	})
+	if err != nil {
+		return usermodel.User{}, err
+	}
+	return user, nil
+}
+
+// AdminUpdateUser lets platform admins edit the profile of any user, with the
+// same rules as UpdateUserByJWT.
+func (sm *StorageManager) AdminUpdateUser(jwt string, userId uuid.UUID, userInfo usermodel.User) (usermodel.User, error) {
+	caller, err := sm.platformAdmin(jwt)
+	if err != nil || caller.Login == "" {
+		return usermodel.User{}, err
+	}
+	oldUser, err := sm.existingUser(userId)
+	if err != nil {
+		return usermodel.User{}, err
+	}
+	user, err := usermodel.MergeUserInfo(oldUser, userInfo)
+	if err != nil {
+		return usermodel.User{}, err
+	}
+	err = sm.storage.UpdateUser(user)
+	sm.audit(usermodel.AuditEvent{
+		ActorID: caller.ID,
+		UserID:  user.ID,
+		Login:   user.Login,
+		Action:  usermodel.AuditProfileUpdate,
+		Outcome: auditOutcome(err == nil),
+		Changes: usermodel.DiffProfiles(oldUser, user),
+	})
+	if err != nil {
+		return usermodel.User{}, err
+	}
+	if user.Email != oldUser.Email {
+		sm.sendEmailVerification(user)
+	}
+	return user, nil
+}
+
+// SetUserSuspended suspends or reinstates a user. A suspended user is signed
+// out everywhere and can neither log in nor use its API keys until
+// reinstated. Admins cannot suspend themselves.
+func (sm *StorageManager) SetUserSuspended(jwt string, userId uuid.UUID, suspended bool) (usermodel.User, error) {
+	caller, err := sm.platformAdmin(jwt)
+	if err != nil || caller.Login == "" {
+		return usermodel.User{}, err
+	}
+	if suspended && userId == caller.ID {
+		return usermodel.User{}, usermodel.ErrSuspendSelf
+	}
+	user, err := sm.existingUser(userId)
+	if err != nil || user.Suspended == suspended {
+		return user, err
+	}
+	user.Suspended = suspended
+	user.UpdateDate = time.Now()
+	if err := sm.storage.UpdateUser(user); err != nil {
+		return usermodel.User{}, err
+	}
+	action := usermodel.AuditUnsuspend
+	if suspended {
+		action = usermodel.AuditSuspend
+	}
+	sm.audit(usermodel.AuditEvent{
+		ActorID: caller.ID,
+		UserID:  user.ID,
+		Login:   user.Login,
+		Action:  action,
+		Outcome: usermodel.AuditSuccess,
+	})
+	if !suspended {
+		return user, nil
+	}
+	return user, sm.endSessions(user.ID)
+}
+
+// ForceLogout revokes every session and token of a user on behalf of a
+// platform admin.
+func (sm *StorageManager) ForceLogout(jwt string, userId uuid.UUID) (bool, error) {
+	caller, err := sm.platformAdmin(jwt)
+	if err != nil || caller.Login == "" {
+		return false, err
+	}
+	user, err := sm.existingUser(userId)
+	if err != nil {
+		return false, err
+	}
+	err = sm.endSessions(user.ID)
+	sm.audit(usermodel.AuditEvent{
+		ActorID: caller.ID,
+		UserID:  user.ID,
+		Login:   user.Login,
+		Action:  usermodel.AuditForceLogout,
+		Outcome: auditOutcome(err == nil),
+	})
+	return err == nil, err
+}
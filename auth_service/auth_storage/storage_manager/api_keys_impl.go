@@ -0,0 +1,94 @@
+package smimpl
+
+import (
+	userkeys "authservice/auth_storage/user_keys"
+	usermodel "authservice/auth_storage/user_model"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// apiKeyLastUsedPrecision limits how often authenticating with a key writes
+// to the storage.
+const apiKeyLastUsedPrecision = time.Minute
+
+func (sm *StorageManager) CreateAPIKey(jwt, name string, scopes []string) (usermodel.APIKey, string, error) {
+	user, err := sm.GetUserByJWT(jwt)
+	if err != nil || user.Login == "" {
+		return usermodel.APIKey{}, "", err
+	}
+	if !user.IsCompany {
+		return usermodel.APIKey{}, "", usermodel.ErrAPIKeysUnavailable
+	}
+	name = strings.TrimSpace(name)
+	if !usermodel.IsValidAPIKey(name, scopes) {
+		return usermodel.APIKey{}, "", usermodel.ErrInvalidAPIKey
+	}
+	slices.Sort(scopes)
+	keyRaw, keyHash := userkeys.GenAPIKey()
+	apiKey := usermodel.APIKey{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		Name:         name,
+		KeyHash:      keyHash,
+		Scopes:       slices.Compact(scopes),
+		CreationDate: time.Now(),
+	}
+	if err := sm.storage.AddAPIKey(apiKey); err != nil {
+		return usermodel.APIKey{}, "", err
+	}
+	return apiKey, keyRaw, nil
+}
+
+func (sm *StorageManager) ListAPIKeys(jwt string) ([]usermodel.APIKey, bool, error) {
+	user, err := sm.GetUserByJWT(jwt)
+	if err != nil || user.Login == "" {
+		return nil, false, err
+	}
+	apiKeys, err := sm.storage.GetUserAPIKeys(user.ID)
+	return apiKeys, err == nil, err
+}
+
+func (sm *StorageManager) RevokeAPIKey(jwt string, keyId uuid.UUID) (bool, error) {
+	user, err := sm.GetUserByJWT(jwt)
+	if err != nil || user.Login == "" {
+		return false, err
+	}
+	deleted, err := sm.storage.DeleteAPIKey(user.ID, keyId)
+	if err != nil {
+		return false, err
+	}
+	if !deleted {
+		return false, usermodel.ErrAPIKeyNotFound
+	}
+	return true, nil
+}
+
+// AuthenticateAPIKey returns the key and its owner with its organisation, or
+// empty values if the key is unknown, was revoked or its owner is suspended.
+func (sm *StorageManager) AuthenticateAPIKey(key string) (usermodel.APIKey, usermodel.User, error) {
+	if !strings.HasPrefix(key, userkeys.APIKeyPrefix) {
+		return usermodel.APIKey{}, usermodel.User{}, nil
+	}
+	apiKey, ok, err := sm.storage.GetAPIKeyByHash(userkeys.HashAPIKey(key))
+	if err != nil || !ok {
+		return usermodel.APIKey{}, usermodel.User{}, err
+	}
+	user, err := sm.storage.GetUserById(apiKey.UserID)
+	if err != nil || user.Login == "" || user.Suspended {
+		return usermodel.APIKey{}, usermodel.User{}, err
+	}
+	if user.OrganisationID, err = sm.organisationId(user.ID); err != nil {
+		return usermodel.APIKey{}, usermodel.User{}, err
+	}
+	now := time.Now()
+	if now.Sub(apiKey.LastUsedDate) > apiKeyLastUsedPrecision {
+		apiKey.LastUsedDate = now
+		if err := sm.storage.UpdateAPIKeyLastUsed(apiKey.ID, now); err != nil {
+			return usermodel.APIKey{}, usermodel.User{}, err
+		}
+	}
+	return apiKey, user, nil
+}
@@ -0,0 +1,62 @@
+package smimpl
+
+import (
+	usermodel "authservice/auth_storage/user_model"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAuditEventsLimit = 100
+	maxAuditEventsLimit     = 1000
+)
+
+// audit failures are only logged, like email delivery: the action itself has
+// already happened. The event is mirrored to Kafka through the outbox, written
+// in the same transaction, so it is published even if Kafka is down.
+func (sm *StorageManager) audit(event usermodel.AuditEvent) {
+	event.ID = uuid.New()
+	event.CreationDate = time.Now()
+	message, err := auditMessage(event)
+	if err == nil {
+		err = sm.storage.Transaction(func(tx Storage) error {
+			if err := tx.AddAuditEvent(event); err != nil {
+				return err
+			}
+			return tx.AddOutboxMessage(message)
+		})
+	}
+	if err != nil {
+		log.Printf("Failed to store audit event %s of %s: %v", event.Action, event.Login, err)
+	}
+}
+
+func auditOutcome(ok bool) usermodel.AuditOutcome {
+	if ok {
+		return usermodel.AuditSuccess
+	}
+	return usermodel.AuditFailure
+}
+
+// ListAuditEvents returns the events of the caller, platform admins can read
+// the events of any user.
+func (sm *StorageManager) ListAuditEvents(jwt string, filter usermodel.AuditFilter) ([]usermodel.AuditEvent, bool, error) {
+	caller, err := sm.GetUserByJWT(jwt)
+	if err != nil || caller.Login == "" {
+		return nil, false, err
+	}
+	if caller.Role != usermodel.RolePlatformAdmin {
+		if filter.UserID != uuid.Nil && filter.UserID != caller.ID {
+			return nil, false, usermodel.ErrForbidden
+		}
+		filter.UserID = caller.ID
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditEventsLimit
+	}
+	filter.Limit = min(filter.Limit, maxAuditEventsLimit)
+	events, err := sm.storage.GetAuditEvents(filter)
+	return events, err == nil, err
+}
@@ -0,0 +1,206 @@
+package smimpl
+
+import (
+	userkeys "authservice/auth_storage/user_keys"
+	usermodel "authservice/auth_storage/user_model"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// introspectionCacheTTL bounds how long a revocation made by another
+	// replica goes unnoticed. Callers are told to cache for the same time.
+	introspectionCacheTTL     = time.Second * 10
+	maxIntrospectionCacheSize = 100000
+)
+
+type introspectionEntry struct {
+	result      usermodel.TokenIntrospection
+	cachedUntil time.Time
+}
+
+// introspectionCache keeps introspections by token ID. The signature of a
+// token is still checked on every call, only the storage lookups are saved.
+// Every invalidation bumps generation, so a lookup that raced with it is not
+// cached.
+type introspectionCache struct {
+	entries    map[uuid.UUID]introspectionEntry
+	generation uint64
+	mx         sync.Mutex
+}
+
+func newIntrospectionCache() *introspectionCache {
+	return &introspectionCache{entries: make(map[uuid.UUID]introspectionEntry)}
+}
+
+func (c *introspectionCache) get(tokenId uuid.UUID, now time.Time) (introspectionEntry, uint64, bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	entry, ok := c.entries[tokenId]
+	if ok && !now.Before(entry.cachedUntil) {
+		delete(c.entries, tokenId)
+		ok = false
+	}
+	return entry, c.generation, ok
+}
+
+func (c *introspectionCache) put(generation uint64, entry introspectionEntry, now time.Time) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if len(c.entries) >= maxIntrospectionCacheSize {
+		for id, cached := range c.entries {
+			if !now.Before(cached.cachedUntil) {
+				delete(c.entries, id)
+			}
+		}
+		if len(c.entries) >= maxIntrospectionCacheSize {
+			clear(c.entries)
+		}
+	}
+	c.entries[entry.result.TokenID] = entry
+}
+
+func (c *introspectionCache) forget(match func(usermodel.TokenIntrospection) bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.generation++
+	for id, entry := range c.entries {
+		if match(entry.result) {
+			delete(c.entries, id)
+		}
+	}
+}
+
+func (c *introspectionCache) forgetUser(userId uuid.UUID) {
+	c.forget(func(result usermodel.TokenIntrospection) bool { return result.UserID == userId })
+}
+
+// introspectionStorage drops cached introspections whenever a write could
+// change them: token and session revocations, profile and membership changes
+// and account deletion.
+type introspectionStorage struct {
+	Storage
+	cache *introspectionCache
+}
+
+func (s *introspectionStorage) Transaction(fn func(tx Storage) error) error {
+	return s.Storage.Transaction(func(tx Storage) error {
+		return fn(&introspectionStorage{Storage: tx, cache: s.cache})
+	})
+}
+
+func (s *introspectionStorage) UpdateUser(user usermodel.User) error {
+	defer s.cache.forgetUser(user.ID)
+	return s.Storage.UpdateUser(user)
+}
+
+func (s *introspectionStorage) DeleteUser(userId uuid.UUID) error {
+	defer s.cache.forgetUser(userId)
+	return s.Storage.DeleteUser(userId)
+}
+
+func (s *introspectionStorage) SaveMembership(member usermodel.OrganisationMember) error {
+	defer s.cache.forgetUser(member.UserID)
+	return s.Storage.SaveMembership(member)
+}
+
+func (s *introspectionStorage) DeleteMembership(userId uuid.UUID) error {
+	defer s.cache.forgetUser(userId)
+	return s.Storage.DeleteMembership(userId)
+}
+
+func (s *introspectionStorage) DeleteOrganisation(organisationId uuid.UUID) error {
+	defer s.cache.forget(func(result usermodel.TokenIntrospection) bool { return result.OrganisationID == organisationId })
+	return s.Storage.DeleteOrganisation(organisationId)
+}
+
+func (s *introspectionStorage) RevokeToken(tokenId uuid.UUID, expiresAt time.Time) error {
+	defer s.cache.forget(func(result usermodel.TokenIntrospection) bool { return result.TokenID == tokenId })
+	return s.Storage.RevokeToken(tokenId, expiresAt)
+}
+
+func (s *introspectionStorage) RevokeUserTokens(userId uuid.UUID) error {
+	defer s.cache.forgetUser(userId)
+	return s.Storage.RevokeUserTokens(userId)
+}
+
+func (s *introspectionStorage) RevokeSession(userId, sessionId uuid.UUID, revokedAt time.Time) (bool, error) {
+	defer s.cache.forget(func(result usermodel.TokenIntrospection) bool { return result.SessionID == sessionId })
+	return s.Storage.RevokeSession(userId, sessionId, revokedAt)
+}
+
+// IntrospectToken reports whether an access token may be trusted, for
+// services authenticating calls on their own. Tokens with a bad signature or
+// past their expiry get an empty answer. An error means the storage failed,
+// never that the token is bad.
+func (sm *StorageManager) IntrospectToken(jwt string) (usermodel.TokenIntrospection, error) {
+	claims, ok := userkeys.ParseJWT(jwt)
+	if !ok {
+		return usermodel.TokenIntrospection{}, nil
+	}
+	now := time.Now()
+	entry, generation, ok := sm.introspections.get(claims.TokenID, now)
+	if !ok {
+		var err error
+		entry, err = sm.introspect(claims, now)
+		if err != nil {
+			return usermodel.TokenIntrospection{}, err
+		}
+		sm.introspections.put(generation, entry, now)
+	}
+	result := entry.result
+	result.CacheTTL = entry.cachedUntil.Sub(now)
+	return result, nil
+}
+
+func (sm *StorageManager) introspect(claims userkeys.TokenClaims, now time.Time) (introspectionEntry, error) {
+	result := usermodel.TokenIntrospection{
+		UserID:                 claims.UserID,
+		TokenID:                claims.TokenID,
+		SessionID:              claims.SessionID,
+		TwoFactorSetupRequired: claims.TwoFactorSetupRequired,
+		IssuedAt:               claims.IssuedAt,
+		ExpiresAt:              claims.ExpiresAt,
+	}
+	revoked, err := sm.isTokenRevoked(claims.UserID, claims.TokenID, claims.Generation)
+	if err != nil {
+		return introspectionEntry{}, err
+	}
+	if !revoked && claims.SessionID != uuid.Nil {
+		session, active, err := sm.getActiveSession(claims.UserID, claims.SessionID)
+		if err != nil {
+			return introspectionEntry{}, err
+		}
+		if active {
+			// Introspections are cached, so the session is seen at most once
+			// per cache period.
+			if err := sm.touchSession(session); err != nil {
+				return introspectionEntry{}, err
+			}
+		}
+		revoked = !active
+	}
+	user, err := sm.storage.GetUserById(claims.UserID)
+	if err != nil {
+		return introspectionEntry{}, err
+	}
+	result.Role, result.IsCompany, result.EmailVerified, result.Suspended = user.Role, user.IsCompany, user.EmailVerified, user.Suspended
+	if result.OrganisationID, err = sm.organisationId(claims.UserID); err != nil {
+		return introspectionEntry{}, err
+	}
+	result.Revoked = revoked || user.Login == ""
+	result.Active = !result.Revoked && !result.Suspended
+
+	// A revoked token never becomes active again, so it is kept until it
+	// expires. Reinstating a suspended user drops its entries.
+	cachedUntil := claims.ExpiresAt
+	if result.Active && now.Add(introspectionCacheTTL).Before(cachedUntil) {
+		cachedUntil = now.Add(introspectionCacheTTL)
+	}
+	return introspectionEntry{result: result, cachedUntil: cachedUntil}, nil
+}
@@ -0,0 +1,60 @@
+package smimpl
+
+import (
+	loginthrottle "authservice/auth_storage/login_throttle"
+	usermodel "authservice/auth_storage/user_model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type throttleKey struct {
+	key          string
+	freeAttempts int
+}
+
+// loginThrottleKeys counts failures per login and, when the gateway forwarded
+// it, per client IP, so neither guessing one password nor spraying many
+// logins from one address goes unlimited.
+func (sm *StorageManager) loginThrottleKeys(login, clientIP string) []throttleKey {
+	keys := []throttleKey{{loginthrottle.LoginKey(login), sm.loginThrottlePolicy.LoginFreeAttempts}}
+	if clientIP != "" {
+		keys = append(keys, throttleKey{loginthrottle.IPKey(clientIP), sm.loginThrottlePolicy.IPFreeAttempts})
+	}
+	return keys
+}
+
+// twoFactorThrottleKeys counts wrong codes per user and per client IP. Every
+// challenge allows one guess, but a caller knowing the password can get as
+// many challenges as it likes.
+func (sm *StorageManager) twoFactorThrottleKeys(userId uuid.UUID, clientIP string) []throttleKey {
+	keys := []throttleKey{{loginthrottle.TwoFactorKey(userId.String()), sm.loginThrottlePolicy.LoginFreeAttempts}}
+	if clientIP != "" {
+		keys = append(keys, throttleKey{loginthrottle.IPKey(clientIP), sm.loginThrottlePolicy.IPFreeAttempts})
+	}
+	return keys
+}
+
+func (sm *StorageManager) checkLoginLockout(keys []throttleKey, now time.Time) error {
+	var retryAfter time.Duration
+	for _, key := range keys {
+		attempts, err := sm.storage.GetLoginAttempts(key.key)
+		if err != nil {
+			return err
+		}
+		retryAfter = max(retryAfter, sm.loginThrottlePolicy.RetryAfter(attempts, key.freeAttempts, now))
+	}
+	if retryAfter > 0 {
+		return &usermodel.LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+func (sm *StorageManager) recordLoginFailure(keys []throttleKey, now time.Time) error {
+	for _, key := range keys {
+		if err := sm.storage.AddLoginFailure(key.key, now, now.Add(-sm.loginThrottlePolicy.ResetAfter)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,507 @@
+package smimpl
+
+import (
+	userkeys "authservice/auth_storage/user_keys"
+	usermodel "authservice/auth_storage/user_model"
+	"context"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const maxOrganisationNameLength = 100
+
+// addOrganisation makes a new company account the owner of an organisation
+// named after its login. It runs in the transaction adding the user.
+func addOrganisation(tx Storage, userId uuid.UUID, name string) (uuid.UUID, error) {
+	now := time.Now()
+	organisation := usermodel.Organisation{ID: uuid.New(), Name: name, CreationDate: now, UpdateDate: now}
+	if err := tx.AddOrganisation(organisation); err != nil {
+		return uuid.Nil, err
+	}
+	return organisation.ID, tx.SaveMembership(usermodel.OrganisationMember{
+		OrganisationID: organisation.ID,
+		UserID:         userId,
+		Role:           usermodel.OrganisationRoleOwner,
+		CreationDate:   now,
+	})
+}
+
+// organisationId is uuid.Nil for users outside of organisations.
+func (sm *StorageManager) organisationId(userId uuid.UUID) (uuid.UUID, error) {
+	member, _, err := sm.storage.GetMembership(userId)
+	return member.OrganisationID, err
+}
+
+// organisationCaller returns the caller and its membership. Both are empty if
+// the JWT is invalid.
+func (sm *StorageManager) organisationCaller(jwt string) (usermodel.User, usermodel.OrganisationMember, error) {
+	claims, ok, err := sm.parseJWT(jwt)
+	if err != nil || !ok {
+		return usermodel.User{}, usermodel.OrganisationMember{}, err
+	}
+	user, err := sm.storage.GetUserById(claims.UserID)
+	if err != nil || user.Login == "" {
+		return usermodel.User{}, usermodel.OrganisationMember{}, err
+	}
+	member, ok, err := sm.storage.GetMembership(user.ID)
+	if err != nil {
+		return usermodel.User{}, usermodel.OrganisationMember{}, err
+	}
+	if !ok {
+		return usermodel.User{}, usermodel.OrganisationMember{}, usermodel.ErrNotInOrganisation
+	}
+	user.OrganisationID = member.OrganisationID
+	return user, member, nil
+}
+
+func canManageMembers(member usermodel.OrganisationMember) bool {
+	return member.Role == usermodel.OrganisationRoleOwner || member.Role == usermodel.OrganisationRoleAdmin
+}
+
+// organisationTarget returns another member of the organisation of caller
+// that caller may manage: owners manage everyone, admins only members.
+func (sm *StorageManager) organisationTarget(caller usermodel.OrganisationMember, userId uuid.UUID) (usermodel.OrganisationMember, error) {
+	if !canManageMembers(caller) {
+		return usermodel.OrganisationMember{}, usermodel.ErrForbidden
+	}
+	target, ok, err := sm.storage.GetMembership(userId)
+	if err != nil {
+		return usermodel.OrganisationMember{}, err
+	}
+	if !ok || target.OrganisationID != caller.OrganisationID {
+		return usermodel.OrganisationMember{}, usermodel.ErrMemberNotFound
+	}
+	if target.Role == usermodel.OrganisationRoleOwner {
+		return usermodel.OrganisationMember{}, usermodel.ErrOrganisationOwner
+	}
+	if caller.Role != usermodel.OrganisationRoleOwner && target.Role != usermodel.OrganisationRoleMember {
+		return usermodel.OrganisationMember{}, usermodel.ErrForbidden
+	}
+	return target, nil
+}
+
+// fillMember adds the login and email of the member's account.
+func (sm *StorageManager) fillMember(member *usermodel.OrganisationMember) error {
+	user, err := sm.storage.GetUserById(member.UserID)
+	member.Login, member.Email = user.Login, user.Email
+	return err
+}
+
+// getOrganisation lists the members and, for owners and admins, the pending
+// invitations.
+func (sm *StorageManager) getOrganisation(viewer usermodel.OrganisationMember) (usermodel.Organisation, error) {
+	organisation, ok, err := sm.storage.GetOrganisation(viewer.OrganisationID)
+	if err != nil {
+		return usermodel.Organisation{}, err
+	}
+	if !ok {
+		return usermodel.Organisation{}, usermodel.ErrNotInOrganisation
+	}
+	members, err := sm.storage.GetOrganisationMembers(organisation.ID)
+	if err != nil {
+		return usermodel.Organisation{}, err
+	}
+	for i := range members {
+		if err := sm.fillMember(&members[i]); err != nil {
+			return usermodel.Organisation{}, err
+		}
+	}
+	organisation.Members = members
+	if canManageMembers(viewer) {
+		invitations, err := sm.storage.GetOrganisationInvitations(organisation.ID)
+		if err != nil {
+			return usermodel.Organisation{}, err
+		}
+		now := time.Now()
+		organisation.Invitations = slices.DeleteFunc(invitations, func(invitation usermodel.OrganisationInvitation) bool {
+			return !invitation.ExpiresAt.After(now)
+		})
+	}
+	return organisation, nil
+}
+
+func (sm *StorageManager) GetOrganisation(jwt string) (usermodel.Organisation, error) {
+	_, caller, err := sm.organisationCaller(jwt)
+	if err != nil || caller.UserID == uuid.Nil {
+		return usermodel.Organisation{}, err
+	}
+	return sm.getOrganisation(caller)
+}
+
+func (sm *StorageManager) RenameOrganisation(jwt, name string) (usermodel.Organisation, error) {
+	_, caller, err := sm.organisationCaller(jwt)
+	if err != nil || caller.UserID == uuid.Nil {
+		return usermodel.Organisation{}, err
+	}
+	if !canManageMembers(caller) {
+		return usermodel.Organisation{}, usermodel.ErrForbidden
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxOrganisationNameLength {
+		return usermodel.Organisation{}, usermodel.ErrInvalidOrganisationName
+	}
+	err = sm.storage.UpdateOrganisation(usermodel.Organisation{ID: caller.OrganisationID, Name: name, UpdateDate: time.Now()})
+	if err != nil {
+		return usermodel.Organisation{}, err
+	}
+	return sm.getOrganisation(caller)
+}
+
+// InviteMember emails an invitation to join the organisation of the caller.
+// Only owners can invite admins. A new invitation to the same address
+// replaces the pending one.
+func (sm *StorageManager) InviteMember(jwt, email string, role usermodel.OrganisationRole) (usermodel.OrganisationInvitation, error) {
+	user, caller, err := sm.organisationCaller(jwt)
+	if err != nil || caller.UserID == uuid.Nil {
+		return usermodel.OrganisationInvitation{}, err
+	}
+	if !canManageMembers(caller) {
+		return usermodel.OrganisationInvitation{}, usermodel.ErrForbidden
+	}
+	email = strings.TrimSpace(email)
+	if err := usermodel.ValidateInvitation(email, role); err != nil {
+		return usermodel.OrganisationInvitation{}, err
+	}
+	if role == usermodel.OrganisationRoleAdmin && caller.Role != usermodel.OrganisationRoleOwner {
+		return usermodel.OrganisationInvitation{}, usermodel.ErrForbidden
+	}
+	organisation, ok, err := sm.storage.GetOrganisation(caller.OrganisationID)
+	if err != nil || !ok {
+		return usermodel.OrganisationInvitation{}, err
+	}
+	pending, err := sm.storage.GetOrganisationInvitations(organisation.ID)
+	if err != nil {
+		return usermodel.OrganisationInvitation{}, err
+	}
+	for _, invitation := range pending {
+		if !strings.EqualFold(invitation.Email, email) {
+			continue
+		}
+		if _, err := sm.storage.DeleteInvitation(organisation.ID, invitation.ID); err != nil {
+			return usermodel.OrganisationInvitation{}, err
+		}
+	}
+	token, tokenHash := userkeys.GenInvitationToken()
+	now := time.Now()
+	invitation := usermodel.OrganisationInvitation{
+		ID:             uuid.New(),
+		OrganisationID: organisation.ID,
+		Email:          email,
+		Role:           role,
+		InvitedBy:      user.ID,
+		TokenHash:      tokenHash,
+		ExpiresAt:      now.Add(userkeys.InvitationTTL),
+		CreationDate:   now,
+	}
+	if err := sm.storage.AddInvitation(invitation); err != nil {
+		return usermodel.OrganisationInvitation{}, err
+	}
+	sm.audit(usermodel.AuditEvent{
+		ActorID: user.ID,
+		UserID:  user.ID,
+		Login:   user.Login,
+		Action:  usermodel.AuditOrganisationInvite,
+		Outcome: usermodel.AuditSuccess,
+		Changes: []usermodel.FieldChange{
+			{Field: "email", New: email},
+			{Field: "organisation_role", New: string(role)},
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	return invitation, sm.notifier.SendOrganisationInvitation(ctx, email, organisation.Name, token)
+}
+
+func (sm *StorageManager) CancelInvitation(jwt string, invitationId uuid.UUID) (bool, error) {
+	_, caller, err := sm.organisationCaller(jwt)
+	if err != nil || caller.UserID == uuid.Nil {
+		return false, err
+	}
+	if !canManageMembers(caller) {
+		return false, usermodel.ErrForbidden
+	}
+	deleted, err := sm.storage.DeleteInvitation(caller.OrganisationID, invitationId)
+	if err != nil {
+		return false, err
+	}
+	if !deleted {
+		return false, usermodel.ErrInvitationNotFound
+	}
+	return true, nil
+}
+
+// AcceptInvitation adds the caller to the organisation if the invitation was
+// sent to the email of its account. The account becomes a company account
+// and is signed out everywhere, the returned tokens carry the organisation.
+func (sm *StorageManager) AcceptInvitation(jwt, token string, client usermodel.ClientInfo) (usermodel.TokenPair, error) {
+	claims, ok, err := sm.parseJWT(jwt)
+	if err != nil || !ok {
+		return usermodel.TokenPair{}, err
+	}
+	user, err := sm.storage.GetUserById(claims.UserID)
+	if err != nil || user.Login == "" {
+		return usermodel.TokenPair{}, err
+	}
+	invitation, ok, err := sm.storage.GetInvitationByHash(userkeys.HashInvitationToken(token))
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	if !ok || !time.Now().Before(invitation.ExpiresAt) {
+		return usermodel.TokenPair{}, usermodel.ErrInvitationNotFound
+	}
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		return usermodel.TokenPair{}, usermodel.ErrInvitationForOtherEmail
+	}
+	if user.Role == usermodel.RolePlatformAdmin {
+		return usermodel.TokenPair{}, usermodel.ErrForbidden
+	}
+	oldUser := user
+	err = sm.storage.Transaction(func(tx Storage) error {
+		if _, ok, err := tx.GetMembership(user.ID); err != nil || ok {
+			if err == nil {
+				err = usermodel.ErrAlreadyInOrganisation
+			}
+			return err
+		}
+		err := tx.SaveMembership(usermodel.OrganisationMember{
+			OrganisationID: invitation.OrganisationID,
+			UserID:         user.ID,
+			Role:           invitation.Role,
+			CreationDate:   time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := tx.DeleteInvitation(invitation.OrganisationID, invitation.ID); err != nil {
+			return err
+		}
+		user.IsCompany = true
+		user.Role = usermodel.CompanyRole(invitation.Role)
+		user.UpdateDate = time.Now()
+		return tx.UpdateUser(user)
+	})
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	sm.audit(usermodel.AuditEvent{
+		ActorID: user.ID,
+		UserID:  user.ID,
+		Login:   user.Login,
+		Action:  usermodel.AuditOrganisationJoin,
+		IP:      client.IP,
+		Outcome: usermodel.AuditSuccess,
+		Changes: append(usermodel.DiffProfiles(oldUser, user),
+			usermodel.FieldChange{Field: "organisation_role", New: string(invitation.Role)}),
+	})
+	if err := sm.signOutEverywhere(user.ID); err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	sessionId, err := sm.newSession(sm.storage, user, client)
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	return sm.issueTokens(user, sessionId, client)
+}
+
+// signOutEverywhere revokes every session and token of the user, so that the
+// next login carries its new role or organisation.
+func (sm *StorageManager) signOutEverywhere(userId uuid.UUID) error {
+	if err := sm.storage.RevokeUserRefreshTokens(userId); err != nil {
+		return err
+	}
+	return sm.storage.RevokeUserTokens(userId)
+}
+
+// setCompanyRole updates the platform role of a member to match its
+// organisation role. It reports whether the role changed, the member then has
+// to be signed out.
+func setCompanyRole(tx Storage, userId uuid.UUID, role usermodel.Role) (bool, error) {
+	user, err := tx.GetUserById(userId)
+	if err != nil || user.Login == "" || user.Role == role {
+		return false, err
+	}
+	user.Role = role
+	user.UpdateDate = time.Now()
+	return true, tx.UpdateUser(user)
+}
+
+// SetMemberRole switches a member between admin and member. Admins can only
+// be appointed or demoted by the owner.
+func (sm *StorageManager) SetMemberRole(jwt string, userId uuid.UUID, role usermodel.OrganisationRole) (usermodel.OrganisationMember, error) {
+	user, caller, err := sm.organisationCaller(jwt)
+	if err != nil || caller.UserID == uuid.Nil {
+		return usermodel.OrganisationMember{}, err
+	}
+	if !usermodel.IsValidMemberRole(role) {
+		return usermodel.OrganisationMember{}, usermodel.ErrInvalidMemberRole
+	}
+	target, err := sm.organisationTarget(caller, userId)
+	if err != nil {
+		return usermodel.OrganisationMember{}, err
+	}
+	if role == usermodel.OrganisationRoleAdmin && caller.Role != usermodel.OrganisationRoleOwner {
+		return usermodel.OrganisationMember{}, usermodel.ErrForbidden
+	}
+	oldRole := target.Role
+	if oldRole != role {
+		target.Role = role
+		var roleChanged bool
+		err = sm.storage.Transaction(func(tx Storage) error {
+			if err := tx.SaveMembership(target); err != nil {
+				return err
+			}
+			roleChanged, err = setCompanyRole(tx, target.UserID, usermodel.CompanyRole(role))
+			return err
+		})
+		if err != nil {
+			return usermodel.OrganisationMember{}, err
+		}
+		if roleChanged {
+			if err := sm.signOutEverywhere(target.UserID); err != nil {
+				return usermodel.OrganisationMember{}, err
+			}
+		}
+	}
+	if err := sm.fillMember(&target); err != nil {
+		return usermodel.OrganisationMember{}, err
+	}
+	if oldRole != role {
+		sm.audit(usermodel.AuditEvent{
+			ActorID: user.ID,
+			UserID:  target.UserID,
+			Login:   target.Login,
+			Action:  usermodel.AuditOrganisationRoleChange,
+			Outcome: usermodel.AuditSuccess,
+			Changes: []usermodel.FieldChange{{Field: "organisation_role", Old: string(oldRole), New: string(role)}},
+		})
+	}
+	return target, nil
+}
+
+// RemoveMember removes another member or, with the caller's own ID, leaves
+// the organisation. The account goes back to a customer account and loses
+// its API keys. The owner can only leave after transferring the organisation.
+func (sm *StorageManager) RemoveMember(jwt string, userId uuid.UUID) (bool, error) {
+	user, caller, err := sm.organisationCaller(jwt)
+	if err != nil || caller.UserID == uuid.Nil {
+		return false, err
+	}
+	target := caller
+	if userId != caller.UserID {
+		if target, err = sm.organisationTarget(caller, userId); err != nil {
+			return false, err
+		}
+	} else if caller.Role == usermodel.OrganisationRoleOwner {
+		return false, usermodel.ErrOrganisationOwner
+	}
+	var removed usermodel.User
+	err = sm.storage.Transaction(func(tx Storage) error {
+		if err := tx.DeleteMembership(target.UserID); err != nil {
+			return err
+		}
+		apiKeys, err := tx.GetUserAPIKeys(target.UserID)
+		if err != nil {
+			return err
+		}
+		for _, apiKey := range apiKeys {
+			if _, err := tx.DeleteAPIKey(target.UserID, apiKey.ID); err != nil {
+				return err
+			}
+		}
+		removed, err = tx.GetUserById(target.UserID)
+		if err != nil || removed.Login == "" {
+			return err
+		}
+		removed.IsCompany = false
+		removed.Role = usermodel.DefaultRole(false)
+		removed.UpdateDate = time.Now()
+		return tx.UpdateUser(removed)
+	})
+	if err != nil {
+		return false, err
+	}
+	sm.audit(usermodel.AuditEvent{
+		ActorID: user.ID,
+		UserID:  target.UserID,
+		Login:   removed.Login,
+		Action:  usermodel.AuditOrganisationLeave,
+		Outcome: usermodel.AuditSuccess,
+		Changes: []usermodel.FieldChange{{Field: "organisation_role", Old: string(target.Role)}},
+	})
+	return true, sm.signOutEverywhere(target.UserID)
+}
+
+// TransferOwnership makes another member the owner. The previous owner stays
+// in the organisation as an admin.
+func (sm *StorageManager) TransferOwnership(jwt string, userId uuid.UUID) (usermodel.Organisation, error) {
+	user, caller, err := sm.organisationCaller(jwt)
+	if err != nil || caller.UserID == uuid.Nil {
+		return usermodel.Organisation{}, err
+	}
+	if caller.Role != usermodel.OrganisationRoleOwner {
+		return usermodel.Organisation{}, usermodel.ErrForbidden
+	}
+	if userId == caller.UserID {
+		return sm.getOrganisation(caller)
+	}
+	target, err := sm.organisationTarget(caller, userId)
+	if err != nil {
+		return usermodel.Organisation{}, err
+	}
+	oldRole := target.Role
+	var roleChanged bool
+	err = sm.storage.Transaction(func(tx Storage) error {
+		caller.Role = usermodel.OrganisationRoleAdmin
+		if err := tx.SaveMembership(caller); err != nil {
+			return err
+		}
+		target.Role = usermodel.OrganisationRoleOwner
+		if err := tx.SaveMembership(target); err != nil {
+			return err
+		}
+		roleChanged, err = setCompanyRole(tx, target.UserID, usermodel.CompanyRole(target.Role))
+		return err
+	})
+	if err != nil {
+		return usermodel.Organisation{}, err
+	}
+	if roleChanged {
+		if err := sm.signOutEverywhere(target.UserID); err != nil {
+			return usermodel.Organisation{}, err
+		}
+	}
+	if err := sm.fillMember(&target); err != nil {
+		return usermodel.Organisation{}, err
+	}
+	sm.audit(usermodel.AuditEvent{
+		ActorID: user.ID,
+		UserID:  target.UserID,
+		Login:   target.Login,
+		Action:  usermodel.AuditOrganisationTransfer,
+		Outcome: usermodel.AuditSuccess,
+		Changes: []usermodel.FieldChange{{Field: "organisation_role", Old: string(oldRole), New: string(target.Role)}},
+	})
+	return sm.getOrganisation(caller)
+}
+
+// deleteMembership runs before an account is deleted. It deletes the
+// organisation of an owner who is its last member and returns its ID, other
+// owners have to transfer the organisation first.
+func deleteMembership(tx Storage, userId uuid.UUID) (uuid.UUID, error) {
+	member, ok, err := tx.GetMembership(userId)
+	if err != nil || !ok || member.Role != usermodel.OrganisationRoleOwner {
+		return uuid.Nil, err
+	}
+	members, err := tx.GetOrganisationMembers(member.OrganisationID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if len(members) > 1 {
+		return uuid.Nil, usermodel.ErrOrganisationOwner
+	}
+	return member.OrganisationID, tx.DeleteOrganisation(member.OrganisationID)
+}
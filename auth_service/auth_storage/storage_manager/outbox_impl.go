@@ -0,0 +1,60 @@
+package smimpl
+
+import (
+	usermodel "authservice/auth_storage/user_model"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	accountDeletedEvent      = "account_deleted"
+	organisationDeletedEvent = "organisation_deleted"
+)
+
+// statsEvent is a message of the stats topic in the format the gateway uses
+// for its own events. Other services clean up after deleted accounts and
+// organisations on these events, so they are written in the transaction of
+// the deletion.
+func statsEvent(eventType string, userId, objectId uuid.UUID) (usermodel.OutboxMessage, error) {
+	now := time.Now()
+	value, err := json.Marshal(map[string]interface{}{
+		"event_type": eventType,
+		"user_id":    userId.String(),
+		"object_id":  objectId.String(),
+		"timestamp":  now.Unix(),
+	})
+	if err != nil {
+		return usermodel.OutboxMessage{}, err
+	}
+	return usermodel.OutboxMessage{
+		Topic:        usermodel.OutboxStatsTopic,
+		Key:          userId.String(),
+		Value:        value,
+		CreationDate: now,
+	}, nil
+}
+
+// auditMessage mirrors an audit event to the audit topic for long-term
+// retention.
+func auditMessage(event usermodel.AuditEvent) (usermodel.OutboxMessage, error) {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return usermodel.OutboxMessage{}, err
+	}
+	return usermodel.OutboxMessage{
+		Topic:        usermodel.OutboxAuditTopic,
+		Key:          event.UserID.String(),
+		Value:        value,
+		CreationDate: event.CreationDate,
+	}, nil
+}
+
+func addStatsEvent(tx Storage, eventType string, userId, objectId uuid.UUID) error {
+	message, err := statsEvent(eventType, userId, objectId)
+	if err != nil {
+		return err
+	}
+	return tx.AddOutboxMessage(message)
+}
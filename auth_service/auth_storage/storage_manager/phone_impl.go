@@ -0,0 +1,134 @@
+package smimpl
+
+import (
+	usermodel "authservice/auth_storage/user_model"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	phoneCodeTTL         = time.Minute * 10
+	phoneCodeResendDelay = time.Minute
+	maxPhoneCodeAttempts = 5
+)
+
+func newPhoneCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func hashPhoneCode(userId uuid.UUID, code string) []byte {
+	sum := sha256.Sum256([]byte(userId.String() + ":" + code))
+	return sum[:]
+}
+
+// isPhoneNumberTaken reports whether another user has verified the phone
+// number of user. It is only told to users who proved they own the number,
+// anyone else could use it to find out who has verified which number.
+func (sm *StorageManager) isPhoneNumberTaken(user usermodel.User) (bool, error) {
+	owner, err := sm.storage.GetUserByVerifiedPhoneNumber(user.PhoneNumber)
+	return owner.Login != "" && owner.ID != user.ID, err
+}
+
+// RequestPhoneVerification texts a one-time code to the phone number of the
+// profile. A new code replaces the pending one.
+func (sm *StorageManager) RequestPhoneVerification(jwt string) (bool, error) {
+	if sm.smsNotifier == nil {
+		return false, usermodel.ErrPhoneVerificationUnavailable
+	}
+	user, err := sm.GetUserByJWT(jwt)
+	if err != nil || user.Login == "" {
+		return false, err
+	}
+	switch {
+	case user.PhoneNumber == "":
+		return false, usermodel.ErrNoPhoneNumber
+	case user.PhoneNumberVerified:
+		return false, usermodel.ErrPhoneNumberVerified
+	}
+	now := time.Now()
+	pending, ok, err := sm.storage.GetPhoneVerification(user.ID)
+	if err != nil {
+		return false, err
+	}
+	if ok && pending.PhoneNumber == user.PhoneNumber && now.Sub(pending.CreationDate) < phoneCodeResendDelay {
+		return false, usermodel.ErrPhoneCodeRecentlySent
+	}
+	code, err := newPhoneCode()
+	if err != nil {
+		return false, err
+	}
+	err = sm.storage.SavePhoneVerification(usermodel.PhoneVerification{
+		UserID:       user.ID,
+		PhoneNumber:  user.PhoneNumber,
+		CodeHash:     hashPhoneCode(user.ID, code),
+		ExpiresAt:    now.Add(phoneCodeTTL),
+		CreationDate: now,
+	})
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	if err := sm.smsNotifier.SendVerificationCode(ctx, user.PhoneNumber, code); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ConfirmPhoneNumber marks the phone number verified unless another user
+// verified it first. A code is accepted for the number it was sent to only,
+// and is dropped after maxPhoneCodeAttempts wrong guesses.
+func (sm *StorageManager) ConfirmPhoneNumber(jwt, code string) (usermodel.User, error) {
+	user, err := sm.GetUserByJWT(jwt)
+	if err != nil || user.Login == "" {
+		return usermodel.User{}, err
+	}
+	pending, ok, err := sm.storage.GetPhoneVerification(user.ID)
+	if err != nil {
+		return usermodel.User{}, err
+	}
+	if !ok || pending.PhoneNumber != user.PhoneNumber || time.Now().After(pending.ExpiresAt) ||
+		pending.Attempts >= maxPhoneCodeAttempts {
+		return usermodel.User{}, usermodel.ErrWrongPhoneCode
+	}
+	if subtle.ConstantTimeCompare(hashPhoneCode(user.ID, code), pending.CodeHash) != 1 {
+		pending.Attempts++
+		if err := sm.storage.SavePhoneVerification(pending); err != nil {
+			return usermodel.User{}, err
+		}
+		return usermodel.User{}, usermodel.ErrWrongPhoneCode
+	}
+	if taken, err := sm.isPhoneNumberTaken(user); err != nil || taken {
+		if err == nil {
+			err = usermodel.ErrPhoneNumberTaken
+		}
+		return usermodel.User{}, err
+	}
+	if err := sm.storage.DeletePhoneVerification(user.ID); err != nil {
+		return usermodel.User{}, err
+	}
+	user.PhoneNumberVerified = true
+	user.UpdateDate = time.Now()
+	// Only the verified flag changed, so a conflict is another user verifying
+	// the number since the check above.
+	err = sm.storage.UpdateUser(user)
+	if errors.Is(err, usermodel.ErrUserExists) {
+		return usermodel.User{}, usermodel.ErrPhoneNumberTaken
+	}
+	if err != nil {
+		return usermodel.User{}, err
+	}
+	return user, nil
+}
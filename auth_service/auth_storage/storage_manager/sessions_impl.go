@@ -0,0 +1,100 @@
+package smimpl
+
+import (
+	userkeys "authservice/auth_storage/user_keys"
+	usermodel "authservice/auth_storage/user_model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// sessionLastSeenPrecision limits how often requests of a session write to
+// the storage.
+const sessionLastSeenPrecision = time.Minute
+
+func (sm *StorageManager) newSession(store Storage, user usermodel.User, client usermodel.ClientInfo) (uuid.UUID, error) {
+	generation, err := store.GetUserTokenGeneration(user.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	now := time.Now()
+	session := usermodel.Session{
+		ID:              uuid.New(),
+		UserID:          user.ID,
+		UserAgent:       client.UserAgent,
+		IP:              client.IP,
+		CreationDate:    now,
+		LastSeenDate:    now,
+		TokenGeneration: generation,
+	}
+	return session.ID, store.AddSession(session)
+}
+
+// sessionClient returns the client of an existing session, so that a session
+// replaced after a password change keeps describing the same device.
+func (sm *StorageManager) sessionClient(sessionId uuid.UUID) (usermodel.ClientInfo, error) {
+	session, _, err := sm.storage.GetSession(sessionId)
+	return usermodel.ClientInfo{IP: session.IP, UserAgent: session.UserAgent}, err
+}
+
+// getActiveSession returns the session unless it was revoked on its own or
+// started before the user was signed out everywhere.
+func (sm *StorageManager) getActiveSession(userId, sessionId uuid.UUID) (usermodel.Session, bool, error) {
+	session, ok, err := sm.storage.GetSession(sessionId)
+	if err != nil || !ok || session.UserID != userId || !session.RevokedAt.IsZero() {
+		return usermodel.Session{}, false, err
+	}
+	generation, err := sm.storage.GetUserTokenGeneration(userId)
+	if err != nil || session.TokenGeneration < generation {
+		return usermodel.Session{}, false, err
+	}
+	return session, true, nil
+}
+
+func (sm *StorageManager) touchSession(session usermodel.Session) error {
+	now := time.Now()
+	if now.Sub(session.LastSeenDate) < sessionLastSeenPrecision {
+		return nil
+	}
+	return sm.storage.UpdateSessionLastSeen(session.ID, now)
+}
+
+// ListSessions returns the active sessions of the user and the ID of the
+// session the JWT belongs to. The sessions are nil if the JWT is invalid.
+func (sm *StorageManager) ListSessions(jwt string) ([]usermodel.Session, uuid.UUID, error) {
+	claims, ok, err := sm.parseJWT(jwt)
+	if err != nil || !ok {
+		return nil, uuid.Nil, err
+	}
+	sessions, err := sm.storage.GetUserSessions(claims.UserID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	generation, err := sm.storage.GetUserTokenGeneration(claims.UserID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	expiredBefore := time.Now().Add(-userkeys.RefreshTokenTTL)
+	active := make([]usermodel.Session, 0, len(sessions))
+	for _, session := range sessions {
+		if session.RevokedAt.IsZero() && session.TokenGeneration >= generation && session.LastSeenDate.After(expiredBefore) {
+			active = append(active, session)
+		}
+	}
+	return active, claims.SessionID, nil
+}
+
+func (sm *StorageManager) RevokeSession(jwt string, sessionId uuid.UUID) (bool, error) {
+	claims, ok, err := sm.parseJWT(jwt)
+	if err != nil || !ok {
+		return false, err
+	}
+	revoked, err := sm.storage.RevokeSession(claims.UserID, sessionId, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if !revoked {
+		return false, usermodel.ErrSessionNotFound
+	}
+	return true, nil
+}
@@ -1,72 +1,734 @@
 package smimpl
 
 import (
+	authmailer "authservice/auth_mailer"
+	authsms "authservice/auth_sms"
+	loginthrottle "authservice/auth_storage/login_throttle"
+	passwordhasher "authservice/auth_storage/password_hasher"
+	twofactor "authservice/auth_storage/two_factor"
 	userkeys "authservice/auth_storage/user_keys"
 	usermodel "authservice/auth_storage/user_model"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// Storage reports unique constraint conflicts on users as
+// usermodel.ErrUserExists, emails are unique ignoring case. Deleting a user removes its organisation
+// membership, deleting an organisation its memberships and invitations.
 type Storage interface {
-	GetUserPasswordByLogin(login string) ([userkeys.Md5Len]byte, bool, error)
+	// Transaction runs fn as one unit of work: everything fn does through tx is
+	// rolled back if it returns an error.
+	Transaction(fn func(tx Storage) error) error
+	GetUserPasswordByLogin(login string) ([]byte, bool, error)
 	GetUserById(userId uuid.UUID) (usermodel.User, error)
 	GetUserByLogin(login string) (usermodel.User, error)
-	AddUser(user usermodel.User, login string, password [userkeys.Md5Len]byte) (uuid.UUID, error)
+	GetUserByEmail(email string) (usermodel.User, error)
+	GetUserByVerifiedPhoneNumber(phoneNumber string) (usermodel.User, error)
+	SearchUsers(filter usermodel.UserFilter) ([]usermodel.User, error)
+	AddUser(user usermodel.User, login string, password []byte) (uuid.UUID, error)
 	UpdateUser(user usermodel.User) error
+	DeleteUser(userId uuid.UUID) error
+	UpdateUserPassword(login string, password []byte) error
+	AddRefreshToken(token usermodel.RefreshToken) error
+	GetRefreshToken(tokenHash []byte) (usermodel.RefreshToken, bool, error)
+	// RevokeRefreshToken reports whether this call revoked the token, it is
+	// false if the token was already revoked.
+	RevokeRefreshToken(tokenId uuid.UUID) (bool, error)
+	RevokeUserRefreshTokens(userId uuid.UUID) error
+	RevokeToken(tokenId uuid.UUID, expiresAt time.Time) error
+	IsTokenRevoked(tokenId uuid.UUID) (bool, error)
+	// RevokeUserTokens signs the user out everywhere by starting a new token
+	// generation, tokens and sessions of earlier ones are rejected.
+	RevokeUserTokens(userId uuid.UUID) error
+	GetUserTokenGeneration(userId uuid.UUID) (int64, error)
+	GetTwoFactor(userId uuid.UUID) (usermodel.TwoFactor, bool, error)
+	SaveTwoFactor(twoFactor usermodel.TwoFactor) error
+	// UseTwoFactorStep records step as the last used TOTP step. It is false
+	// if the step or a later one was already used.
+	UseTwoFactorStep(userId uuid.UUID, step int64) (bool, error)
+	// UseRecoveryCode removes the recovery code with the hash. It is false if
+	// the user has no such code.
+	UseRecoveryCode(userId uuid.UUID, codeHash string) (bool, error)
+	DeleteTwoFactor(userId uuid.UUID) error
+	GetLoginAttempts(key string) (usermodel.LoginAttempts, error)
+	// AddLoginFailure counts the failure, starting over if the previous one
+	// of the key was before resetBefore.
+	AddLoginFailure(key string, failedAt, resetBefore time.Time) error
+	ResetLoginAttempts(key string) error
+	// DeleteLoginAttempts forgets the keys whose last failure was before
+	// resetBefore.
+	DeleteLoginAttempts(resetBefore time.Time) error
+	AddAPIKey(apiKey usermodel.APIKey) error
+	GetAPIKeyByHash(keyHash []byte) (usermodel.APIKey, bool, error)
+	GetUserAPIKeys(userId uuid.UUID) ([]usermodel.APIKey, error)
+	DeleteAPIKey(userId, keyId uuid.UUID) (bool, error)
+	UpdateAPIKeyLastUsed(keyId uuid.UUID, usedAt time.Time) error
+	AddSession(session usermodel.Session) error
+	GetSession(sessionId uuid.UUID) (usermodel.Session, bool, error)
+	GetUserSessions(userId uuid.UUID) ([]usermodel.Session, error)
+	RevokeSession(userId, sessionId uuid.UUID, revokedAt time.Time) (bool, error)
+	UpdateSessionLastSeen(sessionId uuid.UUID, seenAt time.Time) error
+	AddAuditEvent(event usermodel.AuditEvent) error
+	GetAuditEvents(filter usermodel.AuditFilter) ([]usermodel.AuditEvent, error)
+	AddOutboxMessage(message usermodel.OutboxMessage) error
+	GetOutboxMessages(limit int) ([]usermodel.OutboxMessage, error)
+	DeleteOutboxMessages(ids []int64) error
+	GetPhoneVerification(userId uuid.UUID) (usermodel.PhoneVerification, bool, error)
+	SavePhoneVerification(verification usermodel.PhoneVerification) error
+	DeletePhoneVerification(userId uuid.UUID) error
+	AddOrganisation(organisation usermodel.Organisation) error
+	GetOrganisation(organisationId uuid.UUID) (usermodel.Organisation, bool, error)
+	UpdateOrganisation(organisation usermodel.Organisation) error
+	DeleteOrganisation(organisationId uuid.UUID) error
+	GetMembership(userId uuid.UUID) (usermodel.OrganisationMember, bool, error)
+	GetOrganisationMembers(organisationId uuid.UUID) ([]usermodel.OrganisationMember, error)
+	SaveMembership(member usermodel.OrganisationMember) error
+	DeleteMembership(userId uuid.UUID) error
+	AddInvitation(invitation usermodel.OrganisationInvitation) error
+	GetInvitationByHash(tokenHash []byte) (usermodel.OrganisationInvitation, bool, error)
+	GetOrganisationInvitations(organisationId uuid.UUID) ([]usermodel.OrganisationInvitation, error)
+	DeleteInvitation(organisationId, invitationId uuid.UUID) (bool, error)
 }
 
 type StorageManager struct {
-	storage Storage
+	storage             Storage
+	hasher              passwordhasher.Hasher
+	notifier            *authmailer.Notifier
+	twoFactorPolicy     twofactor.Policy
+	loginThrottlePolicy loginthrottle.Policy
+	smsNotifier         *authsms.Notifier
+	introspections      *introspectionCache
 }
 
-func (sm *StorageManager) CreateUser(login, password, email string, isCompany bool) (usermodel.User, error) {
-	if user, err := sm.storage.GetUserByLogin(login); err != nil || user.Login != "" {
+func (sm *StorageManager) CreateUser(login, password, email string, isCompany bool, client usermodel.ClientInfo) (usermodel.User, error) {
+	user, err := sm.createUser(login, password, email, isCompany)
+	var validationErr *usermodel.ValidationError
+	if err != nil && !errors.As(err, &validationErr) && !errors.Is(err, usermodel.ErrUserExists) {
 		return usermodel.User{}, err
 	}
-	user := usermodel.NewUser(login, email, password, isCompany)
-	if user == nil {
-		return usermodel.User{}, nil
+	sm.audit(usermodel.AuditEvent{
+		ActorID: user.ID,
+		UserID:  user.ID,
+		Login:   login,
+		Action:  usermodel.AuditRegister,
+		IP:      client.IP,
+		Outcome: auditOutcome(err == nil),
+	})
+	return user, err
+}
+
+// userExists reports whether the login is taken or the email belongs to
+// another account in any letter case, as emails also identify logins.
+func userExists(storage Storage, login, email string) (bool, error) {
+	existing, err := storage.GetUserByLogin(login)
+	if err != nil || existing.Login != "" {
+		return existing.Login != "", err
+	}
+	existing, err = storage.GetUserByEmail(email)
+	return existing.Login != "", err
+}
+
+// createUser checks for conflicts before hashing the password, which is
+// slow, and again in the transaction adding the user. Registrations racing
+// past both checks are stopped by the unique constraints of the storage.
+// Company accounts start out as the owner of a new organisation.
+func (sm *StorageManager) createUser(login, password, email string, isCompany bool) (usermodel.User, error) {
+	user, err := usermodel.NewUser(login, email, password, isCompany)
+	if err != nil {
+		return usermodel.User{}, err
+	}
+	if exists, err := userExists(sm.storage, login, email); err != nil || exists {
+		if err == nil {
+			err = usermodel.ErrUserExists
+		}
+		return usermodel.User{}, err
+	}
+	hashedPassword, err := sm.hasher.Hash(password)
+	if err != nil {
+		return usermodel.User{}, err
 	}
-	hashedPassword := userkeys.GetPasswordHash(login, password)
-	userId, err := sm.storage.AddUser(*user, login, hashedPassword)
+	err = sm.storage.Transaction(func(tx Storage) error {
+		if exists, err := userExists(tx, login, email); err != nil || exists {
+			if err == nil {
+				err = usermodel.ErrUserExists
+			}
+			return err
+		}
+		userId, err := tx.AddUser(*user, login, []byte(hashedPassword))
+		user.ID = userId
+		if err != nil || !isCompany {
+			return err
+		}
+		user.OrganisationID, err = addOrganisation(tx, userId, login)
+		return err
+	})
 	if err != nil {
 		return usermodel.User{}, err
 	}
-	user.ID = userId
+	sm.sendEmailVerification(*user)
 	return *user, nil
 }
 
-func (sm *StorageManager) GetJWTByCredentials(login, password string) (string, error) {
+// sendEmailVerification failures are only logged: the account is already
+// created and the user can request another email with ResendVerification.
+// Verification links stay valid when the user is signed out, so they carry no
+// token generation.
+func (sm *StorageManager) sendEmailVerification(user usermodel.User) {
+	token := userkeys.NewActionToken(userkeys.EmailVerificationPurpose, user.ID, user.Email, 0, userkeys.EmailVerificationTTL)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	if err := sm.notifier.SendEmailVerification(ctx, user.Email, token); err != nil {
+		log.Printf("Failed to send verification email to %s: %v", user.Login, err)
+	}
+}
+
+func (sm *StorageManager) VerifyEmail(token string) (usermodel.User, error) {
+	claims, ok := userkeys.ParseActionToken(token, userkeys.EmailVerificationPurpose)
+	if !ok {
+		return usermodel.User{}, nil
+	}
+	revoked, err := sm.storage.IsTokenRevoked(claims.TokenID)
+	if err != nil || revoked {
+		return usermodel.User{}, err
+	}
+	user, err := sm.storage.GetUserById(claims.UserID)
+	if err != nil || user.Login == "" || user.Email != claims.Email {
+		return usermodel.User{}, err
+	}
+	if err := sm.storage.RevokeToken(claims.TokenID, claims.ExpiresAt); err != nil {
+		return usermodel.User{}, err
+	}
+	user.EmailVerified = true
+	user.UpdateDate = time.Now()
+	return user, sm.storage.UpdateUser(user)
+}
+
+func (sm *StorageManager) ResendVerification(jwt string) (usermodel.User, error) {
+	user, err := sm.GetUserByJWT(jwt)
+	if err != nil || user.Login == "" || user.EmailVerified {
+		return user, err
+	}
+	sm.sendEmailVerification(user)
+	return user, nil
+}
+
+// resolveLogin returns the login of the account identified by a login or,
+// failing that, by an email address in any letter case. Unknown identifiers
+// are returned as is.
+func (sm *StorageManager) resolveLogin(identifier string) (string, error) {
+	user, err := sm.storage.GetUserByLogin(identifier)
+	if err != nil || user.Login != "" || !strings.Contains(identifier, "@") {
+		return identifier, err
+	}
+	user, err = sm.storage.GetUserByEmail(identifier)
+	if err != nil || user.Login == "" {
+		return identifier, err
+	}
+	return user.Login, nil
+}
+
+// GetJWTByCredentials accepts the login or the email of the account as
+// identifier. Lockouts and the audit log refer to the resolved login.
+func (sm *StorageManager) GetJWTByCredentials(identifier, password string, client usermodel.ClientInfo) (usermodel.TokenPair, error) {
+	login, err := sm.resolveLogin(identifier)
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	now := time.Now()
+	throttleKeys := sm.loginThrottleKeys(login, client.IP)
+	if err := sm.checkLoginLockout(throttleKeys, now); err != nil {
+		return usermodel.TokenPair{}, err
+	}
 	expectedPassword, ok, err := sm.storage.GetUserPasswordByLogin(login)
-	if err != nil || !ok {
-		return "", err
+	if err != nil {
+		return usermodel.TokenPair{}, err
 	}
-	actualPassword := userkeys.GetPasswordHash(login, password)
-	if actualPassword != expectedPassword {
-		return "", nil
+	if ok {
+		ok, err = sm.verifyPassword(login, password, expectedPassword)
+		if err != nil {
+			return usermodel.TokenPair{}, err
+		}
+	}
+	if !ok {
+		sm.auditLoginFailure(usermodel.AuditLogin, login, client)
+		return usermodel.TokenPair{}, sm.recordLoginFailure(throttleKeys, now)
+	}
+	if err := sm.storage.ResetLoginAttempts(loginthrottle.LoginKey(login)); err != nil {
+		return usermodel.TokenPair{}, err
 	}
 	user, err := sm.storage.GetUserByLogin(login)
 	if err != nil {
-		return "", err
+		return usermodel.TokenPair{}, err
+	}
+	if user.Suspended {
+		sm.auditLoginFailure(usermodel.AuditLogin, login, client)
+		return usermodel.TokenPair{}, usermodel.ErrAccountSuspended
 	}
-	return userkeys.GenJWT(user.ID), nil
+	sm.audit(usermodel.AuditEvent{
+		ActorID: user.ID,
+		UserID:  user.ID,
+		Login:   user.Login,
+		Action:  usermodel.AuditLogin,
+		IP:      client.IP,
+		Outcome: usermodel.AuditSuccess,
+	})
+	twoFactor, ok, err := sm.storage.GetTwoFactor(user.ID)
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	if ok && twoFactor.Enabled {
+		generation, err := sm.storage.GetUserTokenGeneration(user.ID)
+		if err != nil {
+			return usermodel.TokenPair{}, err
+		}
+		challenge := userkeys.NewActionToken(userkeys.TwoFactorPurpose, user.ID, user.Email, generation, userkeys.TwoFactorTTL)
+		return usermodel.TokenPair{TwoFactorChallenge: challenge}, nil
+	}
+	sessionId, err := sm.newSession(sm.storage, user, client)
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	return sm.issueTokens(user, sessionId, client)
 }
 
-func (sm *StorageManager) GetUserByJWT(jwt string) (usermodel.User, error) {
-	userId, ok := userkeys.GetUserIdByJWT(jwt)
+// auditLoginFailure records a failed login under the user owning the login,
+// if there is one.
+func (sm *StorageManager) auditLoginFailure(action usermodel.AuditAction, login string, client usermodel.ClientInfo) {
+	user, err := sm.storage.GetUserByLogin(login)
+	if err != nil {
+		log.Printf("Failed to look up %s for the audit log: %v", login, err)
+	}
+	sm.audit(usermodel.AuditEvent{
+		ActorID: user.ID,
+		UserID:  user.ID,
+		Login:   login,
+		Action:  action,
+		IP:      client.IP,
+		Outcome: usermodel.AuditFailure,
+	})
+}
+
+func (sm *StorageManager) issueTokens(user usermodel.User, sessionId uuid.UUID, client usermodel.ClientInfo) (usermodel.TokenPair, error) {
+	tokens, err := sm.newTokenPair(sm.storage, user, sessionId)
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	sm.auditTokenIssued(user, client)
+	return tokens, nil
+}
+
+// newTokenPair stores the refresh token through store. It refuses suspended
+// users, whichever way they got here.
+func (sm *StorageManager) newTokenPair(store Storage, user usermodel.User, sessionId uuid.UUID) (usermodel.TokenPair, error) {
+	if user.Suspended {
+		return usermodel.TokenPair{}, usermodel.ErrAccountSuspended
+	}
+	setupRequired, err := sm.twoFactorSetupRequired(user)
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	organisationId, err := sm.organisationId(user.ID)
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	generation, err := store.GetUserTokenGeneration(user.ID)
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	accessToken, claims := userkeys.NewAccessToken(userkeys.TokenClaims{
+		UserID:                 user.ID,
+		SessionID:              sessionId,
+		Generation:             generation,
+		IsCompany:              user.IsCompany,
+		Role:                   string(user.Role),
+		EmailVerified:          user.EmailVerified,
+		TwoFactorSetupRequired: setupRequired,
+		OrganisationID:         organisationId,
+	})
+	refreshToken, refreshHash := userkeys.GenRefreshToken()
+	curTime := time.Now()
+	err = store.AddRefreshToken(usermodel.RefreshToken{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		SessionID:    sessionId,
+		TokenHash:    refreshHash,
+		ExpiresAt:    curTime.Add(userkeys.RefreshTokenTTL),
+		CreationDate: curTime,
+	})
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	return usermodel.TokenPair{
+		AccessToken:            accessToken,
+		AccessExpiresAt:        claims.ExpiresAt,
+		RefreshToken:           refreshToken,
+		RefreshExpiresAt:       curTime.Add(userkeys.RefreshTokenTTL),
+		TwoFactorSetupRequired: setupRequired,
+	}, nil
+}
+
+func (sm *StorageManager) auditTokenIssued(user usermodel.User, client usermodel.ClientInfo) {
+	sm.audit(usermodel.AuditEvent{
+		ActorID: user.ID,
+		UserID:  user.ID,
+		Login:   user.Login,
+		Action:  usermodel.AuditTokenIssued,
+		IP:      client.IP,
+		Outcome: usermodel.AuditSuccess,
+	})
+}
+
+// RefreshJWT rotates the refresh token. Presenting a token that has already
+// been rotated is treated as theft and revokes every refresh token of the user.
+func (sm *StorageManager) RefreshJWT(refreshToken string, client usermodel.ClientInfo) (usermodel.TokenPair, error) {
+	token, ok, err := sm.storage.GetRefreshToken(userkeys.HashRefreshToken(refreshToken))
+	if err != nil || !ok {
+		return usermodel.TokenPair{}, err
+	}
+	if !token.RevokedAt.IsZero() {
+		return usermodel.TokenPair{}, sm.storage.RevokeUserRefreshTokens(token.UserID)
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return usermodel.TokenPair{}, nil
+	}
+	user, err := sm.storage.GetUserById(token.UserID)
+	if err != nil || user.Login == "" {
+		return usermodel.TokenPair{}, err
+	}
+	var session usermodel.Session
+	if token.SessionID != uuid.Nil {
+		if session, ok, err = sm.getActiveSession(user.ID, token.SessionID); err != nil || !ok {
+			return usermodel.TokenPair{}, err
+		}
+	}
+	// The token is revoked and replaced in one transaction. Of concurrent
+	// refreshes with the same token only one revokes it, the others count as
+	// reuse.
+	var tokens usermodel.TokenPair
+	reused := false
+	err = sm.storage.Transaction(func(tx Storage) error {
+		rotated, err := tx.RevokeRefreshToken(token.ID)
+		if err != nil || !rotated {
+			reused = !rotated
+			return err
+		}
+		sessionId := session.ID
+		if sessionId == uuid.Nil {
+			if sessionId, err = sm.newSession(tx, user, client); err != nil {
+				return err
+			}
+		}
+		tokens, err = sm.newTokenPair(tx, user, sessionId)
+		return err
+	})
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	if reused {
+		return usermodel.TokenPair{}, sm.storage.RevokeUserRefreshTokens(token.UserID)
+	}
+	if session.ID != uuid.Nil {
+		if err := sm.touchSession(session); err != nil {
+			log.Printf("Failed to update session %s: %v", session.ID, err)
+		}
+	}
+	sm.auditTokenIssued(user, client)
+	return tokens, nil
+}
+
+func (sm *StorageManager) Logout(jwt, refreshToken string) (bool, error) {
+	claims, ok, err := sm.parseJWT(jwt)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := sm.storage.RevokeToken(claims.TokenID, claims.ExpiresAt); err != nil {
+		return false, err
+	}
+	if claims.SessionID != uuid.Nil {
+		if _, err := sm.storage.RevokeSession(claims.UserID, claims.SessionID, time.Now()); err != nil {
+			return false, err
+		}
+	}
+	if refreshToken == "" {
+		return true, nil
+	}
+	token, ok, err := sm.storage.GetRefreshToken(userkeys.HashRefreshToken(refreshToken))
+	if err != nil || !ok || token.UserID != claims.UserID {
+		return true, err
+	}
+	_, err = sm.storage.RevokeRefreshToken(token.ID)
+	return true, err
+}
+
+// parseJWT validates the token signature and expiry and rejects tokens that
+// were revoked by logout or belong to a revoked session.
+func (sm *StorageManager) parseJWT(jwt string) (userkeys.TokenClaims, bool, error) {
+	claims, ok := userkeys.ParseJWT(jwt)
 	if !ok {
-		return usermodel.User{}, nil
+		return userkeys.TokenClaims{}, false, nil
+	}
+	revoked, err := sm.isTokenRevoked(claims.UserID, claims.TokenID, claims.Generation)
+	if err != nil || revoked {
+		return userkeys.TokenClaims{}, false, err
+	}
+	if claims.SessionID != uuid.Nil {
+		if _, ok, err := sm.getActiveSession(claims.UserID, claims.SessionID); err != nil || !ok {
+			return userkeys.TokenClaims{}, false, err
+		}
 	}
-	return sm.storage.GetUserById(userId)
+	return claims, true, nil
 }
 
-func (sm *StorageManager) UpdateUserByJWT(jwt string, userInfo usermodel.User) (usermodel.User, error) {
-	user, err := sm.GetUserByJWT(jwt)
+// isTokenRevoked reports whether the token was revoked on its own or was
+// issued in a token generation before the user was signed out everywhere.
+func (sm *StorageManager) isTokenRevoked(userId, tokenId uuid.UUID, generation int64) (bool, error) {
+	revoked, err := sm.storage.IsTokenRevoked(tokenId)
+	if err != nil || revoked {
+		return revoked, err
+	}
+	current, err := sm.storage.GetUserTokenGeneration(userId)
+	if err != nil {
+		return false, err
+	}
+	return generation < current, nil
+}
+
+func (sm *StorageManager) setPassword(user usermodel.User, password string) error {
+	if err := usermodel.ValidatePassword("new_password", password); err != nil {
+		return err
+	}
+	hashedPassword, err := sm.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	if err := sm.storage.UpdateUserPassword(user.Login, []byte(hashedPassword)); err != nil {
+		return err
+	}
+	if err := sm.storage.RevokeUserRefreshTokens(user.ID); err != nil {
+		return err
+	}
+	return sm.storage.RevokeUserTokens(user.ID)
+}
+
+func (sm *StorageManager) ChangePassword(jwt, currentPassword, newPassword string) (usermodel.TokenPair, error) {
+	claims, ok, err := sm.parseJWT(jwt)
+	if err != nil || !ok {
+		return usermodel.TokenPair{}, err
+	}
+	user, err := sm.storage.GetUserById(claims.UserID)
+	if err != nil || user.Login == "" {
+		return usermodel.TokenPair{}, err
+	}
+	if err := sm.checkCurrentPassword(user, currentPassword); err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	if err := sm.setPassword(user, newPassword); err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	if err := sm.storage.RevokeToken(claims.TokenID, claims.ExpiresAt); err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	client, err := sm.sessionClient(claims.SessionID)
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	sessionId, err := sm.newSession(sm.storage, user, client)
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	return sm.issueTokens(user, sessionId, client)
+}
+
+func (sm *StorageManager) checkCurrentPassword(user usermodel.User, password string) error {
+	hash, ok, err := sm.storage.GetUserPasswordByLogin(user.Login)
+	if err != nil {
+		return err
+	}
+	if ok {
+		ok, err = sm.verifyPassword(user.Login, password, hash)
+		if err != nil {
+			return err
+		}
+	}
+	if !ok {
+		return usermodel.ErrWrongPassword
+	}
+	return nil
+}
+
+// DeleteAccount removes the user together with credentials, refresh tokens and
+// 2FA settings. An organisation is deleted with its last member, whose ID is
+// returned then. The account_deleted and organisation_deleted events other
+// services clean up on are written to the outbox in the same transaction.
+func (sm *StorageManager) DeleteAccount(jwt, password string) (bool, uuid.UUID, error) {
+	claims, ok, err := sm.parseJWT(jwt)
+	if err != nil || !ok {
+		return false, uuid.Nil, err
+	}
+	user, err := sm.storage.GetUserById(claims.UserID)
+	if err != nil || user.Login == "" {
+		return false, uuid.Nil, err
+	}
+	if err := sm.checkCurrentPassword(user, password); err != nil {
+		return false, uuid.Nil, err
+	}
+	var organisationId uuid.UUID
+	err = sm.storage.Transaction(func(tx Storage) error {
+		var err error
+		if organisationId, err = deleteMembership(tx, user.ID); err != nil {
+			return err
+		}
+		if err := tx.DeleteUser(user.ID); err != nil {
+			return err
+		}
+		if organisationId != uuid.Nil {
+			if err := addStatsEvent(tx, organisationDeletedEvent, user.ID, organisationId); err != nil {
+				return err
+			}
+		}
+		return addStatsEvent(tx, accountDeletedEvent, user.ID, user.ID)
+	})
+	if err != nil {
+		return false, uuid.Nil, err
+	}
+	if err := sm.storage.ResetLoginAttempts(loginthrottle.LoginKey(user.Login)); err != nil {
+		return false, uuid.Nil, err
+	}
+	return true, organisationId, sm.storage.RevokeToken(claims.TokenID, claims.ExpiresAt)
+}
+
+// RequestPasswordReset looks the account up and sends the email in the
+// background, so that neither the result nor the response time tells which
+// accounts exist. Failures are only logged.
+func (sm *StorageManager) RequestPasswordReset(login string) {
+	go func() {
+		if err := sm.sendPasswordReset(login); err != nil {
+			log.Printf("Failed to send password reset email to %s: %v", login, err)
+		}
+	}()
+}
+
+func (sm *StorageManager) sendPasswordReset(login string) error {
+	user, err := sm.storage.GetUserByLogin(login)
 	if err != nil || user.Login == "" {
+		return err
+	}
+	generation, err := sm.storage.GetUserTokenGeneration(user.ID)
+	if err != nil {
+		return err
+	}
+	token := userkeys.NewActionToken(userkeys.PasswordResetPurpose, user.ID, user.Email, generation, userkeys.PasswordResetTTL)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	return sm.notifier.SendPasswordReset(ctx, user.Email, token)
+}
+
+func (sm *StorageManager) ResetPassword(token, newPassword string) (bool, error) {
+	claims, ok := userkeys.ParseActionToken(token, userkeys.PasswordResetPurpose)
+	if !ok {
+		return false, nil
+	}
+	revoked, err := sm.isTokenRevoked(claims.UserID, claims.TokenID, claims.Generation)
+	if err != nil || revoked {
+		return false, err
+	}
+	user, err := sm.storage.GetUserById(claims.UserID)
+	if err != nil || user.Login == "" {
+		return false, err
+	}
+	if err := sm.setPassword(user, newPassword); err != nil {
+		return false, err
+	}
+	return true, sm.storage.RevokeToken(claims.TokenID, claims.ExpiresAt)
+}
+
+func isLegacyPasswordHash(hash []byte) bool {
+	return len(hash) == userkeys.Md5Len
+}
+
+// verifyPassword checks the password against the stored hash and upgrades
+// legacy MD5 hashes and hashes with outdated parameters on success.
+func (sm *StorageManager) verifyPassword(login, password string, hash []byte) (bool, error) {
+	var ok bool
+	if isLegacyPasswordHash(hash) {
+		legacyHash := userkeys.GetPasswordHash(login, password)
+		ok = subtle.ConstantTimeCompare(legacyHash[:], hash) == 1
+	} else {
+		var err error
+		ok, err = sm.hasher.Verify(password, string(hash))
+		if err != nil {
+			return false, err
+		}
+	}
+	if !ok {
+		return false, nil
+	}
+	if isLegacyPasswordHash(hash) || sm.hasher.NeedsRehash(string(hash)) {
+		newHash, err := sm.hasher.Hash(password)
+		if err == nil {
+			err = sm.storage.UpdateUserPassword(login, []byte(newHash))
+		}
+		if err != nil {
+			log.Printf("Failed to rehash password for %s: %v", login, err)
+		}
+	}
+	return true, nil
+}
+
+// GetUserByJWT treats the tokens of suspended users as invalid. Suspending
+// signs the user out by bumping its token generation, which parseJWT compares
+// with the gen claim; checking the flag as well covers tokens issued by a
+// login that raced with the suspension.
+func (sm *StorageManager) GetUserByJWT(jwt string) (usermodel.User, error) {
+	claims, ok, err := sm.parseJWT(jwt)
+	if err != nil || !ok {
 		return usermodel.User{}, err
 	}
-	user = usermodel.MergeUserInfo(user, userInfo)
-	return user, sm.storage.UpdateUser(user)
+	user, err := sm.storage.GetUserById(claims.UserID)
+	if err != nil || user.Login == "" || user.Suspended {
+		return usermodel.User{}, err
+	}
+	twoFactor, _, err := sm.storage.GetTwoFactor(user.ID)
+	if err != nil {
+		return usermodel.User{}, err
+	}
+	user.TwoFactorEnabled = twoFactor.Enabled
+	user.OrganisationID, err = sm.organisationId(user.ID)
+	return user, err
+}
+
+func (sm *StorageManager) UpdateUserByJWT(jwt string, userInfo usermodel.User, client usermodel.ClientInfo) (usermodel.User, error) {
+	oldUser, err := sm.GetUserByJWT(jwt)
+	if err != nil || oldUser.Login == "" {
+		return usermodel.User{}, err
+	}
+	user, err := usermodel.MergeUserInfo(oldUser, userInfo)
+	if err != nil {
+		return usermodel.User{}, err
+	}
+	err = sm.storage.UpdateUser(user)
+	sm.audit(usermodel.AuditEvent{
+		ActorID: user.ID,
+		UserID:  user.ID,
+		Login:   user.Login,
+		Action:  usermodel.AuditProfileUpdate,
+		IP:      client.IP,
+		Outcome: auditOutcome(err == nil),
+		Changes: usermodel.DiffProfiles(oldUser, user),
+	})
+	if err != nil {
+		return usermodel.User{}, err
+	}
+	if user.Email != oldUser.Email {
+		sm.sendEmailVerification(user)
+	}
+	return user, nil
 }
 
 func (sm *StorageManager) GetUserById(userId uuid.UUID) (usermodel.User, error) {
@@ -77,8 +739,76 @@ func (sm *StorageManager) GetUserById(userId uuid.UUID) (usermodel.User, error)
 	return usermodel.FetchUserPublicInfo(user), nil
 }
 
-func NewStorageManager(storage Storage) usermodel.StorageManager {
+// SetUserRole lets platform admins change the role of another user. The user
+// is signed out so that the next login carries the new role.
+func (sm *StorageManager) SetUserRole(jwt string, userId uuid.UUID, role usermodel.Role) (usermodel.User, error) {
+	caller, err := sm.GetUserByJWT(jwt)
+	if err != nil || caller.Login == "" {
+		return usermodel.User{}, err
+	}
+	if caller.Role != usermodel.RolePlatformAdmin {
+		return usermodel.User{}, usermodel.ErrForbidden
+	}
+	user, err := sm.storage.GetUserById(userId)
+	if err != nil {
+		return usermodel.User{}, err
+	}
+	return sm.changeRole(caller.ID, user, role)
+}
+
+// SetUserRoleByLogin is the set-role command operators use to appoint the
+// first platform admin. It has no caller, the audit event has no actor.
+func (sm *StorageManager) SetUserRoleByLogin(login string, role usermodel.Role) (usermodel.User, error) {
+	user, err := sm.storage.GetUserByLogin(login)
+	if err != nil {
+		return usermodel.User{}, err
+	}
+	return sm.changeRole(uuid.Nil, user, role)
+}
+
+func (sm *StorageManager) changeRole(actorId uuid.UUID, user usermodel.User, role usermodel.Role) (usermodel.User, error) {
+	if !usermodel.IsValidRole(role) {
+		return usermodel.User{}, usermodel.ErrInvalidRole
+	}
+	if user.Login == "" {
+		return usermodel.User{}, usermodel.ErrUserNotFound
+	}
+	if user.Role == role {
+		return user, nil
+	}
+	oldUser := user
+	user.Role = role
+	user.UpdateDate = time.Now()
+	if err := sm.storage.UpdateUser(user); err != nil {
+		return usermodel.User{}, err
+	}
+	sm.audit(usermodel.AuditEvent{
+		ActorID: actorId,
+		UserID:  user.ID,
+		Login:   user.Login,
+		Action:  usermodel.AuditRoleChange,
+		Outcome: usermodel.AuditSuccess,
+		Changes: usermodel.DiffProfiles(oldUser, user),
+	})
+	if err := sm.storage.RevokeUserRefreshTokens(user.ID); err != nil {
+		return usermodel.User{}, err
+	}
+	return user, sm.storage.RevokeUserTokens(user.ID)
+}
+
+// NewStorageManager leaves phone number verification disabled if smsNotifier
+// is nil.
+func NewStorageManager(storage Storage, hasher passwordhasher.Hasher, notifier *authmailer.Notifier,
+	twoFactorPolicy twofactor.Policy, loginThrottlePolicy loginthrottle.Policy, smsNotifier *authsms.Notifier,
+) usermodel.StorageManager {
+	introspections := newIntrospectionCache()
 	return &StorageManager{
-		storage: storage,
+		storage:             &introspectionStorage{Storage: storage, cache: introspections},
+		hasher:              hasher,
+		notifier:            notifier,
+		twoFactorPolicy:     twoFactorPolicy,
+		loginThrottlePolicy: loginThrottlePolicy,
+		smsNotifier:         smsNotifier,
+		introspections:      introspections,
 	}
 }
@@ -0,0 +1,236 @@
+package smimpl
+
+import (
+	loginthrottle "authservice/auth_storage/login_throttle"
+	twofactor "authservice/auth_storage/two_factor"
+	userkeys "authservice/auth_storage/user_keys"
+	usermodel "authservice/auth_storage/user_model"
+	"errors"
+	"time"
+)
+
+func (sm *StorageManager) twoFactorSetupRequired(user usermodel.User) (bool, error) {
+	if !user.IsCompany || !sm.twoFactorPolicy.RequiredForCompanies {
+		return false, nil
+	}
+	twoFactor, ok, err := sm.storage.GetTwoFactor(user.ID)
+	return !ok || !twoFactor.Enabled, err
+}
+
+// checkTwoFactorCode accepts a TOTP code or an unused recovery code and
+// consumes the step or code in the storage, so that neither can be used
+// twice, even by concurrent requests.
+func (sm *StorageManager) checkTwoFactorCode(twoFactor usermodel.TwoFactor, code string) (bool, error) {
+	if step, ok := twofactor.ValidateCode(twoFactor.Secret, code, twoFactor.LastUsedStep, time.Now()); ok {
+		return sm.storage.UseTwoFactorStep(twoFactor.UserID, step)
+	}
+	if !twoFactor.Enabled {
+		return false, nil
+	}
+	return sm.storage.UseRecoveryCode(twoFactor.UserID, twofactor.HashRecoveryCode(code))
+}
+
+// checkThrottledTwoFactorCode checks the code of a signed in user under the
+// lockout of LoginTwoFactor, so that a stolen access token cannot be used to
+// guess codes either. It returns ErrWrongTwoFactorCode for a wrong code.
+func (sm *StorageManager) checkThrottledTwoFactorCode(twoFactor usermodel.TwoFactor, code string, client usermodel.ClientInfo) error {
+	now := time.Now()
+	throttleKeys := sm.twoFactorThrottleKeys(twoFactor.UserID, client.IP)
+	if err := sm.checkLoginLockout(throttleKeys, now); err != nil {
+		return err
+	}
+	ok, err := sm.checkTwoFactorCode(twoFactor, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := sm.recordLoginFailure(throttleKeys, now); err != nil {
+			return err
+		}
+		return usermodel.ErrWrongTwoFactorCode
+	}
+	return sm.storage.ResetLoginAttempts(loginthrottle.TwoFactorKey(twoFactor.UserID.String()))
+}
+
+// auditTwoFactorChange records enabling or disabling 2FA, unless the code
+// was not even checked because of a lockout.
+func (sm *StorageManager) auditTwoFactorChange(user usermodel.User, action usermodel.AuditAction, client usermodel.ClientInfo, err error) {
+	var lockedErr *usermodel.LoginLockedError
+	if errors.As(err, &lockedErr) {
+		return
+	}
+	sm.audit(usermodel.AuditEvent{
+		ActorID: user.ID,
+		UserID:  user.ID,
+		Login:   user.Login,
+		Action:  action,
+		IP:      client.IP,
+		Outcome: auditOutcome(err == nil),
+	})
+}
+
+// LoginTwoFactor completes a login started by GetJWTByCredentials. A challenge
+// can be used once, a wrong code requires entering the password again.
+func (sm *StorageManager) LoginTwoFactor(challenge, code string, client usermodel.ClientInfo) (usermodel.TokenPair, error) {
+	claims, ok := userkeys.ParseActionToken(challenge, userkeys.TwoFactorPurpose)
+	if !ok {
+		return usermodel.TokenPair{}, nil
+	}
+	revoked, err := sm.isTokenRevoked(claims.UserID, claims.TokenID, claims.Generation)
+	if err != nil || revoked {
+		return usermodel.TokenPair{}, err
+	}
+	now := time.Now()
+	throttleKeys := sm.twoFactorThrottleKeys(claims.UserID, client.IP)
+	if err := sm.checkLoginLockout(throttleKeys, now); err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	if err := sm.storage.RevokeToken(claims.TokenID, claims.ExpiresAt); err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	user, err := sm.storage.GetUserById(claims.UserID)
+	if err != nil || user.Login == "" {
+		return usermodel.TokenPair{}, err
+	}
+	twoFactor, ok, err := sm.storage.GetTwoFactor(user.ID)
+	if err != nil || !ok || !twoFactor.Enabled {
+		return usermodel.TokenPair{}, err
+	}
+	ok, err = sm.checkTwoFactorCode(twoFactor, code)
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	sm.audit(usermodel.AuditEvent{
+		ActorID: user.ID,
+		UserID:  user.ID,
+		Login:   user.Login,
+		Action:  usermodel.AuditTwoFactorLogin,
+		IP:      client.IP,
+		Outcome: auditOutcome(ok),
+	})
+	if !ok {
+		return usermodel.TokenPair{}, sm.recordLoginFailure(throttleKeys, now)
+	}
+	if err := sm.storage.ResetLoginAttempts(loginthrottle.TwoFactorKey(user.ID.String())); err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	sessionId, err := sm.newSession(sm.storage, user, client)
+	if err != nil {
+		return usermodel.TokenPair{}, err
+	}
+	return sm.issueTokens(user, sessionId, client)
+}
+
+// EnrollTwoFactor stores a new secret that is not enforced until it is
+// confirmed with a code, replacing any unconfirmed one.
+func (sm *StorageManager) EnrollTwoFactor(jwt string) (usermodel.TwoFactorEnrollment, error) {
+	user, err := sm.GetUserByJWT(jwt)
+	if err != nil || user.Login == "" {
+		return usermodel.TwoFactorEnrollment{}, err
+	}
+	if !user.IsCompany {
+		return usermodel.TwoFactorEnrollment{}, usermodel.ErrTwoFactorUnavailable
+	}
+	if user.TwoFactorEnabled {
+		return usermodel.TwoFactorEnrollment{}, usermodel.ErrTwoFactorAlreadyEnabled
+	}
+	secret, uri, err := twofactor.GenerateSecret(user.Login)
+	if err != nil {
+		return usermodel.TwoFactorEnrollment{}, err
+	}
+	err = sm.storage.SaveTwoFactor(usermodel.TwoFactor{
+		UserID:       user.ID,
+		Secret:       secret,
+		CreationDate: time.Now(),
+	})
+	if err != nil {
+		return usermodel.TwoFactorEnrollment{}, err
+	}
+	return usermodel.TwoFactorEnrollment{Secret: secret, ProvisioningURI: uri}, nil
+}
+
+// ConfirmTwoFactor enables the enrolled secret and returns the recovery codes
+// together with new tokens that no longer ask for 2FA setup. Every other
+// session is signed out, as it was started without the second factor.
+func (sm *StorageManager) ConfirmTwoFactor(jwt, code string, client usermodel.ClientInfo) ([]string, usermodel.TokenPair, error) {
+	user, err := sm.GetUserByJWT(jwt)
+	if err != nil || user.Login == "" {
+		return nil, usermodel.TokenPair{}, err
+	}
+	twoFactor, ok, err := sm.storage.GetTwoFactor(user.ID)
+	if err != nil {
+		return nil, usermodel.TokenPair{}, err
+	}
+	if !ok {
+		return nil, usermodel.TokenPair{}, usermodel.ErrTwoFactorNotEnrolled
+	}
+	if twoFactor.Enabled {
+		return nil, usermodel.TokenPair{}, usermodel.ErrTwoFactorAlreadyEnabled
+	}
+	codes, err := sm.enableTwoFactor(twoFactor, code, client)
+	sm.auditTwoFactorChange(user, usermodel.AuditTwoFactorOn, client, err)
+	if err != nil {
+		return nil, usermodel.TokenPair{}, err
+	}
+	if err := sm.signOutEverywhere(user.ID); err != nil {
+		return nil, usermodel.TokenPair{}, err
+	}
+	claims, _ := userkeys.ParseJWT(jwt)
+	sessionClient, err := sm.sessionClient(claims.SessionID)
+	if err != nil {
+		return nil, usermodel.TokenPair{}, err
+	}
+	sessionId, err := sm.newSession(sm.storage, user, sessionClient)
+	if err != nil {
+		return nil, usermodel.TokenPair{}, err
+	}
+	tokens, err := sm.issueTokens(user, sessionId, sessionClient)
+	if err != nil {
+		return nil, usermodel.TokenPair{}, err
+	}
+	return codes, tokens, nil
+}
+
+// enableTwoFactor checks the code and enables the secret with new recovery
+// codes, which it returns.
+func (sm *StorageManager) enableTwoFactor(twoFactor usermodel.TwoFactor, code string, client usermodel.ClientInfo) ([]string, error) {
+	if err := sm.checkThrottledTwoFactorCode(twoFactor, code, client); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := twofactor.NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	twoFactor, _, err = sm.storage.GetTwoFactor(twoFactor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	twoFactor.Enabled = true
+	twoFactor.RecoveryCodes = hashes
+	return codes, sm.storage.SaveTwoFactor(twoFactor)
+}
+
+// DisableTwoFactor removes the secret after checking a code, under the same
+// lockout as LoginTwoFactor.
+func (sm *StorageManager) DisableTwoFactor(jwt, code string, client usermodel.ClientInfo) (bool, error) {
+	user, err := sm.GetUserByJWT(jwt)
+	if err != nil || user.Login == "" {
+		return false, err
+	}
+	if user.IsCompany && sm.twoFactorPolicy.RequiredForCompanies {
+		return false, usermodel.ErrTwoFactorRequired
+	}
+	twoFactor, ok, err := sm.storage.GetTwoFactor(user.ID)
+	if err != nil {
+		return false, err
+	}
+	if !ok || !twoFactor.Enabled {
+		return false, usermodel.ErrTwoFactorNotEnrolled
+	}
+	err = sm.checkThrottledTwoFactorCode(twoFactor, code, client)
+	if err == nil {
+		err = sm.storage.DeleteTwoFactor(user.ID)
+	}
+	sm.auditTwoFactorChange(user, usermodel.AuditTwoFactorOff, client, err)
+	return err == nil, err
+}
@@ -0,0 +1,148 @@
+package twofactor
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	Issuer            = "Loyalty Platform"
+	RecoveryCodeCount = 10
+	recoveryCodeBytes = 5
+	period            = 30
+	skew              = 1
+)
+
+var ErrInvalidSecretKey = errors.New("totp secret key must be 32 base64 encoded bytes")
+
+// Policy is set by the platform administrator at startup.
+type Policy struct {
+	RequiredForCompanies bool
+}
+
+func GenerateSecret(accountName string) (secret, provisioningURI string, err error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      Issuer,
+		AccountName: accountName,
+		Period:      period,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return key.Secret(), key.URL(), nil
+}
+
+// ValidateCode checks the code against the time steps around now and returns
+// the matched step. Steps up to lastStep are rejected, so a code cannot be
+// replayed.
+func ValidateCode(secret, code string, lastStep int64, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	current := now.Unix() / period
+	for step := current - skew; step <= current+skew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*period, 0), totp.ValidateOpts{
+			Period:    period,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, false
+		}
+		if expected == code {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// NewRecoveryCodes returns codes to show to the user once and the hashes to
+// store instead of them.
+func NewRecoveryCodes() (codes []string, hashes []string, err error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		raw := make([]byte, recoveryCodeBytes*2)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(raw[:recoveryCodeBytes]) + "-" +
+			encoding.EncodeToString(raw[recoveryCodeBytes:]))
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	hash := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hash[:])
+}
+
+// SecretCipher encrypts TOTP secrets at rest with AES-256-GCM.
+type SecretCipher struct {
+	aead cipher.AEAD
+}
+
+func NewSecretCipher(key []byte) (*SecretCipher, error) {
+	if len(key) != 32 {
+		return nil, ErrInvalidSecretKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretCipher{aead: aead}, nil
+}
+
+func LoadSecretCipher(path string) (*SecretCipher, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading totp secret key: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return nil, ErrInvalidSecretKey
+	}
+	return NewSecretCipher(key)
+}
+
+func (c *SecretCipher) Encrypt(secret string) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, []byte(secret), nil), nil
+}
+
+func (c *SecretCipher) Decrypt(data []byte) (string, error) {
+	if len(data) < c.aead.NonceSize() {
+		return "", errors.New("encrypted totp secret is too short")
+	}
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	secret, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
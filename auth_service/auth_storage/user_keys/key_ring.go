@@ -0,0 +1,226 @@
+package userkeys
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Keys in JwtKeyDir are stored as <kid>.private.pem and <kid>.public.pem.
+// The active signing key is named by the "active" file; without it the
+// lexicographically greatest kid that has a private key is used. Public keys
+// without a private counterpart are kept for verification only, so tokens
+// signed by a retired key stay valid until they expire.
+const (
+	JwtKeyDir         = "credentials/jwt_keys"
+	JwtActiveKeyFile  = "active"
+	privateKeySuffix  = ".private.pem"
+	publicKeySuffix   = ".public.pem"
+	KeyReloadInterval = time.Minute
+)
+
+type KeyRing struct {
+	activeKid string
+	private   *rsa.PrivateKey
+	public    map[string]*rsa.PublicKey
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+var (
+	ring     *KeyRing
+	ringMx   sync.RWMutex
+	ringOnce sync.Once
+)
+
+func getKeyRing() *KeyRing {
+	ringOnce.Do(func() {
+		if err := ReloadKeys(); err != nil {
+			log.Fatalf("Error loading jwt keys: %v", err)
+		}
+	})
+	ringMx.RLock()
+	defer ringMx.RUnlock()
+	return ring
+}
+
+// ReloadKeys rereads the key ring from disk. On failure the previously
+// loaded keys stay in use.
+func ReloadKeys() error {
+	newRing, err := LoadKeyRing(JwtKeyDir)
+	if errors.Is(err, os.ErrNotExist) {
+		newRing, err = loadLegacyKeyRing(JwtPrivateFile, JwtPublicFile)
+	}
+	if err != nil {
+		return err
+	}
+	ringMx.Lock()
+	defer ringMx.Unlock()
+	ring = newRing
+	return nil
+}
+
+func StartKeyReloader(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := ReloadKeys(); err != nil {
+				log.Printf("Error reloading jwt keys: %v", err)
+			}
+		}
+	}()
+}
+
+func LoadKeyRing(dir string) (*KeyRing, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	newRing := &KeyRing{public: make(map[string]*rsa.PublicKey)}
+	private := make(map[string]*rsa.PrivateKey)
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+		switch {
+		case strings.HasSuffix(name, privateKeySuffix):
+			key, err := readPrivateKey(path)
+			if err != nil {
+				return nil, err
+			}
+			kid := strings.TrimSuffix(name, privateKeySuffix)
+			private[kid] = key
+			newRing.public[kid] = &key.PublicKey
+		case strings.HasSuffix(name, publicKeySuffix):
+			key, err := readPublicKey(path)
+			if err != nil {
+				return nil, err
+			}
+			kid := strings.TrimSuffix(name, publicKeySuffix)
+			if _, ok := newRing.public[kid]; !ok {
+				newRing.public[kid] = key
+			}
+		}
+	}
+
+	active, err := os.ReadFile(filepath.Join(dir, JwtActiveKeyFile))
+	switch {
+	case err == nil:
+		newRing.activeKid = strings.TrimSpace(string(active))
+	case errors.Is(err, os.ErrNotExist):
+		kids := make([]string, 0, len(private))
+		for kid := range private {
+			kids = append(kids, kid)
+		}
+		sort.Strings(kids)
+		if len(kids) > 0 {
+			newRing.activeKid = kids[len(kids)-1]
+		}
+	default:
+		return nil, err
+	}
+	newRing.private = private[newRing.activeKid]
+	if newRing.private == nil {
+		return nil, fmt.Errorf("no private key for active kid %q in %s", newRing.activeKid, dir)
+	}
+	return newRing, nil
+}
+
+func loadLegacyKeyRing(privateFile, publicFile string) (*KeyRing, error) {
+	private, err := readPrivateKey(privateFile)
+	if err != nil {
+		return nil, err
+	}
+	public, err := readPublicKey(publicFile)
+	if err != nil {
+		return nil, err
+	}
+	kid := KeyThumbprint(public)
+	return &KeyRing{
+		activeKid: kid,
+		private:   private,
+		public:    map[string]*rsa.PublicKey{kid: public},
+	}, nil
+}
+
+func readPrivateKey(path string) (*rsa.PrivateKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading jwt private key: %w", err)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing jwt private key %s: %w", path, err)
+	}
+	return key, nil
+}
+
+func readPublicKey(path string) (*rsa.PublicKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading jwt public key: %w", err)
+	}
+	key, err := jwt.ParseRSAPublicKeyFromPEM(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing jwt public key %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// KeyThumbprint returns the RFC 7638 JWK thumbprint of the key.
+func KeyThumbprint(key *rsa.PublicKey) string {
+	jwk := newJWK("", key)
+	canonical := fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	hash := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func newJWK(kid string, key *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: jwt.SigningMethodRS256.Alg(),
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func (kr *KeyRing) verificationKey(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" {
+		kid = kr.activeKid
+	}
+	key, ok := kr.public[kid]
+	return key, ok
+}
+
+func GetJWKS() []JWK {
+	kr := getKeyRing()
+	kids := make([]string, 0, len(kr.public))
+	for kid := range kr.public {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	keys := make([]JWK, 0, len(kids))
+	for _, kid := range kids {
+		keys = append(keys, newJWK(kid, kr.public[kid]))
+	}
+	return keys
+}
@@ -2,12 +2,13 @@ package userkeys
 
 import (
 	"crypto/md5"
-	"crypto/rsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 	"log"
-	"os"
-	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt"
@@ -18,91 +19,281 @@ const (
 	Md5Len         = 16
 	JwtPublicFile  = "credentials/jwt_public_key.txt"
 	JwtPrivateFile = "credentials/jwt_private_key.txt"
-)
 
-var (
-	jwtPublic  *rsa.PublicKey
-	jwtPrivate *rsa.PrivateKey
-	once       sync.Once
+	AccessTokenTTL     = 15 * time.Minute
+	RefreshTokenTTL    = 30 * 24 * time.Hour
+	refreshTokenLength = 32
+	InvitationTTL      = 7 * 24 * time.Hour
+
+	EmailVerificationPurpose = "email_verification"
+	EmailVerificationTTL     = 24 * time.Hour
+	PasswordResetPurpose     = "password_reset"
+	PasswordResetTTL         = time.Hour
+	TwoFactorPurpose         = "two_factor"
+	TwoFactorTTL             = 5 * time.Minute
+
+	APIKeyPrefix = "lpk_"
 )
 
-func getJwtKeys() (*rsa.PublicKey, *rsa.PrivateKey) {
-	once.Do(func() {
-		var err error
-		private, err := os.ReadFile(JwtPrivateFile)
-		if err != nil {
-			log.Fatalf("Error reading jwt private key: %v", err)
-		}
-		public, err := os.ReadFile(JwtPublicFile)
-		if err != nil {
-			log.Fatalf("Error reading jwt public key: %v", err)
-		}
-		jwtPrivate, err = jwt.ParseRSAPrivateKeyFromPEM(private)
-		if err != nil {
-			log.Fatalf("Error parsing jwt private key: %v", err)
-		}
-		jwtPublic, err = jwt.ParseRSAPublicKeyFromPEM(public)
-		if err != nil {
-			log.Fatalf("Error parsing jwt public key: %v", err)
-		}
-	})
-	return jwtPublic, jwtPrivate
+// Generation is the token generation of the user when the token was issued,
+// signing the user out everywhere starts a new one.
+type TokenClaims struct {
+	UserID                 uuid.UUID
+	TokenID                uuid.UUID
+	SessionID              uuid.UUID
+	Generation             int64
+	IssuedAt               time.Time
+	ExpiresAt              time.Time
+	IsCompany              bool
+	Role                   string
+	EmailVerified          bool
+	TwoFactorSetupRequired bool
+	OrganisationID         uuid.UUID
 }
 
-func GetUserIdByJWT(tokenRaw string) (uuid.UUID, bool) {
+type ActionClaims struct {
+	UserID     uuid.UUID
+	TokenID    uuid.UUID
+	Email      string
+	Generation int64
+	IssuedAt   time.Time
+	ExpiresAt  time.Time
+}
+
+func parseSignedClaims(tokenRaw string) (jwt.MapClaims, bool) {
 	token, err := jwt.Parse(tokenRaw, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
 			return nil, errors.New("Wrong signing method")
 		}
-		jwtPublic, _ := getJwtKeys()
+		kid, _ := token.Header["kid"].(string)
+		jwtPublic, ok := getKeyRing().verificationKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("Unknown key id %q", kid)
+		}
 		return jwtPublic, nil
 	})
 	if err != nil {
-		log.Println("Error fetching jwt token: %v", err)
-		return uuid.Nil, false
+		log.Printf("Error fetching jwt token: %v", err)
+		return nil, false
 	}
 	if !token.Valid {
-		return uuid.Nil, false
+		return nil, false
 	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return uuid.Nil, false
+		return nil, false
 	}
 	exp, ok := claims["exp"].(float64)
 	if !ok {
-		return uuid.Nil, false
+		return nil, false
 	}
-	expirationTime := time.Unix(int64(exp), 0)
-	if time.Now().After(expirationTime) {
-		return uuid.Nil, false
+	if time.Now().After(time.Unix(int64(exp), 0)) {
+		return nil, false
 	}
-	userIdraw, ok := claims["user_id"].(string)
+	return claims, true
+}
+
+func parseUUIDClaim(claims jwt.MapClaims, name string) (uuid.UUID, bool) {
+	raw, ok := claims[name].(string)
 	if !ok {
 		return uuid.Nil, false
 	}
-	userId, err := uuid.Parse(userIdraw)
+	id, err := uuid.Parse(raw)
 	if err != nil {
 		return uuid.Nil, false
 	}
-	return userId, true
+	return id, true
 }
 
-func GenJWT(userId uuid.UUID) string {
-	claims := &jwt.MapClaims{
-		"user_id": userId.String(),
-		"iss":     "auth-service",
-		"exp":     time.Now().Add(time.Hour * 72).Unix(),
-		"iat":     time.Now().Unix(),
+func issuedAt(claims jwt.MapClaims) time.Time {
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return time.Time{}
 	}
-	_, jwtPrivate := getJwtKeys()
-	tokenRaw, err := jwt.NewWithClaims(jwt.SigningMethodRS256,
-		claims).SignedString(jwtPrivate)
+	return time.Unix(int64(iat), 0)
+}
+
+// generation reads the gen claim, tokens issued before it existed are of the
+// first generation.
+func generation(claims jwt.MapClaims) int64 {
+	gen, _ := claims["gen"].(float64)
+	return int64(gen)
+}
+
+func signClaims(claims jwt.MapClaims) string {
+	kr := getKeyRing()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kr.activeKid
+	tokenRaw, err := token.SignedString(kr.private)
 	if err != nil {
 		log.Fatalf("Error generating jwt token: %v", err)
 	}
 	return tokenRaw
 }
 
+func ParseJWT(tokenRaw string) (TokenClaims, bool) {
+	claims, ok := parseSignedClaims(tokenRaw)
+	if !ok {
+		return TokenClaims{}, false
+	}
+	if _, ok := claims["purpose"]; ok {
+		return TokenClaims{}, false
+	}
+	userId, ok := parseUUIDClaim(claims, "user_id")
+	if !ok {
+		return TokenClaims{}, false
+	}
+	tokenId, ok := parseUUIDClaim(claims, "jti")
+	if !ok {
+		return TokenClaims{}, false
+	}
+	result := TokenClaims{
+		UserID:    userId,
+		TokenID:   tokenId,
+		IssuedAt:  issuedAt(claims),
+		ExpiresAt: time.Unix(int64(claims["exp"].(float64)), 0),
+	}
+	result.Generation = generation(claims)
+	result.SessionID, _ = parseUUIDClaim(claims, "sid")
+	result.OrganisationID, _ = parseUUIDClaim(claims, "org_id")
+	result.IsCompany, _ = claims["is_company"].(bool)
+	result.Role, _ = claims["role"].(string)
+	result.EmailVerified, _ = claims["email_verified"].(bool)
+	result.TwoFactorSetupRequired, _ = claims["two_factor_setup_required"].(bool)
+	return result, true
+}
+
+func GetUserIdByJWT(tokenRaw string) (uuid.UUID, bool) {
+	claims, ok := ParseJWT(tokenRaw)
+	return claims.UserID, ok
+}
+
+// NewAccessToken signs an access token for the subject described by claims,
+// filling in a fresh token ID and the expiration time.
+func NewAccessToken(tokenClaims TokenClaims) (string, TokenClaims) {
+	now := time.Now()
+	tokenClaims.TokenID = uuid.New()
+	tokenClaims.IssuedAt = now.Truncate(time.Second)
+	tokenClaims.ExpiresAt = now.Add(AccessTokenTTL)
+	claims := jwt.MapClaims{
+		"user_id":                   tokenClaims.UserID.String(),
+		"is_company":                tokenClaims.IsCompany,
+		"role":                      tokenClaims.Role,
+		"email_verified":            tokenClaims.EmailVerified,
+		"two_factor_setup_required": tokenClaims.TwoFactorSetupRequired,
+		"jti":                       tokenClaims.TokenID.String(),
+		"gen":                       tokenClaims.Generation,
+		"iss":                       "auth-service",
+		"exp":                       tokenClaims.ExpiresAt.Unix(),
+		"iat":                       now.Unix(),
+	}
+	if tokenClaims.SessionID != uuid.Nil {
+		claims["sid"] = tokenClaims.SessionID.String()
+	}
+	if tokenClaims.OrganisationID != uuid.Nil {
+		claims["org_id"] = tokenClaims.OrganisationID.String()
+	}
+	return signClaims(claims), tokenClaims
+}
+
+func GenJWT(userId uuid.UUID) string {
+	tokenRaw, _ := NewAccessToken(TokenClaims{UserID: userId})
+	return tokenRaw
+}
+
+// NewActionToken signs a single-purpose token, e.g. for an email
+// verification link. Action tokens are never accepted as access tokens.
+func NewActionToken(purpose string, userId uuid.UUID, email string, generation int64, ttl time.Duration) string {
+	now := time.Now()
+	return signClaims(jwt.MapClaims{
+		"purpose": purpose,
+		"user_id": userId.String(),
+		"email":   email,
+		"gen":     generation,
+		"jti":     uuid.New().String(),
+		"iss":     "auth-service",
+		"exp":     now.Add(ttl).Unix(),
+		"iat":     now.Unix(),
+	})
+}
+
+func ParseActionToken(tokenRaw, purpose string) (ActionClaims, bool) {
+	claims, ok := parseSignedClaims(tokenRaw)
+	if !ok {
+		return ActionClaims{}, false
+	}
+	if actual, _ := claims["purpose"].(string); actual != purpose {
+		return ActionClaims{}, false
+	}
+	userId, ok := parseUUIDClaim(claims, "user_id")
+	if !ok {
+		return ActionClaims{}, false
+	}
+	tokenId, ok := parseUUIDClaim(claims, "jti")
+	if !ok {
+		return ActionClaims{}, false
+	}
+	email, _ := claims["email"].(string)
+	return ActionClaims{
+		UserID:     userId,
+		TokenID:    tokenId,
+		Email:      email,
+		Generation: generation(claims),
+		IssuedAt:   issuedAt(claims),
+		ExpiresAt:  time.Unix(int64(claims["exp"].(float64)), 0),
+	}, true
+}
+
+// GenRefreshToken returns an opaque refresh token together with the hash
+// that should be persisted instead of the token itself.
+func GenRefreshToken() (string, []byte) {
+	buff := make([]byte, refreshTokenLength)
+	if _, err := rand.Read(buff); err != nil {
+		log.Fatalf("Error generating refresh token: %v", err)
+	}
+	tokenRaw := base64.RawURLEncoding.EncodeToString(buff)
+	return tokenRaw, HashRefreshToken(tokenRaw)
+}
+
+func HashRefreshToken(tokenRaw string) []byte {
+	hash := sha256.Sum256([]byte(tokenRaw))
+	return hash[:]
+}
+
+// GenInvitationToken returns an opaque organisation invitation token and the
+// hash to store.
+func GenInvitationToken() (string, []byte) {
+	buff := make([]byte, refreshTokenLength)
+	if _, err := rand.Read(buff); err != nil {
+		log.Fatalf("Error generating invitation token: %v", err)
+	}
+	tokenRaw := base64.RawURLEncoding.EncodeToString(buff)
+	return tokenRaw, HashInvitationToken(tokenRaw)
+}
+
+func HashInvitationToken(tokenRaw string) []byte {
+	hash := sha256.Sum256([]byte(tokenRaw))
+	return hash[:]
+}
+
+// GenAPIKey returns a key with a recognisable prefix, so that leaked keys are
+// easy to find in logs and repositories, and the hash to store.
+func GenAPIKey() (string, []byte) {
+	buff := make([]byte, refreshTokenLength)
+	if _, err := rand.Read(buff); err != nil {
+		log.Fatalf("Error generating API key: %v", err)
+	}
+	keyRaw := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buff)
+	return keyRaw, HashAPIKey(keyRaw)
+}
+
+func HashAPIKey(keyRaw string) []byte {
+	hash := sha256.Sum256([]byte(keyRaw))
+	return hash[:]
+}
+
+// GetPasswordHash is the legacy unsalted MD5 scheme. It is only used to
+// verify passwords of accounts created before the switch to passwordhasher.
 func GetPasswordHash(login string, password string) [Md5Len]byte {
 	hashGenerator := md5.New()
 	_, err := io.WriteString(hashGenerator, password+login)
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"strings"
+)
+
+// DefaultPhoneRegion is assumed for national numbers sent without a region,
+// the platform accepted only Russian numbers before.
+const DefaultPhoneRegion = "RU"
+
+const (
+	minE164Digits = 7
+	maxE164Digits = 15
+)
+
+// phoneRegion describes the national numbering plan of a country. Lengths are
+// of the national significant number, without the trunk prefix.
+type phoneRegion struct {
+	region      string
+	callingCode string
+	trunkPrefix string
+	minLength   int
+	maxLength   int
+}
+
+// phoneRegions lists the supported regions. Regions sharing a calling code
+// come after the main one, which numbers without a region hint are assigned to.
+var phoneRegions = []phoneRegion{
+	{"RU", "7", "8", 10, 10},
+	{"KZ", "7", "8", 10, 10},
+	{"BY", "375", "8", 9, 9},
+	{"UA", "380", "0", 9, 9},
+	{"AM", "374", "0", 8, 8},
+	{"GE", "995", "0", 9, 9},
+	{"AZ", "994", "0", 9, 9},
+	{"KG", "996", "0", 9, 9},
+	{"TJ", "992", "", 9, 9},
+	{"UZ", "998", "", 9, 9},
+	{"US", "1", "1", 10, 10},
+	{"CA", "1", "1", 10, 10},
+	{"GB", "44", "0", 9, 10},
+	{"DE", "49", "0", 6, 13},
+	{"FR", "33", "0", 9, 9},
+	{"ES", "34", "", 9, 9},
+	{"IT", "39", "", 6, 11},
+	{"NL", "31", "0", 9, 9},
+	{"PL", "48", "", 9, 9},
+	{"RS", "381", "0", 8, 10},
+	{"TR", "90", "0", 10, 10},
+	{"IL", "972", "0", 8, 9},
+	{"AE", "971", "0", 8, 9},
+	{"IN", "91", "0", 10, 10},
+	{"CN", "86", "0", 5, 12},
+	{"JP", "81", "0", 9, 10},
+	{"BR", "55", "0", 10, 11},
+}
+
+func findPhoneRegion(region string) (phoneRegion, bool) {
+	for _, r := range phoneRegions {
+		if r.region == region {
+			return r, true
+		}
+	}
+	return phoneRegion{}, false
+}
+
+func (r phoneRegion) fits(national string) bool {
+	return len(national) >= r.minLength && len(national) <= r.maxLength
+}
+
+func IsKnownPhoneRegion(region string) bool {
+	_, ok := findPhoneRegion(strings.ToUpper(strings.TrimSpace(region)))
+	return ok
+}
+
+// ParsePhoneNumber normalises a phone number to E.164. Numbers starting with
+// + or 00 are international, others are read as national numbers of region,
+// DefaultPhoneRegion if it is empty. The region of the number is returned
+// with it, empty for a calling code missing from phoneRegions.
+func ParsePhoneNumber(phoneNumber, region string) (string, string, bool) {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if region == "" {
+		region = DefaultPhoneRegion
+	}
+	hint, ok := findPhoneRegion(region)
+	if !ok {
+		return "", "", false
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phoneNumber))
+	international := false
+	if rest, ok := strings.CutPrefix(digits, "+"); ok {
+		digits, international = rest, true
+	} else if rest, ok := strings.CutPrefix(digits, "00"); ok {
+		digits, international = rest, true
+	}
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) != -1 {
+		return "", "", false
+	}
+
+	if !international {
+		national := digits
+		if stripped, ok := strings.CutPrefix(national, hint.trunkPrefix); ok && hint.trunkPrefix != "" && hint.fits(stripped) {
+			national = stripped
+		}
+		if !hint.fits(national) {
+			return "", "", false
+		}
+		return "+" + hint.callingCode + national, hint.region, true
+	}
+
+	if len(digits) < minE164Digits || len(digits) > maxE164Digits || digits[0] == '0' {
+		return "", "", false
+	}
+	candidates := append([]phoneRegion{hint}, phoneRegions...)
+	knownCode := false
+	for _, r := range candidates {
+		national, ok := strings.CutPrefix(digits, r.callingCode)
+		if !ok {
+			continue
+		}
+		knownCode = true
+		if r.fits(national) {
+			return "+" + digits, r.region, true
+		}
+	}
+	if knownCode {
+		return "", "", false
+	}
+	return "+" + digits, "", true
+}
@@ -1,25 +1,81 @@
 package tests
 
 import (
-	"regexp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
-	"unicode"
 
 	"github.com/google/uuid"
 )
 
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	FirstName    string    `json:"first_name"`
-	SecondName   string    `json:"second_name"`
-	BirthDate    time.Time `json:"birth_date"`
-	Email        string    `json:"email"`
-	PhoneNumber  string    `json:"phone_number"`
-	IsCompany    bool      `json:"is_company"`
-	CreationDate time.Time `json:"creation_date"`
-	UpdateDate   time.Time `json:"update_date"`
-	Login        string    `json:"login"`
+	ID                  uuid.UUID `json:"id"`
+	FirstName           string    `json:"first_name"`
+	SecondName          string    `json:"second_name"`
+	BirthDate           time.Time `json:"birth_date"`
+	Email               string    `json:"email"`
+	EmailVerified       bool      `json:"email_verified"`
+	PhoneNumber         string    `json:"phone_number"`
+	PhoneRegion         string    `json:"phone_region"`
+	PhoneNumberVerified bool      `json:"phone_number_verified"`
+	IsCompany           bool      `json:"is_company"`
+	Role                Role      `json:"role"`
+	TwoFactorEnabled    bool      `json:"two_factor_enabled"`
+	OrganisationID      uuid.UUID `json:"organisation_id"`
+	Suspended           bool      `json:"suspended"`
+	CreationDate        time.Time `json:"creation_date"`
+	UpdateDate          time.Time `json:"update_date"`
+	Login               string    `json:"login"`
+}
+
+type Role string
+
+const (
+	RoleCustomer      Role = "customer"
+	RoleCompanyMember Role = "company_member"
+	RoleCompanyAdmin  Role = "company_admin"
+	RolePlatformAdmin Role = "platform_admin"
+)
+
+func DefaultRole(isCompany bool) Role {
+	if isCompany {
+		return RoleCompanyAdmin
+	}
+	return RoleCustomer
+}
+
+func IsValidRole(role Role) bool {
+	switch role {
+	case RoleCustomer, RoleCompanyMember, RoleCompanyAdmin, RolePlatformAdmin:
+		return true
+	}
+	return false
+}
+
+// OrganisationRole is the role of a member within its organisation. Owners
+// and admins are company admins on the platform, members company members.
+type OrganisationRole string
+
+const (
+	OrganisationRoleOwner  OrganisationRole = "owner"
+	OrganisationRoleAdmin  OrganisationRole = "admin"
+	OrganisationRoleMember OrganisationRole = "member"
+)
+
+func CompanyRole(role OrganisationRole) Role {
+	if role == OrganisationRoleMember {
+		return RoleCompanyMember
+	}
+	return RoleCompanyAdmin
+}
+
+// IsValidMemberRole reports whether members can be invited with or given the
+// role. Owners only change through an ownership transfer.
+func IsValidMemberRole(role OrganisationRole) bool {
+	return role == OrganisationRoleAdmin || role == OrganisationRoleMember
 }
 
 type UserCreds struct {
@@ -29,52 +85,391 @@ type UserCreds struct {
 	IsCompany bool   `json:"is_company"`
 }
 
-type StorageManager interface {
-	CreateUser(login, password, email string, isCompany bool) (User, error)
-	GetJWTByCredentials(login, password string) (string, error)
-	GetUserByJWT(jwt string) (User, error)
-	UpdateUserByJWT(jwt string, userInfo User) (User, error)
-	GetUserById(userId uuid.UUID) (User, error)
+var (
+	ErrInvalidUser     = errors.New("invalid user data")
+	ErrInvalidProfile  = errors.New("invalid profile data")
+	ErrInvalidPassword = errors.New("password must be at least 8 characters long and contain a digit and an uppercase letter")
+	ErrWrongPassword   = errors.New("wrong password")
+
+	ErrTwoFactorUnavailable    = errors.New("two-factor authentication is only available for company accounts")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication is required for company accounts")
+	ErrWrongTwoFactorCode      = errors.New("wrong two-factor code")
+
+	ErrForbidden    = errors.New("permission denied")
+	ErrInvalidRole  = errors.New("invalid role")
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("user already exists")
+
+	ErrAccountSuspended = errors.New("account is suspended")
+	ErrSuspendSelf      = errors.New("admins cannot suspend their own account")
+
+	ErrTooManyLoginAttempts = errors.New("too many failed login attempts")
+
+	ErrAPIKeysUnavailable = errors.New("API keys are only available for company accounts")
+	ErrInvalidAPIKey      = errors.New("API key needs a name of up to 100 characters and scopes from " + strings.Join(APIKeyScopes, ", "))
+	ErrAPIKeyNotFound     = errors.New("API key not found")
+
+	ErrSessionNotFound = errors.New("session not found")
+
+	ErrNotInOrganisation       = errors.New("user is not a member of an organisation")
+	ErrAlreadyInOrganisation   = errors.New("user is already a member of an organisation")
+	ErrInvalidOrganisationName = errors.New("organisation name must be 1 to 100 characters long")
+	ErrInvalidMemberRole       = errors.New("organisation role must be admin or member")
+	ErrInvalidInvitation       = errors.New("invalid invitation")
+	ErrMemberNotFound          = errors.New("organisation member not found")
+	ErrInvitationNotFound      = errors.New("invitation not found or expired")
+	ErrInvitationForOtherEmail = errors.New("invitation was sent to another email address")
+	ErrOrganisationOwner       = errors.New("the owner has to transfer the organisation to another member first")
+
+	ErrPhoneVerificationUnavailable = errors.New("phone number verification is not configured")
+	ErrNoPhoneNumber                = errors.New("profile has no phone number")
+	ErrPhoneNumberVerified          = errors.New("phone number is already verified")
+	ErrPhoneNumberTaken             = errors.New("phone number is verified by another user")
+	ErrWrongPhoneCode               = errors.New("wrong or expired phone verification code")
+	ErrPhoneCodeRecentlySent        = errors.New("a verification code was sent less than a minute ago")
+)
+
+// ClientInfo describes where a request comes from, as forwarded by the gateway.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// Session groups the tokens issued by one login and their refreshes.
+// Session is active while its token generation is the current one of the
+// user and it is not revoked on its own.
+type Session struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	UserAgent       string
+	IP              string
+	CreationDate    time.Time
+	LastSeenDate    time.Time
+	RevokedAt       time.Time
+	TokenGeneration int64
+}
+
+type AuditAction string
+
+const (
+	AuditRegister       AuditAction = "register"
+	AuditLogin          AuditAction = "login"
+	AuditTwoFactorLogin AuditAction = "two_factor_login"
+	AuditTokenIssued    AuditAction = "token_issued"
+	AuditProfileUpdate  AuditAction = "profile_update"
+	AuditRoleChange     AuditAction = "role_change"
+	AuditSuspend        AuditAction = "suspend"
+	AuditUnsuspend      AuditAction = "unsuspend"
+	AuditForceLogout    AuditAction = "force_logout"
+	AuditTwoFactorOn    AuditAction = "two_factor_enable"
+	AuditTwoFactorOff   AuditAction = "two_factor_disable"
+	AuditUserSearch     AuditAction = "user_search"
+	AuditUserView       AuditAction = "user_view"
+
+	AuditOrganisationInvite     AuditAction = "organisation_invite"
+	AuditOrganisationJoin       AuditAction = "organisation_join"
+	AuditOrganisationRoleChange AuditAction = "organisation_role_change"
+	AuditOrganisationLeave      AuditAction = "organisation_leave"
+	AuditOrganisationTransfer   AuditAction = "organisation_transfer"
+)
+
+type AuditOutcome string
+
+const (
+	AuditSuccess AuditOutcome = "success"
+	AuditFailure AuditOutcome = "failure"
+)
+
+// FieldChange is a single profile field before and after an update.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// AuditEvent is an entry of the append-only audit log. ActorID is the user who
+// did the action and UserID the user it was done to, both are uuid.Nil for a
+// login attempt with an unknown login. A user search has no UserID and lists
+// its filter in Changes, as new values.
+type AuditEvent struct {
+	ID           uuid.UUID     `json:"id"`
+	ActorID      uuid.UUID     `json:"actor_id"`
+	UserID       uuid.UUID     `json:"user_id"`
+	Login        string        `json:"login"`
+	Action       AuditAction   `json:"action"`
+	IP           string        `json:"ip"`
+	Outcome      AuditOutcome  `json:"outcome"`
+	Changes      []FieldChange `json:"changes,omitempty"`
+	CreationDate time.Time     `json:"creation_date"`
+}
+
+// AuditFilter selects audit events of UserID, or of all users if it is
+// uuid.Nil, created in [From, To). Zero times leave the range open.
+type AuditFilter struct {
+	UserID uuid.UUID
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
+// OutboxTopic names a Kafka topic of the outbox, the relay maps it to the
+// configured topic name.
+type OutboxTopic string
+
+const (
+	OutboxStatsTopic OutboxTopic = "stats"
+	OutboxAuditTopic OutboxTopic = "audit"
+)
+
+// OutboxMessage is a Kafka message stored in the transaction of the change it
+// announces. The relay publishes messages in ID order and deletes them once
+// the broker acknowledged them, so they are delivered at least once.
+type OutboxMessage struct {
+	ID           int64
+	Topic        OutboxTopic
+	Key          string
+	Value        []byte
+	CreationDate time.Time
+}
+
+// UserFilter selects users for admins. Login and Email match fragments
+// ignoring case, IsCompany is ignored if nil and creation dates are in
+// [CreatedFrom, CreatedTo), zero times leave the range open. Users are ordered
+// by creation date.
+type UserFilter struct {
+	Login       string
+	Email       string
+	IsCompany   *bool
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Offset      int
+	Limit       int
+}
+
+// PhoneVerification is a pending SMS code for PhoneNumber. Only the hash of
+// the code is stored.
+type PhoneVerification struct {
+	UserID       uuid.UUID
+	PhoneNumber  string
+	CodeHash     []byte
+	Attempts     int
+	ExpiresAt    time.Time
+	CreationDate time.Time
+}
+
+// APIKeyScopes are the permissions an API key can be limited to.
+var APIKeyScopes = []string{"promos:write", "stats:read"}
+
+// APIKey lets a company back-end act as the owning user, limited to Scopes.
+// Only the hash of the key is stored.
+type APIKey struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	Name         string
+	KeyHash      []byte
+	Scopes       []string
+	CreationDate time.Time
+	LastUsedDate time.Time
+}
+
+// Organisation owns the promos of a company, so that they outlive the staff
+// accounts creating them. Exactly one member is the owner. Members and
+// Invitations are only filled in by the storage manager.
+type Organisation struct {
+	ID           uuid.UUID
+	Name         string
+	CreationDate time.Time
+	UpdateDate   time.Time
+	Members      []OrganisationMember
+	Invitations  []OrganisationInvitation
+}
+
+// OrganisationMember links a user to its only organisation. Login and Email
+// are filled in by the storage manager.
+type OrganisationMember struct {
+	OrganisationID uuid.UUID
+	UserID         uuid.UUID
+	Role           OrganisationRole
+	Login          string
+	Email          string
+	CreationDate   time.Time
+}
+
+// OrganisationInvitation is accepted by the account with Email. Only the hash
+// of the token sent by email is stored.
+type OrganisationInvitation struct {
+	ID             uuid.UUID
+	OrganisationID uuid.UUID
+	Email          string
+	Role           OrganisationRole
+	InvitedBy      uuid.UUID
+	TokenHash      []byte
+	ExpiresAt      time.Time
+	CreationDate   time.Time
+}
+
+// LoginLockedError is returned instead of checking the password while a login
+// or a client IP is locked out.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("%v, retry in %v", ErrTooManyLoginAttempts, e.RetryAfter)
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrTooManyLoginAttempts
+}
+
+// LoginAttempts counts consecutive failed logins for a throttling key.
+type LoginAttempts struct {
+	Key         string
+	Failures    int
+	LastFailure time.Time
+}
+
+type RefreshToken struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	SessionID    uuid.UUID
+	TokenHash    []byte
+	ExpiresAt    time.Time
+	RevokedAt    time.Time
+	CreationDate time.Time
+}
+
+// TokenPair carries only TwoFactorChallenge when the password was correct but
+// the login has to be completed with a second factor.
+type TokenPair struct {
+	AccessToken            string
+	AccessExpiresAt        time.Time
+	RefreshToken           string
+	RefreshExpiresAt       time.Time
+	TwoFactorChallenge     string
+	TwoFactorSetupRequired bool
+}
+
+// TwoFactor keeps the TOTP secret in plain text; storages encrypt it.
+// RecoveryCodes are hashes of the unused recovery codes.
+type TwoFactor struct {
+	UserID        uuid.UUID
+	Secret        string
+	Enabled       bool
+	RecoveryCodes []string
+	LastUsedStep  int64
+	CreationDate  time.Time
+}
+
+// TokenIntrospection describes an access token to other services. Only an
+// Active token may be trusted. Revoked tokens still carry their claims, with
+// the role and flags of the user as they are now. Tokens of suspended users
+// are never active. CacheTTL is how long the caller may reuse the answer.
+type TokenIntrospection struct {
+	Active                 bool
+	Revoked                bool
+	UserID                 uuid.UUID
+	TokenID                uuid.UUID
+	SessionID              uuid.UUID
+	Role                   Role
+	IsCompany              bool
+	EmailVerified          bool
+	TwoFactorSetupRequired bool
+	OrganisationID         uuid.UUID
+	Suspended              bool
+	IssuedAt               time.Time
+	ExpiresAt              time.Time
+	CacheTTL               time.Duration
 }
 
-func IsValidLogin(login string) bool {
-	return len(login) >= 6 && strings.IndexFunc(login, func(r rune) bool {
-		return r > 127
-	}) == -1
+type TwoFactorEnrollment struct {
+	Secret          string
+	ProvisioningURI string
 }
 
-func IsValidPassword(password string) bool {
-	return len(password) >= 8 && strings.IndexFunc(password, unicode.IsDigit) != -1 &&
-		strings.IndexFunc(password, unicode.IsUpper) != -1
+type StorageManager interface {
+	CreateUser(login, password, email string, isCompany bool, client ClientInfo) (User, error)
+	GetJWTByCredentials(identifier, password string, client ClientInfo) (TokenPair, error)
+	RefreshJWT(refreshToken string, client ClientInfo) (TokenPair, error)
+	Logout(jwt, refreshToken string) (bool, error)
+	VerifyEmail(token string) (User, error)
+	ResendVerification(jwt string) (User, error)
+	ChangePassword(jwt, currentPassword, newPassword string) (TokenPair, error)
+	RequestPasswordReset(login string)
+	ResetPassword(token, newPassword string) (bool, error)
+	DeleteAccount(jwt, password string) (bool, uuid.UUID, error)
+	LoginTwoFactor(challenge, code string, client ClientInfo) (TokenPair, error)
+	EnrollTwoFactor(jwt string) (TwoFactorEnrollment, error)
+	ConfirmTwoFactor(jwt, code string, client ClientInfo) ([]string, TokenPair, error)
+	DisableTwoFactor(jwt, code string, client ClientInfo) (bool, error)
+	GetUserByJWT(jwt string) (User, error)
+	IntrospectToken(jwt string) (TokenIntrospection, error)
+	UpdateUserByJWT(jwt string, userInfo User, client ClientInfo) (User, error)
+	GetUserById(userId uuid.UUID) (User, error)
+	SetUserRole(jwt string, userId uuid.UUID, role Role) (User, error)
+	SetUserRoleByLogin(login string, role Role) (User, error)
+	SearchUsers(jwt string, filter UserFilter) ([]User, bool, error)
+	AdminGetUser(jwt string, userId uuid.UUID) (User, error)
+	AdminUpdateUser(jwt string, userId uuid.UUID, userInfo User) (User, error)
+	SetUserSuspended(jwt string, userId uuid.UUID, suspended bool) (User, error)
+	ForceLogout(jwt string, userId uuid.UUID) (bool, error)
+	CreateAPIKey(jwt, name string, scopes []string) (APIKey, string, error)
+	ListAPIKeys(jwt string) ([]APIKey, bool, error)
+	RevokeAPIKey(jwt string, keyId uuid.UUID) (bool, error)
+	AuthenticateAPIKey(key string) (APIKey, User, error)
+	ListSessions(jwt string) ([]Session, uuid.UUID, error)
+	RevokeSession(jwt string, sessionId uuid.UUID) (bool, error)
+	RequestPhoneVerification(jwt string) (bool, error)
+	ConfirmPhoneNumber(jwt, code string) (User, error)
+	ListAuditEvents(jwt string, filter AuditFilter) ([]AuditEvent, bool, error)
+	GetOrganisation(jwt string) (Organisation, error)
+	RenameOrganisation(jwt, name string) (Organisation, error)
+	InviteMember(jwt, email string, role OrganisationRole) (OrganisationInvitation, error)
+	CancelInvitation(jwt string, invitationId uuid.UUID) (bool, error)
+	AcceptInvitation(jwt, token string, client ClientInfo) (TokenPair, error)
+	SetMemberRole(jwt string, userId uuid.UUID, role OrganisationRole) (OrganisationMember, error)
+	RemoveMember(jwt string, userId uuid.UUID) (bool, error)
+	TransferOwnership(jwt string, userId uuid.UUID) (Organisation, error)
 }
 
-func IsValidEmail(email string) bool {
-	pattern := `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
-	regex := regexp.MustCompile(pattern)
-	return regex.MatchString(email)
+func IsValidAPIKey(name string, scopes []string) bool {
+	if name == "" || len(name) > 100 || len(scopes) == 0 {
+		return false
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(APIKeyScopes, scope) {
+			return false
+		}
+	}
+	return true
 }
 
 func IsValidPhoneNumber(phoneNumber string) bool {
-	return phoneNumber[0] == '8' && strings.IndexFunc(phoneNumber, func(r rune) bool {
-		return !unicode.IsDigit(r)
-	}) == -1
+	_, _, ok := ParsePhoneNumber(phoneNumber, DefaultPhoneRegion)
+	return ok
 }
 
-func NewUser(login, email, password string, isCompany bool) *User {
-	if !IsValidLogin(login) || !IsValidPassword(password) {
-		return nil
+func NewUser(login, email, password string, isCompany bool) (*User, error) {
+	if err := ValidateNewUser(login, email, password); err != nil {
+		return nil, err
 	}
 	curTime := time.Now()
 	return &User{
 		Login:        login,
 		Email:        email,
 		IsCompany:    isCompany,
+		Role:         DefaultRole(isCompany),
 		CreationDate: curTime,
 		UpdateDate:   curTime,
-	}
+	}, nil
 }
 
-func MergeUserInfo(user User, newInfo User) User {
+func MergeUserInfo(user User, newInfo User) (User, error) {
+	if err := ValidateProfile(newInfo); err != nil {
+		return User{}, err
+	}
 	curTime := time.Now()
 	if newInfo.FirstName != "" {
 		user.FirstName = newInfo.FirstName
@@ -85,14 +480,67 @@ func MergeUserInfo(user User, newInfo User) User {
 	if !newInfo.BirthDate.IsZero() {
 		user.BirthDate = newInfo.BirthDate
 	}
-	if newInfo.Email != "" && IsValidEmail(newInfo.Email) {
+	if newInfo.Email != "" && newInfo.Email != user.Email {
 		user.Email = newInfo.Email
+		user.EmailVerified = false
 	}
-	if newInfo.PhoneNumber != "" && IsValidPhoneNumber(newInfo.PhoneNumber) {
-		user.PhoneNumber = newInfo.PhoneNumber
+	if newInfo.PhoneNumber != "" {
+		phoneNumber, region, _ := ParsePhoneNumber(newInfo.PhoneNumber, newInfo.PhoneRegion)
+		if phoneNumber != user.PhoneNumber {
+			user.PhoneNumber = phoneNumber
+			user.PhoneNumberVerified = false
+		}
+		user.PhoneRegion = region
 	}
 	user.UpdateDate = curTime
-	return user
+	return user, nil
+}
+
+// DiffProfiles lists the profile fields that differ between two versions of
+// the user.
+func DiffProfiles(old, new User) []FieldChange {
+	var changes []FieldChange
+	addChange := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	formatDate := func(date time.Time) string {
+		if date.IsZero() {
+			return ""
+		}
+		return date.Format(time.DateOnly)
+	}
+	addChange("first_name", old.FirstName, new.FirstName)
+	addChange("second_name", old.SecondName, new.SecondName)
+	addChange("birth_date", formatDate(old.BirthDate), formatDate(new.BirthDate))
+	addChange("email", old.Email, new.Email)
+	addChange("phone_number", old.PhoneNumber, new.PhoneNumber)
+	addChange("role", string(old.Role), string(new.Role))
+	return changes
+}
+
+// DescribeUserFilter lists the criteria of a user search, in the form of
+// the changes of an audit event.
+func DescribeUserFilter(filter UserFilter) []FieldChange {
+	var criteria []FieldChange
+	addCriterion := func(field, value string) {
+		if value != "" {
+			criteria = append(criteria, FieldChange{Field: field, New: value})
+		}
+	}
+	addCriterion("login", filter.Login)
+	addCriterion("email", filter.Email)
+	if filter.IsCompany != nil {
+		addCriterion("is_company", strconv.FormatBool(*filter.IsCompany))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		addCriterion("created_from", filter.CreatedFrom.Format(time.RFC3339))
+	}
+	if !filter.CreatedTo.IsZero() {
+		addCriterion("created_to", filter.CreatedTo.Format(time.RFC3339))
+	}
+	return criteria
 }
 
 func FetchUserPublicInfo(user User) User {
@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Reasons of field violations, stable codes for clients to map to messages.
+const (
+	ReasonRequired          = "REQUIRED"
+	ReasonTooShort          = "TOO_SHORT"
+	ReasonInvalidCharacters = "INVALID_CHARACTERS"
+	ReasonMissingDigit      = "MISSING_DIGIT"
+	ReasonMissingUppercase  = "MISSING_UPPERCASE"
+	ReasonInvalidFormat     = "INVALID_FORMAT"
+)
+
+var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+type FieldViolation struct {
+	Field       string
+	Reason      string
+	Description string
+}
+
+// ValidationError lists every invalid field of a request and wraps the error
+// describing the request as a whole, e.g. ErrInvalidPassword.
+type ValidationError struct {
+	Err        error
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		if len(fields) == 0 || fields[len(fields)-1] != violation.Field {
+			fields = append(fields, violation.Field)
+		}
+	}
+	return fmt.Sprintf("%v: invalid %s", e.Err, strings.Join(fields, ", "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+type validator struct {
+	violations []FieldViolation
+}
+
+func (v *validator) add(field, reason, description string) {
+	v.violations = append(v.violations, FieldViolation{Field: field, Reason: reason, Description: description})
+}
+
+func (v *validator) err(base error) error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Err: base, Violations: v.violations}
+}
+
+func (v *validator) login(field, login string) {
+	switch {
+	case login == "":
+		v.add(field, ReasonRequired, "login is required")
+	case len(login) < 6:
+		v.add(field, ReasonTooShort, "login must be at least 6 characters long")
+	case strings.IndexFunc(login, func(r rune) bool { return r > 127 }) != -1:
+		v.add(field, ReasonInvalidCharacters, "login may only contain ASCII characters")
+	}
+}
+
+// password reports every rule the password breaks, so the user can fix them
+// at once.
+func (v *validator) password(field, password string) {
+	if password == "" {
+		v.add(field, ReasonRequired, "password is required")
+		return
+	}
+	if len(password) < 8 {
+		v.add(field, ReasonTooShort, "password must be at least 8 characters long")
+	}
+	if strings.IndexFunc(password, unicode.IsDigit) == -1 {
+		v.add(field, ReasonMissingDigit, "password must contain a digit")
+	}
+	if strings.IndexFunc(password, unicode.IsUpper) == -1 {
+		v.add(field, ReasonMissingUppercase, "password must contain an uppercase letter")
+	}
+}
+
+func (v *validator) email(field, email string) {
+	switch {
+	case email == "":
+		v.add(field, ReasonRequired, "email is required")
+	case !emailRegex.MatchString(email):
+		v.add(field, ReasonInvalidFormat, "email must look like name@example.com")
+	}
+}
+
+func (v *validator) phoneNumber(numberField, regionField, phoneNumber, region string) {
+	if region != "" && !IsKnownPhoneRegion(region) {
+		v.add(regionField, ReasonInvalidFormat, "phone region must be a supported ISO 3166 country code")
+		return
+	}
+	if _, _, ok := ParsePhoneNumber(phoneNumber, region); !ok {
+		v.add(numberField, ReasonInvalidFormat, "phone number must start with + and a country code or be a national number of the phone region")
+	}
+}
+
+func ValidateNewUser(login, email, password string) error {
+	var v validator
+	v.login("login", login)
+	v.password("password", password)
+	v.email("email", email)
+	return v.err(ErrInvalidUser)
+}
+
+func ValidatePassword(field, password string) error {
+	var v validator
+	v.password(field, password)
+	return v.err(ErrInvalidPassword)
+}
+
+// ValidateProfile checks the fields set in a profile update, empty fields are
+// left unchanged.
+func ValidateProfile(newInfo User) error {
+	var v validator
+	if newInfo.Email != "" {
+		v.email("email", newInfo.Email)
+	}
+	if newInfo.PhoneNumber != "" {
+		v.phoneNumber("phone_number", "phone_region", newInfo.PhoneNumber, newInfo.PhoneRegion)
+	}
+	return v.err(ErrInvalidProfile)
+}
+
+func ValidateInvitation(email string, role OrganisationRole) error {
+	var v validator
+	v.email("email", email)
+	if !IsValidMemberRole(role) {
+		v.add("role", ReasonInvalidFormat, "role must be admin or member")
+	}
+	return v.err(ErrInvalidInvitation)
+}
+
+func IsValidLogin(login string) bool {
+	var v validator
+	v.login("login", login)
+	return len(v.violations) == 0
+}
+
+func IsValidPassword(password string) bool {
+	var v validator
+	v.password("password", password)
+	return len(v.violations) == 0
+}
+
+func IsValidEmail(email string) bool {
+	return emailRegex.MatchString(email)
+}
@@ -1,28 +1,213 @@
 package main
 
 import (
+	authconfig "authservice/auth_config"
 	authhandlers "authservice/auth_handlers"
+	authmailer "authservice/auth_mailer"
+	authoutbox "authservice/auth_outbox"
+	authsms "authservice/auth_sms"
+	loginthrottle "authservice/auth_storage/login_throttle"
+	passwordhasher "authservice/auth_storage/password_hasher"
 	pgstorage "authservice/auth_storage/postgresql_storage"
 	smimpl "authservice/auth_storage/storage_manager"
+	twofactor "authservice/auth_storage/two_factor"
+	userkeys "authservice/auth_storage/user_keys"
+	usermodel "authservice/auth_storage/user_model"
 	protoauth "authservice/proto/auth"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"net"
+	"os"
+	"strconv"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
 
+func newMailer(cfg authconfig.Mailer) (authmailer.Mailer, error) {
+	switch cfg.Kind {
+	case "smtp":
+		return authmailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case "file":
+		return authmailer.NewFileMailer(cfg.Dir, cfg.From)
+	}
+	return nil, fmt.Errorf("unknown mailer %q", cfg.Kind)
+}
+
+// newSMSNotifier returns nil, disabling phone number verification, unless an
+// SMS sender is configured.
+func newSMSNotifier(cfg authconfig.SMS) (*authsms.Notifier, error) {
+	switch cfg.Sender {
+	case "http":
+		return authsms.NewNotifier(authsms.NewHTTPSender(cfg.GatewayURL, cfg.GatewayToken)), nil
+	case "log":
+		return authsms.NewNotifier(authsms.LogSender{}), nil
+	case "":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown SMS sender %q", cfg.Sender)
+}
+
+// outboxRelayInterval is how long events announced by the service may wait
+// for delivery while Kafka is available.
+const outboxRelayInterval = time.Second
+
+// loginAttemptsPruneInterval is how often failure counters past the reset
+// period are deleted. The next failure of a key starts its count over anyway,
+// pruning only keeps the table small.
+const loginAttemptsPruneInterval = time.Hour
+
+func pruneLoginAttempts(storage smimpl.Storage, resetAfter time.Duration) {
+	for range time.Tick(loginAttemptsPruneInterval) {
+		if err := storage.DeleteLoginAttempts(time.Now().Add(-resetAfter)); err != nil {
+			log.Printf("Failed to prune login attempts: %v", err)
+		}
+	}
+}
+
+// openSQLiteStorage is set by sqlite.go when the binary is built with
+// -tags sqlite. The pure-Go SQLite engine is large, so production images leave
+// it out.
+var openSQLiteStorage func(path string, secretCipher *twofactor.SecretCipher) (smimpl.Storage, error)
+
+func newStorage(cfg authconfig.Storage, secretCipher *twofactor.SecretCipher) (smimpl.Storage, error) {
+	switch cfg.Backend {
+	case "postgres":
+		return pgstorage.NewStorage(cfg.Postgres, secretCipher), nil
+	case "sqlite":
+		if openSQLiteStorage == nil {
+			return nil, errors.New("built without SQLite support, rebuild with -tags sqlite")
+		}
+		return openSQLiteStorage(cfg.SQLitePath, secretCipher)
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+}
+
+// setRole is run as "main set-role <login> <role>" to appoint the first
+// platform admin, later roles are managed through the SetUserRole RPC.
+// migrate is run as "main migrate up", "main migrate down <version>" or
+// "main migrate version". The service refuses to start until the schema is
+// migrated to the version it was built with.
+func migrate(cfg authconfig.Postgres, args []string) error {
+	db := pgstorage.OpenDB(cfg)
+	migrations, err := pgstorage.LoadMigrations()
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(args) == 1 && args[0] == "up":
+		if err := pgstorage.Migrate(db, len(migrations)); err != nil {
+			return err
+		}
+	case len(args) == 2 && args[0] == "down":
+		target, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := pgstorage.Migrate(db, target); err != nil {
+			return err
+		}
+	case len(args) == 1 && args[0] == "version":
+	default:
+		return errors.New("usage: main migrate up | down <version> | version")
+	}
+	version, err := pgstorage.SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	log.Printf("Schema version %d, latest %d", version, len(migrations))
+	return nil
+}
+
 func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML config file, overridden by the environment")
+	printConfig := flag.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+	flag.Parse()
+	cfg, err := authconfig.Load(*configPath)
+	if err != nil {
+		log.Fatal("Invalid config: ", err)
+	}
+	if *printConfig {
+		if err := authconfig.Print(os.Stdout, cfg); err != nil {
+			log.Fatal("Failed to print config: ", err)
+		}
+		return
+	}
+
+	args := flag.Args()
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := migrate(cfg.Storage.Postgres, args[1:]); err != nil {
+			log.Fatal("Failed to migrate: ", err)
+		}
+		return
+	}
+
+	hasher, err := passwordhasher.NewHasher(cfg.PasswordHashAlgorithm)
+	if err != nil {
+		log.Fatal("Failed to create password hasher:", err)
+	}
+
+	if err := userkeys.ReloadKeys(); err != nil {
+		log.Fatal("Failed to load JWT keys:", err)
+	}
+	userkeys.StartKeyReloader(userkeys.KeyReloadInterval)
+
+	mailer, err := newMailer(cfg.Mailer)
+	if err != nil {
+		log.Fatal("Failed to create mailer:", err)
+	}
+	notifier := authmailer.NewNotifier(mailer, cfg.PublicURL)
+
+	smsNotifier, err := newSMSNotifier(cfg.SMS)
+	if err != nil {
+		log.Fatal("Failed to create SMS sender:", err)
+	}
+
+	secretCipher, err := twofactor.LoadSecretCipher(cfg.TOTPSecretKeyFile)
+	if err != nil {
+		log.Fatal("Failed to load TOTP secret key:", err)
+	}
+	twoFactorPolicy := twofactor.Policy{RequiredForCompanies: cfg.RequireCompanyTwoFactor}
+
+	storage, err := newStorage(cfg.Storage, secretCipher)
+	if err != nil {
+		log.Fatal("Failed to open storage:", err)
+	}
+	storageManager := smimpl.NewStorageManager(storage, hasher, notifier, twoFactorPolicy, loginthrottle.DefaultPolicy, smsNotifier)
+	if len(args) > 0 && args[0] == "set-role" {
+		if len(args) != 3 {
+			log.Fatal("Usage: main set-role <login> <role>")
+		}
+		// The audit event is relayed to Kafka once the service runs.
+		if _, err := storageManager.SetUserRoleByLogin(args[1], usermodel.Role(args[2])); err != nil {
+			log.Fatal("Failed to set role:", err)
+		}
+		log.Printf("Role of %s set to %s", args[1], args[2])
+		return
+	}
+
+	outboxWriter := authoutbox.NewKafkaWriter(cfg.Kafka.Brokers, map[usermodel.OutboxTopic]string{
+		usermodel.OutboxStatsTopic: cfg.Kafka.StatsTopic,
+		usermodel.OutboxAuditTopic: cfg.Kafka.AuditTopic,
+	})
+	defer outboxWriter.Close()
+	go authoutbox.NewRelay(storage, outboxWriter, outboxRelayInterval).Run(context.Background())
+	go pruneLoginAttempts(storage, loginthrottle.DefaultPolicy.ResetAfter)
+
 	server := grpc.NewServer()
-	protoauth.RegisterAuthServiceServer(server, authhandlers.NewAuthServer(smimpl.NewStorageManager(pgstorage.NewStorage())))
+	protoauth.RegisterAuthServiceServer(server, authhandlers.NewAuthServer(storageManager))
 	reflection.Register(server)
 
-	listener, err := net.Listen("tcp", ":8080")
+	listener, err := net.Listen("tcp", cfg.ListenAddress)
 	if err != nil {
 		log.Fatal("Failed to listen:", err)
 	}
 
-	log.Println("gRPC server started on :8080")
+	log.Println("gRPC server started on", cfg.ListenAddress)
 	if err := server.Serve(listener); err != nil {
 		log.Fatal("Failed to serve:", err)
 	}
@@ -0,0 +1,11 @@
+//go:build sqlite
+
+package main
+
+import (
+	sqlitestorage "authservice/auth_storage/sqlite_storage"
+)
+
+func init() {
+	openSQLiteStorage = sqlitestorage.NewStorage
+}
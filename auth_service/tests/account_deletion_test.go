@@ -0,0 +1,44 @@
+package tests
+
+import (
+	usermodel "authservice/auth_storage/user_model"
+	"errors"
+	"testing"
+)
+
+func TestDeleteAccount(t *testing.T) {
+	env := newTestEnv(t, withTokenUser)
+	sm, user := env.sm, env.user(t, "tokenUser")
+	tokens, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if err != nil {
+		t.Fatalf("GetJWTByCredentials failed: %v", err)
+	}
+
+	if ok, _, err := sm.DeleteAccount(tokens.AccessToken, "WrongPass123"); ok || !errors.Is(err, usermodel.ErrWrongPassword) {
+		t.Fatalf("DeleteAccount accepted a wrong password: %v, %v", ok, err)
+	}
+	if events := outboxStatsEvents(t, env.storage); len(events) != 0 {
+		t.Errorf("a refused deletion wrote %+v to the outbox", events)
+	}
+	if ok, _, err := sm.DeleteAccount(tokens.AccessToken, "ValidPass123"); err != nil || !ok {
+		t.Fatalf("DeleteAccount failed: %v, %v", ok, err)
+	}
+	events := outboxStatsEvents(t, env.storage)
+	if len(events) != 1 || events[0].EventType != "account_deleted" || events[0].UserID != user.ID.String() ||
+		events[0].ObjectID != user.ID.String() {
+		t.Errorf("outbox holds %+v; want the account_deleted event", events)
+	}
+
+	if user, err := sm.GetUserByJWT(tokens.AccessToken); err != nil || user.Login != "" {
+		t.Errorf("JWT of a deleted account is still accepted: %v", err)
+	}
+	if refreshed, err := sm.RefreshJWT(tokens.RefreshToken, testClient); err != nil || refreshed.AccessToken != "" {
+		t.Errorf("refresh token of a deleted account is still accepted: %v", err)
+	}
+	if tokens, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient); err != nil || tokens.AccessToken != "" {
+		t.Errorf("deleted account can still log in: %v", err)
+	}
+	if _, err := sm.CreateUser("tokenUser", "ValidPass123", "token@example.com", false, testClient); err != nil {
+		t.Errorf("login of a deleted account cannot be reused: %v", err)
+	}
+}
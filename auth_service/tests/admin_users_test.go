@@ -0,0 +1,181 @@
+package tests
+
+import (
+	usermodel "authservice/auth_storage/user_model"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAdminOnlyUserManagement(t *testing.T) {
+	env := newTestEnv(t, withAdminUser, withTokenUser)
+	sm, customer := env.sm, env.user(t, "tokenUser")
+	tokens, _ := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if _, _, err := sm.SearchUsers(tokens.AccessToken, usermodel.UserFilter{}); !errors.Is(err, usermodel.ErrForbidden) {
+		t.Errorf("customer searched users: %v", err)
+	}
+	if _, err := sm.AdminGetUser(tokens.AccessToken, customer.ID); !errors.Is(err, usermodel.ErrForbidden) {
+		t.Errorf("customer read a user as admin: %v", err)
+	}
+	if _, err := sm.SetUserSuspended(tokens.AccessToken, customer.ID, true); !errors.Is(err, usermodel.ErrForbidden) {
+		t.Errorf("customer suspended a user: %v", err)
+	}
+	if _, err := sm.ForceLogout(tokens.AccessToken, customer.ID); !errors.Is(err, usermodel.ErrForbidden) {
+		t.Errorf("customer forced a logout: %v", err)
+	}
+	if _, ok, err := sm.SearchUsers("invalid", usermodel.UserFilter{}); err != nil || ok {
+		t.Errorf("SearchUsers accepted an invalid JWT: %v", err)
+	}
+}
+
+func TestSearchUsers(t *testing.T) {
+	env := newTestEnv(t, withAdminUser, withTokenUser)
+	sm, adminJWT := env.sm, env.login(t, "adminUser").AccessToken
+	for _, login := range []string{"companyOne", "companyTwo"} {
+		if _, err := sm.CreateUser(login, "ValidPass123", login+"@corp.example.com", true, testClient); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+	logins := func(users []usermodel.User) []string {
+		var result []string
+		for _, user := range users {
+			result = append(result, user.Login)
+		}
+		return result
+	}
+	isCompany := true
+	filters := []struct {
+		filter   usermodel.UserFilter
+		expected []string
+	}{
+		{usermodel.UserFilter{}, []string{"adminUser", "tokenUser", "companyOne", "companyTwo"}},
+		{usermodel.UserFilter{Login: "COMPANY"}, []string{"companyOne", "companyTwo"}},
+		{usermodel.UserFilter{Email: "@corp."}, []string{"companyOne", "companyTwo"}},
+		{usermodel.UserFilter{Email: "%"}, nil},
+		{usermodel.UserFilter{IsCompany: &isCompany, Offset: 1}, []string{"companyTwo"}},
+		{usermodel.UserFilter{Offset: 1, Limit: 2}, []string{"tokenUser", "companyOne"}},
+		{usermodel.UserFilter{CreatedFrom: time.Now().Add(time.Hour)}, nil},
+	}
+	for _, test := range filters {
+		users, ok, err := sm.SearchUsers(adminJWT, test.filter)
+		if err != nil || !ok || !slices.Equal(logins(users), test.expected) {
+			t.Errorf("SearchUsers(%+v) = %v, %v, %v; want %v", test.filter, logins(users), ok, err, test.expected)
+		}
+	}
+
+	admin, _ := sm.GetUserByJWT(adminJWT)
+	events, _, _ := sm.ListAuditEvents(adminJWT, usermodel.AuditFilter{Limit: 1})
+	if len(events) != 1 || events[0].Action != usermodel.AuditUserSearch || events[0].ActorID != admin.ID ||
+		len(events[0].Changes) != 1 || events[0].Changes[0].Field != "created_from" {
+		t.Errorf("audit log has %+v", events)
+	}
+}
+
+func TestSuspendUser(t *testing.T) {
+	env := newTestEnv(t, withAdminUser, withTokenUser)
+	sm, adminJWT := env.sm, env.login(t, "adminUser").AccessToken
+	company, _ := sm.CreateUser("companyUser", "ValidPass123", "company@example.com", true, testClient)
+	tokens, _ := sm.GetJWTByCredentials("companyUser", "ValidPass123", testClient)
+	_, key, err := sm.CreateAPIKey(tokens.AccessToken, "partner", []string{"stats:read"})
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+
+	user, err := sm.SetUserSuspended(adminJWT, company.ID, true)
+	if err != nil || !user.Suspended {
+		t.Fatalf("SetUserSuspended failed: %+v, %v", user, err)
+	}
+	if user, err := sm.GetUserByJWT(tokens.AccessToken); err != nil || user.Login != "" {
+		t.Errorf("JWT of a suspended user is still accepted: %v", err)
+	}
+	if refreshed, err := sm.RefreshJWT(tokens.RefreshToken, testClient); err != nil || refreshed.AccessToken != "" {
+		t.Errorf("refresh token of a suspended user is still accepted: %v", err)
+	}
+	if _, err := sm.GetJWTByCredentials("companyUser", "ValidPass123", testClient); !errors.Is(err, usermodel.ErrAccountSuspended) {
+		t.Errorf("suspended user logged in: %v", err)
+	}
+	if _, user, err := sm.AuthenticateAPIKey(key); err != nil || user.Login != "" {
+		t.Errorf("API key of a suspended user is still accepted: %v", err)
+	}
+	if admin, _ := sm.GetUserByJWT(adminJWT); admin.Login == "" {
+		t.Fatalf("admin JWT is not accepted")
+	} else if _, err := sm.SetUserSuspended(adminJWT, admin.ID, true); !errors.Is(err, usermodel.ErrSuspendSelf) {
+		t.Errorf("admin suspended itself: %v", err)
+	}
+	if _, err := sm.SetUserSuspended(adminJWT, uuid.New(), true); !errors.Is(err, usermodel.ErrUserNotFound) {
+		t.Errorf("SetUserSuspended accepted an unknown user: %v", err)
+	}
+
+	if user, err := sm.SetUserSuspended(adminJWT, company.ID, false); err != nil || user.Suspended {
+		t.Fatalf("reinstating failed: %+v, %v", user, err)
+	}
+	if tokens, err := sm.GetJWTByCredentials("companyUser", "ValidPass123", testClient); err != nil || tokens.AccessToken == "" {
+		t.Errorf("reinstated user cannot log in: %v", err)
+	}
+	if _, user, err := sm.AuthenticateAPIKey(key); err != nil || user.Login != "companyUser" {
+		t.Errorf("API key of a reinstated user is not accepted: %v", err)
+	}
+
+	events, _, _ := sm.ListAuditEvents(adminJWT, usermodel.AuditFilter{UserID: company.ID})
+	var actions []usermodel.AuditAction
+	for _, event := range events {
+		if event.Action == usermodel.AuditSuspend || event.Action == usermodel.AuditUnsuspend {
+			actions = append(actions, event.Action)
+		}
+	}
+	if !slices.Equal(actions, []usermodel.AuditAction{usermodel.AuditUnsuspend, usermodel.AuditSuspend}) {
+		t.Errorf("audit log has suspension actions %v", actions)
+	}
+}
+
+func TestForceLogout(t *testing.T) {
+	env := newTestEnv(t, withAdminUser, withTokenUser)
+	sm, customer, adminJWT := env.sm, env.user(t, "tokenUser"), env.login(t, "adminUser").AccessToken
+	tokens, _ := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+
+	if ok, err := sm.ForceLogout(adminJWT, customer.ID); err != nil || !ok {
+		t.Fatalf("ForceLogout failed: %v", err)
+	}
+	if introspection, err := sm.IntrospectToken(tokens.AccessToken); err != nil || introspection.Active {
+		t.Errorf("session is still active after a forced logout: %v", err)
+	}
+	if user, err := sm.GetUserByJWT(tokens.AccessToken); err != nil || user.Login != "" {
+		t.Errorf("JWT is still accepted after a forced logout: %v", err)
+	}
+	if refreshed, err := sm.RefreshJWT(tokens.RefreshToken, testClient); err != nil || refreshed.AccessToken != "" {
+		t.Errorf("refresh token is still accepted after a forced logout: %v", err)
+	}
+	if tokens, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient); err != nil || tokens.AccessToken == "" {
+		t.Errorf("user cannot log in again: %v", err)
+	}
+	if _, err := sm.ForceLogout(adminJWT, uuid.New()); !errors.Is(err, usermodel.ErrUserNotFound) {
+		t.Errorf("ForceLogout accepted an unknown user: %v", err)
+	}
+}
+
+func TestAdminUpdateUser(t *testing.T) {
+	env := newTestEnv(t, withAdminUser, withTokenUser)
+	sm, customer, adminJWT := env.sm, env.user(t, "tokenUser"), env.login(t, "adminUser").AccessToken
+	if _, err := sm.AdminUpdateUser(adminJWT, customer.ID, usermodel.User{Email: "not-an-email"}); err == nil {
+		t.Errorf("AdminUpdateUser accepted an invalid email")
+	}
+	user, err := sm.AdminUpdateUser(adminJWT, customer.ID, usermodel.User{FirstName: "Ivan"})
+	if err != nil || user.FirstName != "Ivan" || user.Login != "tokenUser" {
+		t.Fatalf("AdminUpdateUser failed: %+v, %v", user, err)
+	}
+	user, err = sm.AdminGetUser(adminJWT, customer.ID)
+	if err != nil || user.FirstName != "Ivan" || user.Role != usermodel.RoleCustomer || user.CreationDate.IsZero() {
+		t.Errorf("AdminGetUser = %+v, %v", user, err)
+	}
+
+	admin, _ := sm.GetUserByJWT(adminJWT)
+	events, _, _ := sm.ListAuditEvents(adminJWT, usermodel.AuditFilter{UserID: customer.ID, Limit: 2})
+	if len(events) != 2 || events[0].Action != usermodel.AuditUserView || events[0].ActorID != admin.ID ||
+		events[1].Action != usermodel.AuditProfileUpdate || events[1].ActorID != admin.ID ||
+		!slices.Equal(events[1].Changes, []usermodel.FieldChange{{Field: "first_name", Old: "", New: "Ivan"}}) {
+		t.Errorf("audit log has %+v", events)
+	}
+}
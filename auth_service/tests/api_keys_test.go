@@ -0,0 +1,56 @@
+package tests
+
+import (
+	usermodel "authservice/auth_storage/user_model"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAPIKeys(t *testing.T) {
+	sm := newTestEnv(t, withTokenUser).sm
+	customer, _ := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if _, _, err := sm.CreateAPIKey(customer.AccessToken, "partner", []string{"promos:write"}); !errors.Is(err, usermodel.ErrAPIKeysUnavailable) {
+		t.Errorf("customer created an API key: %v", err)
+	}
+
+	if _, err := sm.CreateUser("companyUser", "ValidPass123", "company@example.com", true, testClient); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	company, _ := sm.GetJWTByCredentials("companyUser", "ValidPass123", testClient)
+	if _, _, err := sm.CreateAPIKey(company.AccessToken, "partner", []string{"users:manage"}); !errors.Is(err, usermodel.ErrInvalidAPIKey) {
+		t.Errorf("API key got a scope outside of the allowed ones: %v", err)
+	}
+	apiKey, key, err := sm.CreateAPIKey(company.AccessToken, "partner", []string{"stats:read", "promos:write", "stats:read"})
+	if err != nil || !strings.HasPrefix(key, "lpk_") {
+		t.Fatalf("CreateAPIKey failed: %q, %v", key, err)
+	}
+	if strings.Contains(string(apiKey.KeyHash), key) || len(apiKey.Scopes) != 2 {
+		t.Errorf("unexpected API key %+v", apiKey)
+	}
+
+	authenticated, user, err := sm.AuthenticateAPIKey(key)
+	if err != nil || user.Login != "companyUser" || authenticated.ID != apiKey.ID {
+		t.Fatalf("AuthenticateAPIKey failed: %+v, %v", user, err)
+	}
+	if authenticated.LastUsedDate.IsZero() {
+		t.Errorf("last use of the API key is not recorded")
+	}
+	if _, user, err := sm.AuthenticateAPIKey(key + "x"); err != nil || user.Login != "" {
+		t.Errorf("AuthenticateAPIKey accepted a wrong key: %v", err)
+	}
+
+	apiKeys, ok, err := sm.ListAPIKeys(company.AccessToken)
+	if err != nil || !ok || len(apiKeys) != 1 {
+		t.Fatalf("ListAPIKeys returned %d keys: %v", len(apiKeys), err)
+	}
+	if _, err := sm.RevokeAPIKey(customer.AccessToken, apiKey.ID); !errors.Is(err, usermodel.ErrAPIKeyNotFound) {
+		t.Errorf("another user revoked the API key: %v", err)
+	}
+	if ok, err := sm.RevokeAPIKey(company.AccessToken, apiKey.ID); err != nil || !ok {
+		t.Fatalf("RevokeAPIKey failed: %v", err)
+	}
+	if _, user, err := sm.AuthenticateAPIKey(key); err != nil || user.Login != "" {
+		t.Errorf("revoked API key is still accepted: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	usermodel "authservice/auth_storage/user_model"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAuditLog(t *testing.T) {
+	env := newTestEnv(t)
+	sm := env.sm
+	startedAt := time.Now()
+	user, err := sm.CreateUser("auditUser", "ValidPass123", "audit@example.com", false, testClient)
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if _, err := sm.GetJWTByCredentials("auditUser", "WrongPass123", testClient); err != nil {
+		t.Fatalf("GetJWTByCredentials failed: %v", err)
+	}
+	tokens, err := sm.GetJWTByCredentials("auditUser", "ValidPass123", testClient)
+	if err != nil {
+		t.Fatalf("GetJWTByCredentials failed: %v", err)
+	}
+	if _, err := sm.UpdateUserByJWT(tokens.AccessToken, usermodel.User{FirstName: "Ivan"}, testClient); err != nil {
+		t.Fatalf("UpdateUserByJWT failed: %v", err)
+	}
+
+	events, ok, err := sm.ListAuditEvents(tokens.AccessToken, usermodel.AuditFilter{})
+	if err != nil || !ok {
+		t.Fatalf("ListAuditEvents failed: %v, %v", ok, err)
+	}
+	want := []struct {
+		action  usermodel.AuditAction
+		outcome usermodel.AuditOutcome
+	}{
+		{usermodel.AuditProfileUpdate, usermodel.AuditSuccess},
+		{usermodel.AuditTokenIssued, usermodel.AuditSuccess},
+		{usermodel.AuditLogin, usermodel.AuditSuccess},
+		{usermodel.AuditLogin, usermodel.AuditFailure},
+		{usermodel.AuditRegister, usermodel.AuditSuccess},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("ListAuditEvents returned %d events, want %d", len(events), len(want))
+	}
+	for i, event := range events {
+		if event.Action != want[i].action || event.Outcome != want[i].outcome || event.UserID != user.ID || event.IP != testClient.IP {
+			t.Errorf("event %d is %+v, want %s %s", i, event, want[i].action, want[i].outcome)
+		}
+	}
+	changes := events[0].Changes
+	if len(changes) != 1 || changes[0] != (usermodel.FieldChange{Field: "first_name", Old: "", New: "Ivan"}) {
+		t.Errorf("profile update recorded changes %+v", changes)
+	}
+	messages, err := env.storage.GetOutboxMessages(100)
+	if err != nil {
+		t.Fatalf("GetOutboxMessages failed: %v", err)
+	}
+	var mirrored []usermodel.AuditEvent
+	for _, message := range messages {
+		var event usermodel.AuditEvent
+		if message.Topic != usermodel.OutboxAuditTopic || json.Unmarshal(message.Value, &event) != nil {
+			continue
+		}
+		mirrored = append(mirrored, event)
+	}
+	if len(mirrored) != len(want) || mirrored[0].ID != events[len(events)-1].ID {
+		t.Errorf("%d events were mirrored to the outbox, want %d", len(mirrored), len(want))
+	}
+
+	if events, _, _ := sm.ListAuditEvents(tokens.AccessToken, usermodel.AuditFilter{From: time.Now()}); len(events) != 0 {
+		t.Errorf("time filter returned %d events", len(events))
+	}
+	if events, _, _ := sm.ListAuditEvents(tokens.AccessToken, usermodel.AuditFilter{From: startedAt, Limit: 2}); len(events) != 2 {
+		t.Errorf("limit returned %d events", len(events))
+	}
+	if _, _, err := sm.ListAuditEvents(tokens.AccessToken, usermodel.AuditFilter{UserID: uuid.New()}); !errors.Is(err, usermodel.ErrForbidden) {
+		t.Errorf("customer read the audit log of another user: %v", err)
+	}
+
+	env.setRole(t, env.createUser(t, "adminUser", "admin@example.com", false), usermodel.RolePlatformAdmin)
+	adminTokens, _ := sm.GetJWTByCredentials("adminUser", "ValidPass123", testClient)
+	events, _, err = sm.ListAuditEvents(adminTokens.AccessToken, usermodel.AuditFilter{UserID: user.ID})
+	if err != nil || len(events) != len(want) {
+		t.Errorf("admin got %d events of the user: %v", len(events), err)
+	}
+}
+
+func TestAuditUnknownLogin(t *testing.T) {
+	env := newTestEnv(t)
+	sm, storage := env.sm, env.storage
+	if _, err := sm.GetJWTByCredentials("missingUser", "ValidPass123", testClient); err != nil {
+		t.Fatalf("GetJWTByCredentials failed: %v", err)
+	}
+	events, err := storage.GetAuditEvents(usermodel.AuditFilter{Limit: 10})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("GetAuditEvents returned %d events: %v", len(events), err)
+	}
+	if event := events[0]; event.Login != "missingUser" || event.UserID != uuid.Nil || event.Outcome != usermodel.AuditFailure {
+		t.Errorf("failed login recorded as %+v", event)
+	}
+}
@@ -0,0 +1,141 @@
+package tests
+
+import (
+	authconfig "authservice/auth_config"
+	pgstorage "authservice/auth_storage/postgresql_storage"
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing config failed: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := authconfig.Load("")
+	if err != nil {
+		t.Fatalf("default config is invalid: %v", err)
+	}
+	if cfg.ListenAddress != ":8080" || cfg.Storage.Postgres.Host != "postgres" || cfg.Storage.Postgres.Port != 5432 {
+		t.Errorf("Load(\"\") = %+v", cfg)
+	}
+
+	path := writeConfigFile(t, `
+listen_address: ":9090"
+storage:
+  postgres:
+    host: db.staging
+    port: 6432
+    connect_timeout: 3s
+kafka:
+  brokers: [kafka-1:9092]
+mailer:
+  kind: smtp
+  smtp_host: smtp.staging
+  smtp_port: 587
+`)
+	t.Setenv("POSTGRES_PORT", "7432")
+	t.Setenv("KAFKA_BROKERS", "kafka-1:9092, kafka-2:9092")
+	t.Setenv("REQUIRE_COMPANY_TWO_FACTOR", "true")
+	cfg, err = authconfig.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.ListenAddress != ":9090" || cfg.Storage.Postgres.Host != "db.staging" || cfg.Mailer.SMTPHost != "smtp.staging" {
+		t.Errorf("file values are not applied: %+v", cfg)
+	}
+	if cfg.Storage.Postgres.Port != 7432 || !cfg.RequireCompanyTwoFactor ||
+		!slices.Equal(cfg.Kafka.Brokers, []string{"kafka-1:9092", "kafka-2:9092"}) {
+		t.Errorf("environment does not override the file: %+v", cfg)
+	}
+	if cfg.Storage.Postgres.ConnectTimeout != 3*time.Second || cfg.Mailer.From != "no-reply@loyalty.local" {
+		t.Errorf("defaults or durations are lost: %+v", cfg)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	if _, err := authconfig.Load(writeConfigFile(t, "listen_adress: \":9090\"\n")); err == nil {
+		t.Errorf("Load accepted an unknown key")
+	}
+	if _, err := authconfig.Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("Load accepted a missing file")
+	}
+
+	path := writeConfigFile(t, `
+storage:
+  backend: mysql
+mailer:
+  kind: smtp
+`)
+	t.Setenv("PUBLIC_URL", "localhost")
+	_, err := authconfig.Load(path)
+	for _, problem := range []string{"storage.backend", "mailer.smtp_host", "mailer.smtp_port", "public_url"} {
+		if err == nil || !strings.Contains(err.Error(), problem) {
+			t.Errorf("Load error %v does not mention %s", err, problem)
+		}
+	}
+
+	t.Setenv("PUBLIC_URL", "http://localhost:8081")
+	t.Setenv("POSTGRES_PORT", "five")
+	if _, err := authconfig.Load(""); err == nil || !strings.Contains(err.Error(), "POSTGRES_PORT") {
+		t.Errorf("Load error %v does not mention POSTGRES_PORT", err)
+	}
+}
+
+func TestPrintConfigRedactsSecrets(t *testing.T) {
+	t.Setenv("POSTGRES_PASSWORD", "pg-secret")
+	t.Setenv("SMTP_PASSWORD", "smtp-secret")
+	t.Setenv("SMS_SENDER", "http")
+	t.Setenv("SMS_GATEWAY_URL", "https://sms.example.com/send")
+	t.Setenv("SMS_GATEWAY_TOKEN", "sms-secret")
+	cfg, err := authconfig.Load("")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	var out bytes.Buffer
+	if err := authconfig.Print(&out, cfg); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	if strings.Contains(out.String(), "-secret") || strings.Count(out.String(), "REDACTED") != 3 {
+		t.Errorf("Print wrote\n%s", out.String())
+	}
+	if cfg.Storage.Postgres.Password != "pg-secret" {
+		t.Errorf("Print redacted the loaded config")
+	}
+
+	// The printed config loads back, apart from the redacted secrets.
+	os.Unsetenv("POSTGRES_PASSWORD")
+	os.Unsetenv("SMTP_PASSWORD")
+	os.Unsetenv("SMS_GATEWAY_TOKEN")
+	printed, err := authconfig.Load(writeConfigFile(t, out.String()))
+	if err != nil || printed.SMS.GatewayURL != cfg.SMS.GatewayURL || printed.Storage.Postgres.ConnectTimeout != cfg.Storage.Postgres.ConnectTimeout {
+		t.Errorf("printed config loads as %+v, %v", printed, err)
+	}
+}
+
+func TestPostgresDSN(t *testing.T) {
+	credentials := filepath.Join(t.TempDir(), "postgresql.json")
+	if err := os.WriteFile(credentials, []byte(`{"user": "file-user", "password": "file-pass", "db_name": "auth"}`), 0o600); err != nil {
+		t.Fatalf("writing credentials failed: %v", err)
+	}
+	cfg := authconfig.Default().Storage.Postgres
+	cfg.CredentialsFile = credentials
+	cfg.User = "env-user"
+	dsn, err := pgstorage.DSN(cfg)
+	if err != nil || dsn != "host=postgres port=5432 user=env-user password=file-pass dbname=auth sslmode=disable connect_timeout=10" {
+		t.Errorf("DSN = %q, %v", dsn, err)
+	}
+	cfg.CredentialsFile = filepath.Join(t.TempDir(), "missing.json")
+	if _, err := pgstorage.DSN(cfg); err == nil {
+		t.Errorf("DSN ignored a missing credentials file")
+	}
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	usermodel "authservice/auth_storage/user_model"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func linkToken(t *testing.T, body, path string) string {
+	for _, line := range strings.Split(body, "\n") {
+		if !strings.HasPrefix(line, testPublicURL+path+"?") {
+			continue
+		}
+		link, err := url.Parse(line)
+		if err != nil {
+			t.Fatalf("invalid link %q: %v", line, err)
+		}
+		return link.Query().Get("token")
+	}
+	t.Fatalf("no %s link in %q", path, body)
+	return ""
+}
+
+func verificationToken(t *testing.T, body string) string {
+	return linkToken(t, body, "/api/v1/verify_email")
+}
+
+func TestEmailVerification(t *testing.T) {
+	env := newTestEnv(t, withTokenUser)
+	sm, mailer := env.sm, env.mailer
+
+	messages := mailer.Messages()
+	if len(messages) != 1 || messages[0].To != "token@example.com" {
+		t.Fatalf("expected one verification email, got %+v", messages)
+	}
+	token := verificationToken(t, messages[0].Body)
+
+	tokens, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if err != nil {
+		t.Fatalf("GetJWTByCredentials failed: %v", err)
+	}
+	if user, _ := sm.GetUserByJWT(tokens.AccessToken); user.EmailVerified {
+		t.Fatalf("new user is verified before confirming the email")
+	}
+	if user, err := sm.GetUserByJWT(token); err != nil || user.Login != "" {
+		t.Errorf("verification token accepted as access token")
+	}
+
+	user, err := sm.VerifyEmail(token)
+	if err != nil || !user.EmailVerified {
+		t.Fatalf("VerifyEmail failed: %+v, %v", user, err)
+	}
+	if user, err := sm.VerifyEmail(token); err != nil || user.Login != "" {
+		t.Errorf("verification token accepted twice")
+	}
+	if user, err := sm.ResendVerification(tokens.AccessToken); err != nil || !user.EmailVerified {
+		t.Errorf("ResendVerification for verified user: %+v, %v", user, err)
+	}
+	if len(mailer.Messages()) != 1 {
+		t.Errorf("verified user got another verification email")
+	}
+}
+
+func TestEmailChangeRequiresVerification(t *testing.T) {
+	env := newTestEnv(t, withTokenUser)
+	sm, mailer := env.sm, env.mailer
+	staleToken := verificationToken(t, mailer.Messages()[0].Body)
+
+	tokens, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if err != nil {
+		t.Fatalf("GetJWTByCredentials failed: %v", err)
+	}
+	user, err := sm.UpdateUserByJWT(tokens.AccessToken, usermodel.User{Email: "changed@example.com"}, testClient)
+	if err != nil || user.EmailVerified {
+		t.Fatalf("UpdateUserByJWT failed: %+v, %v", user, err)
+	}
+	if user, err := sm.VerifyEmail(staleToken); err != nil || user.Login != "" {
+		t.Errorf("token for the previous email verified the new one")
+	}
+
+	if _, err := sm.ResendVerification(tokens.AccessToken); err != nil {
+		t.Fatalf("ResendVerification failed: %v", err)
+	}
+	messages := mailer.Messages()
+	if len(messages) != 3 || messages[2].To != "changed@example.com" {
+		t.Fatalf("unexpected emails: %+v", messages)
+	}
+	if user, err := sm.VerifyEmail(verificationToken(t, messages[2].Body)); err != nil || !user.EmailVerified {
+		t.Errorf("VerifyEmail for the new email failed: %+v, %v", user, err)
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	usermodel "authservice/auth_storage/user_model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestIntrospectToken(t *testing.T) {
+	env := newTestEnv(t, withTokenUser)
+	sm, tokens := env.sm, env.login(t, "tokenUser")
+	user, _ := sm.GetUserByJWT(tokens.AccessToken)
+
+	result, err := sm.IntrospectToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("IntrospectToken failed: %v", err)
+	}
+	if !result.Active || result.Revoked || result.UserID != user.ID || result.Role != usermodel.RoleCustomer ||
+		result.IsCompany || result.TokenID == uuid.Nil || result.SessionID == uuid.Nil {
+		t.Errorf("IntrospectToken = %+v; want an active token of %v", result, user.ID)
+	}
+	if result.ExpiresAt.Unix() != tokens.AccessExpiresAt.Unix() {
+		t.Errorf("ExpiresAt = %v; want %v", result.ExpiresAt, tokens.AccessExpiresAt)
+	}
+	if result.CacheTTL <= 0 || result.CacheTTL > time.Second*10 {
+		t.Errorf("CacheTTL = %v; want up to 10s", result.CacheTTL)
+	}
+
+	for _, jwt := range []string{"not-a-jwt", tokens.AccessToken + "x", tokens.RefreshToken} {
+		if result, err := sm.IntrospectToken(jwt); err != nil || result.Active || result.UserID != uuid.Nil {
+			t.Errorf("IntrospectToken(%q) = %+v, %v; want an empty answer", jwt, result, err)
+		}
+	}
+}
+
+// TestIntrospectTokenIsCached suspends the user behind the back of the
+// service, which only shows once the cached answer expires.
+func TestIntrospectTokenIsCached(t *testing.T) {
+	env := newTestEnv(t, withTokenUser)
+	tokens := env.login(t, "tokenUser")
+	if result, err := env.sm.IntrospectToken(tokens.AccessToken); err != nil || !result.Active {
+		t.Fatalf("IntrospectToken = %+v, %v", result, err)
+	}
+	user := env.user(t, "tokenUser")
+	user.Suspended = true
+	if err := env.storage.UpdateUser(user); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	if result, err := env.sm.IntrospectToken(tokens.AccessToken); err != nil || !result.Active || result.Suspended {
+		t.Errorf("IntrospectToken = %+v, %v; want the cached answer", result, err)
+	}
+}
+
+// TestIntrospectTokenSeesRevocation checks that revocations made through the
+// service drop the cached answer at once.
+func TestIntrospectTokenSeesRevocation(t *testing.T) {
+	revocations := []struct {
+		name   string
+		revoke func(sm usermodel.StorageManager, tokens usermodel.TokenPair) error
+	}{
+		{"Logout", func(sm usermodel.StorageManager, tokens usermodel.TokenPair) error {
+			_, err := sm.Logout(tokens.AccessToken, tokens.RefreshToken)
+			return err
+		}},
+		{"ChangePassword", func(sm usermodel.StorageManager, tokens usermodel.TokenPair) error {
+			_, err := sm.ChangePassword(tokens.AccessToken, "ValidPass123", "NewValidPass456")
+			return err
+		}},
+		{"RevokeSession", func(sm usermodel.StorageManager, tokens usermodel.TokenPair) error {
+			sessions, _, err := sm.ListSessions(tokens.AccessToken)
+			if err != nil || len(sessions) != 1 {
+				t.Fatalf("ListSessions = %d sessions, %v", len(sessions), err)
+			}
+			_, err = sm.RevokeSession(tokens.AccessToken, sessions[0].ID)
+			return err
+		}},
+		{"DeleteAccount", func(sm usermodel.StorageManager, tokens usermodel.TokenPair) error {
+			_, _, err := sm.DeleteAccount(tokens.AccessToken, "ValidPass123")
+			return err
+		}},
+	}
+	for _, test := range revocations {
+		t.Run(test.name, func(t *testing.T) {
+			env := newTestEnv(t, withTokenUser)
+			sm, tokens := env.sm, env.login(t, "tokenUser")
+			if result, _ := sm.IntrospectToken(tokens.AccessToken); !result.Active {
+				t.Fatalf("token is not active before revoking")
+			}
+			if err := test.revoke(sm, tokens); err != nil {
+				t.Fatalf("revoking failed: %v", err)
+			}
+			result, err := sm.IntrospectToken(tokens.AccessToken)
+			if err != nil || result.Active || !result.Revoked || result.UserID == uuid.Nil {
+				t.Errorf("IntrospectToken after revoking = %+v, %v; want a revoked token", result, err)
+			}
+		})
+	}
+}
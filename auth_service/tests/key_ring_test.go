@@ -0,0 +1,130 @@
+package tests
+
+import (
+	userkeys "authservice/auth_storage/user_keys"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func writeKeyPair(t *testing.T, kid string, withPrivate bool) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey failed: %v", err)
+	}
+	publicPem := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public})
+	if err := os.WriteFile(filepath.Join(userkeys.JwtKeyDir, kid+".public.pem"), publicPem, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if withPrivate {
+		privatePem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+		if err := os.WriteFile(filepath.Join(userkeys.JwtKeyDir, kid+".private.pem"), privatePem, 0600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	return key
+}
+
+func jwtKid(t *testing.T, token string) string {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	if err != nil {
+		t.Fatalf("failed to decode JWT header: %v", err)
+	}
+	var header struct {
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		t.Fatalf("failed to decode JWT header: %v", err)
+	}
+	return header.Kid
+}
+
+func TestKeyRotation(t *testing.T) {
+	if err := os.MkdirAll(userkeys.JwtKeyDir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(userkeys.JwtKeyDir)
+		if err := userkeys.ReloadKeys(); err != nil {
+			t.Fatalf("failed to restore legacy keys: %v", err)
+		}
+	}()
+
+	writeKeyPair(t, "2024-01", true)
+	if err := userkeys.ReloadKeys(); err != nil {
+		t.Fatalf("ReloadKeys failed: %v", err)
+	}
+	userId := uuid.New()
+	oldToken := userkeys.GenJWT(userId)
+	if kid := jwtKid(t, oldToken); kid != "2024-01" {
+		t.Errorf("token signed with kid %q, want 2024-01", kid)
+	}
+
+	writeKeyPair(t, "2024-02", true)
+	if err := userkeys.ReloadKeys(); err != nil {
+		t.Fatalf("ReloadKeys failed: %v", err)
+	}
+	newToken := userkeys.GenJWT(userId)
+	if kid := jwtKid(t, newToken); kid != "2024-02" {
+		t.Errorf("token signed with kid %q, want 2024-02", kid)
+	}
+	for _, token := range []string{oldToken, newToken} {
+		if parsed, ok := userkeys.GetUserIdByJWT(token); !ok || parsed != userId {
+			t.Errorf("token with kid %q rejected after rotation", jwtKid(t, token))
+		}
+	}
+	if keys := userkeys.GetJWKS(); len(keys) != 2 || keys[0].Kid != "2024-01" || keys[1].Kid != "2024-02" {
+		t.Errorf("unexpected JWKS: %+v", keys)
+	}
+
+	if err := os.WriteFile(filepath.Join(userkeys.JwtKeyDir, userkeys.JwtActiveKeyFile), []byte("2024-01\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := userkeys.ReloadKeys(); err != nil {
+		t.Fatalf("ReloadKeys failed: %v", err)
+	}
+	if kid := jwtKid(t, userkeys.GenJWT(userId)); kid != "2024-01" {
+		t.Errorf("active file ignored, token signed with kid %q", kid)
+	}
+
+	_ = os.Remove(filepath.Join(userkeys.JwtKeyDir, "2024-02.private.pem"))
+	_ = os.Remove(filepath.Join(userkeys.JwtKeyDir, "2024-02.public.pem"))
+	if err := userkeys.ReloadKeys(); err != nil {
+		t.Fatalf("ReloadKeys failed: %v", err)
+	}
+	if _, ok := userkeys.GetUserIdByJWT(newToken); ok {
+		t.Errorf("token signed with a removed key is still accepted")
+	}
+}
+
+func TestReloadKeysKeepsRingOnError(t *testing.T) {
+	if err := os.MkdirAll(userkeys.JwtKeyDir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(userkeys.JwtKeyDir)
+		_ = userkeys.ReloadKeys()
+	}()
+
+	token := userkeys.GenJWT(uuid.New())
+	writeKeyPair(t, "verify-only", false)
+	if err := userkeys.ReloadKeys(); err == nil || !strings.Contains(err.Error(), "no private key") {
+		t.Fatalf("ReloadKeys accepted a ring without a signing key: %v", err)
+	}
+	if _, ok := userkeys.GetUserIdByJWT(token); !ok {
+		t.Errorf("failed reload dropped the previous keys")
+	}
+}
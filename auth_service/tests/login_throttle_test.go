@@ -0,0 +1,133 @@
+package tests
+
+import (
+	loginthrottle "authservice/auth_storage/login_throttle"
+	usermodel "authservice/auth_storage/user_model"
+	"errors"
+	"testing"
+	"time"
+)
+
+var testThrottlePolicy = loginthrottle.Policy{
+	LoginFreeAttempts: 3,
+	IPFreeAttempts:    6,
+	BaseLockout:       time.Minute,
+	MaxLockout:        time.Hour,
+	ResetAfter:        24 * time.Hour,
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Now()
+	attempts := usermodel.LoginAttempts{Key: loginthrottle.LoginKey("tokenUser"), Failures: 2, LastFailure: now}
+	if retryAfter := testThrottlePolicy.RetryAfter(attempts, 3, now); retryAfter != 0 {
+		t.Errorf("locked out before the free attempts were used: %v", retryAfter)
+	}
+	attempts.Failures = 3
+	if retryAfter := testThrottlePolicy.RetryAfter(attempts, 3, now); retryAfter != time.Minute {
+		t.Errorf("expected a minute lockout, got %v", retryAfter)
+	}
+	attempts.Failures = 5
+	if retryAfter := testThrottlePolicy.RetryAfter(attempts, 3, now.Add(time.Minute)); retryAfter != 3*time.Minute {
+		t.Errorf("lockout does not double, got %v", retryAfter)
+	}
+	attempts.Failures = 100
+	if retryAfter := testThrottlePolicy.RetryAfter(attempts, 3, now); retryAfter != time.Hour {
+		t.Errorf("lockout is not capped, got %v", retryAfter)
+	}
+	if retryAfter := testThrottlePolicy.RetryAfter(attempts, 3, now.Add(25*time.Hour)); retryAfter != 0 {
+		t.Errorf("old failures are not forgotten: %v", retryAfter)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	sm := newTestEnv(t, withThrottlePolicy(testThrottlePolicy), withUser("firstUser", "firstUser@example.com", false),
+		withUser("secondUser", "secondUser@example.com", false)).sm
+
+	for i := 0; i < 2; i++ {
+		if _, err := sm.GetJWTByCredentials("firstUser", "WrongPass123", testClient); err != nil {
+			t.Fatalf("failed attempt %d was rejected with %v", i, err)
+		}
+	}
+	if tokens, err := sm.GetJWTByCredentials("firstUser", "ValidPass123", testClient); err != nil || tokens.AccessToken == "" {
+		t.Fatalf("login failed before the lockout: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		sm.GetJWTByCredentials("firstUser", "WrongPass123", testClient)
+	}
+	_, err := sm.GetJWTByCredentials("firstUser", "ValidPass123", testClient)
+	var lockedErr *usermodel.LoginLockedError
+	if !errors.As(err, &lockedErr) || !errors.Is(err, usermodel.ErrTooManyLoginAttempts) {
+		t.Fatalf("correct password accepted during lockout: %v", err)
+	}
+	if lockedErr.RetryAfter <= 0 || lockedErr.RetryAfter > time.Minute {
+		t.Errorf("unexpected retry-after %v", lockedErr.RetryAfter)
+	}
+	if tokens, err := sm.GetJWTByCredentials("secondUser", "ValidPass123", testClient); err != nil || tokens.AccessToken == "" {
+		t.Errorf("lockout of one login affects another: %v", err)
+	}
+
+	if _, err := sm.GetJWTByCredentials("secondUser", "WrongPass123", testClient); err != nil {
+		t.Fatalf("failed attempt was rejected with %v", err)
+	}
+	if _, err := sm.GetJWTByCredentials("secondUser", "ValidPass123", testClient); !errors.Is(err, usermodel.ErrTooManyLoginAttempts) {
+		t.Errorf("client IP is not locked out after spraying logins: %v", err)
+	}
+	if tokens, err := sm.GetJWTByCredentials("secondUser", "ValidPass123", usermodel.ClientInfo{IP: "198.51.100.7"}); err != nil || tokens.AccessToken == "" {
+		t.Errorf("IP lockout affects another address: %v", err)
+	}
+}
+
+func TestTwoFactorLockout(t *testing.T) {
+	env := newTestEnv(t, withThrottlePolicy(testThrottlePolicy), withCompanyUser)
+	secret, _, _ := enableTwoFactor(t, env.sm, env.login(t, "companyUser").AccessToken, time.Now())
+
+	challenge := func() string {
+		tokens, err := env.sm.GetJWTByCredentials("companyUser", testPassword, testClient)
+		if err != nil || tokens.TwoFactorChallenge == "" {
+			t.Fatalf("login with 2FA did not return a challenge: %+v, %v", tokens, err)
+		}
+		return tokens.TwoFactorChallenge
+	}
+	for i := 0; i < 3; i++ {
+		if tokens, err := env.sm.LoginTwoFactor(challenge(), "000000", testClient); err != nil || tokens.AccessToken != "" {
+			t.Fatalf("wrong code %d = %+v, %v", i, tokens, err)
+		}
+	}
+	_, err := env.sm.LoginTwoFactor(challenge(), totpCode(t, secret, time.Now().Add(30*time.Second)), testClient)
+	if !errors.Is(err, usermodel.ErrTooManyLoginAttempts) {
+		t.Errorf("correct code accepted during lockout: %v", err)
+	}
+}
+
+// TestTwoFactorSettingsLockout guesses codes with a signed in session, which
+// counts towards the same lockout as LoginTwoFactor.
+func TestTwoFactorSettingsLockout(t *testing.T) {
+	env := newTestEnv(t, withThrottlePolicy(testThrottlePolicy), withCompanyUser)
+	secret, _, tokens := enableTwoFactor(t, env.sm, env.login(t, "companyUser").AccessToken, time.Now())
+
+	for i := 0; i < 3; i++ {
+		if _, err := env.sm.DisableTwoFactor(tokens.AccessToken, "000000", testClient); !errors.Is(err, usermodel.ErrWrongTwoFactorCode) {
+			t.Fatalf("wrong code %d = %v", i, err)
+		}
+	}
+	var lockedErr *usermodel.LoginLockedError
+	_, err := env.sm.DisableTwoFactor(tokens.AccessToken, totpCode(t, secret, time.Now().Add(30*time.Second)), testClient)
+	if !errors.As(err, &lockedErr) {
+		t.Errorf("correct code accepted during lockout: %v", err)
+	}
+	challenge, _ := env.sm.GetJWTByCredentials("companyUser", testPassword, testClient)
+	if _, err := env.sm.LoginTwoFactor(challenge.TwoFactorChallenge, "000000", testClient); !errors.As(err, &lockedErr) {
+		t.Errorf("lockout does not apply to logins: %v", err)
+	}
+
+	events, _, _ := env.sm.ListAuditEvents(tokens.AccessToken, usermodel.AuditFilter{})
+	failures := 0
+	for _, event := range events {
+		if event.Action == usermodel.AuditTwoFactorOff && event.Outcome == usermodel.AuditFailure {
+			failures++
+		}
+	}
+	if failures != 3 {
+		t.Errorf("audit log has %d failed attempts to disable 2FA; want 3", failures)
+	}
+}
@@ -0,0 +1,167 @@
+package tests
+
+import (
+	pgstorage "authservice/auth_storage/postgresql_storage"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := pgstorage.LoadMigrations()
+	if err != nil {
+		t.Fatalf("LoadMigrations failed: %v", err)
+	}
+	for i, migration := range migrations {
+		if migration.Version != i+1 || migration.Up == "" || migration.Down == "" {
+			t.Errorf("migration %d is %d_%s", i+1, migration.Version, migration.Name)
+		}
+	}
+}
+
+// TestMigrationsCoverModels guards against model fields added without a
+// migration creating their column.
+func TestMigrationsCoverModels(t *testing.T) {
+	migrations, err := pgstorage.LoadMigrations()
+	if err != nil {
+		t.Fatalf("LoadMigrations failed: %v", err)
+	}
+	var upSQL strings.Builder
+	for _, migration := range migrations {
+		upSQL.WriteString(migration.Up)
+	}
+	models := []interface{}{
+		&pgstorage.UserInfo{}, &pgstorage.UserCredentials{}, &pgstorage.Session{}, &pgstorage.RefreshToken{},
+		&pgstorage.RevokedToken{}, &pgstorage.TwoFactor{}, &pgstorage.LoginAttempt{}, &pgstorage.APIKey{},
+		&pgstorage.AuditEvent{}, &pgstorage.PhoneVerification{}, &pgstorage.Organisation{},
+		&pgstorage.OrganisationMember{}, &pgstorage.OrganisationInvitation{}, &pgstorage.OutboxMessage{},
+	}
+	for _, model := range models {
+		parsed, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{SingularTable: true})
+		if err != nil {
+			t.Fatalf("parsing %T failed: %v", model, err)
+		}
+		if !strings.Contains(upSQL.String(), "CREATE TABLE IF NOT EXISTS "+parsed.Table+" (") {
+			t.Errorf("no migration creates table %s", parsed.Table)
+		}
+		for _, field := range parsed.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if !regexp.MustCompile(`(?m)(^\s+|ADD COLUMN IF NOT EXISTS )` + field.DBName + ` `).MatchString(upSQL.String()) {
+				t.Errorf("no migration creates column %s.%s", parsed.Table, field.DBName)
+			}
+		}
+	}
+}
+
+var (
+	createdTable    = regexp.MustCompile(`CREATE TABLE IF NOT EXISTS (\w+)`)
+	createdIndex    = regexp.MustCompile(`CREATE (?:UNIQUE )?INDEX IF NOT EXISTS (\w+) ON (\w+)`)
+	droppedIndex    = regexp.MustCompile(`DROP INDEX IF EXISTS (\w+)`)
+	addedColumn     = regexp.MustCompile(`ADD COLUMN IF NOT EXISTS (\w+)`)
+	droppedColumn   = regexp.MustCompile(`DROP COLUMN IF EXISTS (\w+)`)
+	createdFunction = regexp.MustCompile(`CREATE OR REPLACE FUNCTION (\w+)`)
+)
+
+// TestDownMigrationsRevertUp checks without a database that every down
+// migration drops what its up migration creates and restores what it drops.
+// TestPGMigrationsRoundTrip runs them for real.
+func TestDownMigrationsRevertUp(t *testing.T) {
+	migrations, err := pgstorage.LoadMigrations()
+	if err != nil {
+		t.Fatalf("LoadMigrations failed: %v", err)
+	}
+	for _, migration := range migrations {
+		name := fmt.Sprintf("%04d_%s", migration.Version, migration.Name)
+		want := func(statement string) {
+			if !strings.Contains(migration.Down, statement) {
+				t.Errorf("%s.down.sql does not %s", name, statement)
+			}
+		}
+		tables := make(map[string]bool)
+		for _, match := range createdTable.FindAllStringSubmatch(migration.Up, -1) {
+			tables[match[1]] = true
+			want("DROP TABLE IF EXISTS " + match[1])
+		}
+		for _, match := range createdIndex.FindAllStringSubmatch(migration.Up, -1) {
+			if !tables[match[2]] {
+				want("DROP INDEX IF EXISTS " + match[1])
+			}
+		}
+		for _, match := range droppedIndex.FindAllStringSubmatch(migration.Up, -1) {
+			if !regexp.MustCompile(`CREATE (UNIQUE )?INDEX IF NOT EXISTS ` + match[1] + ` `).MatchString(migration.Down) {
+				t.Errorf("%s.down.sql does not recreate index %s", name, match[1])
+			}
+		}
+		for _, match := range addedColumn.FindAllStringSubmatch(migration.Up, -1) {
+			want("DROP COLUMN IF EXISTS " + match[1])
+		}
+		for _, match := range droppedColumn.FindAllStringSubmatch(migration.Up, -1) {
+			want("ADD COLUMN IF NOT EXISTS " + match[1])
+		}
+		for _, match := range createdFunction.FindAllStringSubmatch(migration.Up, -1) {
+			want("DROP FUNCTION IF EXISTS " + match[1])
+		}
+	}
+}
+
+// TestPGMigrationsRoundTrip migrates the database in AUTH_TEST_POSTGRES_DSN
+// all the way down and up again, which wipes it.
+func TestPGMigrationsRoundTrip(t *testing.T) {
+	dsn := os.Getenv("AUTH_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("AUTH_TEST_POSTGRES_DSN is not set")
+	}
+	migrations, err := pgstorage.LoadMigrations()
+	if err != nil {
+		t.Fatalf("LoadMigrations failed: %v", err)
+	}
+	db, err := pgstorage.OpenDSN(dsn)
+	if err != nil {
+		t.Fatalf("OpenDSN failed: %v", err)
+	}
+	migrate := func(target int) {
+		t.Helper()
+		if err := pgstorage.Migrate(db, target); err != nil {
+			t.Fatalf("Migrate(%d) failed: %v", target, err)
+		}
+		if version, err := pgstorage.SchemaVersion(db); err != nil || version != target {
+			t.Fatalf("SchemaVersion after Migrate(%d) = %d, %v", target, version, err)
+		}
+	}
+
+	migrate(len(migrations))
+	if err := pgstorage.CheckSchemaVersion(db); err != nil {
+		t.Errorf("CheckSchemaVersion of a migrated database: %v", err)
+	}
+	migrate(0)
+	var tables []string
+	err = db.Raw("SELECT tablename FROM pg_tables WHERE schemaname = current_schema() AND tablename <> 'schema_version'").
+		Scan(&tables).Error
+	if err != nil || len(tables) != 0 {
+		t.Errorf("tables left after migrating down: %v, %v", tables, err)
+	}
+	if err := pgstorage.CheckSchemaVersion(db); !errors.Is(err, pgstorage.ErrUnexpectedSchemaVersion) {
+		t.Errorf("CheckSchemaVersion of an empty database = %v", err)
+	}
+
+	if err := db.Exec("DROP TABLE schema_version").Error; err != nil {
+		t.Fatalf("dropping schema_version failed: %v", err)
+	}
+	if version, err := pgstorage.SchemaVersion(db); err != nil || version != 0 {
+		t.Errorf("SchemaVersion without the version table = %d, %v", version, err)
+	}
+	if db.Migrator().HasTable("schema_version") {
+		t.Errorf("SchemaVersion created the version table")
+	}
+	for version := 1; version <= len(migrations); version++ {
+		migrate(version)
+	}
+}
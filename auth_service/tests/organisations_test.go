@@ -0,0 +1,268 @@
+package tests
+
+import (
+	authmailer "authservice/auth_mailer"
+	userkeys "authservice/auth_storage/user_keys"
+	usermodel "authservice/auth_storage/user_model"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func invitationToken(t *testing.T, mailer *authmailer.MemoryMailer, to string) string {
+	messages := mailer.Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].To == to {
+			return linkToken(t, messages[i].Body, "/accept_invitation")
+		}
+	}
+	t.Fatalf("no invitation sent to %s", to)
+	return ""
+}
+
+// joinOrganisation registers a customer and makes it accept an invitation
+// with the given role.
+func joinOrganisation(t *testing.T, sm usermodel.StorageManager, mailer *authmailer.MemoryMailer, ownerJWT, login string,
+	role usermodel.OrganisationRole) (usermodel.User, usermodel.TokenPair) {
+	email := login + "@example.com"
+	user, err := sm.CreateUser(login, "ValidPass123", email, false, testClient)
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if _, err := sm.InviteMember(ownerJWT, email, role); err != nil {
+		t.Fatalf("InviteMember failed: %v", err)
+	}
+	tokens, _ := sm.GetJWTByCredentials(login, "ValidPass123", testClient)
+	tokens, err = sm.AcceptInvitation(tokens.AccessToken, invitationToken(t, mailer, email), testClient)
+	if err != nil || tokens.AccessToken == "" {
+		t.Fatalf("AcceptInvitation failed: %+v, %v", tokens, err)
+	}
+	return user, tokens
+}
+
+func TestCompanyRegistrationCreatesOrganisation(t *testing.T) {
+	env := newTestEnv(t, withTokenUser, withUser("acmeCorp", "owner@acme.example", true))
+	sm, tokens := env.sm, env.login(t, "acmeCorp")
+	owner, err := sm.GetUserByJWT(tokens.AccessToken)
+	if err != nil || owner.OrganisationID == uuid.Nil {
+		t.Fatalf("GetUserByJWT = %+v, %v; want an organisation", owner, err)
+	}
+	if claims, ok := userkeys.ParseJWT(tokens.AccessToken); !ok || claims.OrganisationID != owner.OrganisationID {
+		t.Errorf("JWT carries organisation %v; want %v", claims.OrganisationID, owner.OrganisationID)
+	}
+
+	organisation, err := sm.GetOrganisation(tokens.AccessToken)
+	if err != nil || organisation.ID != owner.OrganisationID || organisation.Name != "acmeCorp" {
+		t.Fatalf("GetOrganisation = %+v, %v", organisation, err)
+	}
+	if len(organisation.Members) != 1 || organisation.Members[0].UserID != owner.ID ||
+		organisation.Members[0].Role != usermodel.OrganisationRoleOwner || organisation.Members[0].Login != "acmeCorp" {
+		t.Errorf("members = %+v; want the owner only", organisation.Members)
+	}
+
+	customerTokens, _ := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if _, err := sm.GetOrganisation(customerTokens.AccessToken); !errors.Is(err, usermodel.ErrNotInOrganisation) {
+		t.Errorf("GetOrganisation of a customer: %v; want ErrNotInOrganisation", err)
+	}
+	if claims, _ := userkeys.ParseJWT(customerTokens.AccessToken); claims.OrganisationID != uuid.Nil {
+		t.Errorf("JWT of a customer carries organisation %v", claims.OrganisationID)
+	}
+
+	if organisation, err := sm.RenameOrganisation(tokens.AccessToken, "  Acme Corp "); err != nil || organisation.Name != "Acme Corp" {
+		t.Errorf("RenameOrganisation = %+v, %v", organisation, err)
+	}
+	if _, err := sm.RenameOrganisation(tokens.AccessToken, " "); !errors.Is(err, usermodel.ErrInvalidOrganisationName) {
+		t.Errorf("RenameOrganisation accepted a blank name: %v", err)
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	env := newTestEnv(t, withTokenUser, withUser("acmeCorp", "owner@acme.example", true))
+	sm, mailer, ownerTokens := env.sm, env.mailer, env.login(t, "acmeCorp")
+	if _, err := sm.InviteMember(ownerTokens.AccessToken, "token@example.com", "owner"); err == nil {
+		t.Errorf("InviteMember accepted the owner role")
+	}
+	invitation, err := sm.InviteMember(ownerTokens.AccessToken, "token@example.com", usermodel.OrganisationRoleMember)
+	if err != nil || invitation.ID == uuid.Nil {
+		t.Fatalf("InviteMember failed: %+v, %v", invitation, err)
+	}
+	token := invitationToken(t, mailer, "token@example.com")
+
+	if _, err := sm.CreateUser("otherUser", "ValidPass123", "other@example.com", false, testClient); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	otherTokens, _ := sm.GetJWTByCredentials("otherUser", "ValidPass123", testClient)
+	if _, err := sm.AcceptInvitation(otherTokens.AccessToken, token, testClient); !errors.Is(err, usermodel.ErrInvitationForOtherEmail) {
+		t.Errorf("invitation accepted by another email: %v", err)
+	}
+	if _, err := sm.AcceptInvitation(otherTokens.AccessToken, "unknown", testClient); !errors.Is(err, usermodel.ErrInvitationNotFound) {
+		t.Errorf("AcceptInvitation with an unknown token: %v", err)
+	}
+
+	customerTokens, _ := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	tokens, err := sm.AcceptInvitation(customerTokens.AccessToken, token, testClient)
+	if err != nil || tokens.AccessToken == "" {
+		t.Fatalf("AcceptInvitation failed: %+v, %v", tokens, err)
+	}
+	if user, _ := sm.GetUserByJWT(customerTokens.AccessToken); user.Login != "" {
+		t.Errorf("token issued before joining is still accepted")
+	}
+	member, err := sm.GetUserByJWT(tokens.AccessToken)
+	if err != nil || !member.IsCompany || member.Role != usermodel.RoleCompanyMember || member.OrganisationID == uuid.Nil {
+		t.Errorf("member after joining = %+v, %v", member, err)
+	}
+	if claims, _ := userkeys.ParseJWT(tokens.AccessToken); claims.OrganisationID != member.OrganisationID {
+		t.Errorf("JWT carries organisation %v; want %v", claims.OrganisationID, member.OrganisationID)
+	}
+	if _, err := sm.AcceptInvitation(tokens.AccessToken, token, testClient); !errors.Is(err, usermodel.ErrInvitationNotFound) {
+		t.Errorf("invitation accepted twice: %v", err)
+	}
+
+	organisation, err := sm.GetOrganisation(tokens.AccessToken)
+	if err != nil || len(organisation.Members) != 2 {
+		t.Fatalf("GetOrganisation = %+v, %v; want 2 members", organisation, err)
+	}
+	if organisation.Invitations != nil {
+		t.Errorf("members see invitations: %+v", organisation.Invitations)
+	}
+}
+
+func TestCancelInvitation(t *testing.T) {
+	env := newTestEnv(t, withTokenUser, withUser("acmeCorp", "owner@acme.example", true))
+	sm, mailer, ownerTokens := env.sm, env.mailer, env.login(t, "acmeCorp")
+	if _, err := sm.InviteMember(ownerTokens.AccessToken, "token@example.com", usermodel.OrganisationRoleMember); err != nil {
+		t.Fatalf("InviteMember failed: %v", err)
+	}
+	staleToken := invitationToken(t, mailer, "token@example.com")
+	invitation, err := sm.InviteMember(ownerTokens.AccessToken, "Token@example.com", usermodel.OrganisationRoleAdmin)
+	if err != nil {
+		t.Fatalf("InviteMember failed: %v", err)
+	}
+	organisation, _ := sm.GetOrganisation(ownerTokens.AccessToken)
+	if len(organisation.Invitations) != 1 || organisation.Invitations[0].ID != invitation.ID {
+		t.Fatalf("invitations = %+v; want only the latest one", organisation.Invitations)
+	}
+
+	customerTokens, _ := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if _, err := sm.AcceptInvitation(customerTokens.AccessToken, staleToken, testClient); !errors.Is(err, usermodel.ErrInvitationNotFound) {
+		t.Errorf("replaced invitation accepted: %v", err)
+	}
+	if ok, err := sm.CancelInvitation(ownerTokens.AccessToken, invitation.ID); err != nil || !ok {
+		t.Fatalf("CancelInvitation failed: %v, %v", ok, err)
+	}
+	if _, err := sm.CancelInvitation(ownerTokens.AccessToken, invitation.ID); !errors.Is(err, usermodel.ErrInvitationNotFound) {
+		t.Errorf("CancelInvitation of a cancelled invitation: %v", err)
+	}
+	token := invitationToken(t, mailer, "Token@example.com")
+	if _, err := sm.AcceptInvitation(customerTokens.AccessToken, token, testClient); !errors.Is(err, usermodel.ErrInvitationNotFound) {
+		t.Errorf("cancelled invitation accepted: %v", err)
+	}
+}
+
+func TestMemberRoles(t *testing.T) {
+	env := newTestEnv(t, withTokenUser, withUser("acmeCorp", "owner@acme.example", true))
+	sm, mailer, ownerTokens := env.sm, env.mailer, env.login(t, "acmeCorp")
+	admin, adminTokens := joinOrganisation(t, sm, mailer, ownerTokens.AccessToken, "adminUser", usermodel.OrganisationRoleAdmin)
+	member, memberTokens := joinOrganisation(t, sm, mailer, ownerTokens.AccessToken, "memberUser", usermodel.OrganisationRoleMember)
+	owner, _ := sm.GetUserByJWT(ownerTokens.AccessToken)
+
+	if _, err := sm.InviteMember(memberTokens.AccessToken, "new@example.com", usermodel.OrganisationRoleMember); !errors.Is(err, usermodel.ErrForbidden) {
+		t.Errorf("member invited: %v", err)
+	}
+	if _, err := sm.InviteMember(adminTokens.AccessToken, "new@example.com", usermodel.OrganisationRoleAdmin); !errors.Is(err, usermodel.ErrForbidden) {
+		t.Errorf("admin invited an admin: %v", err)
+	}
+	if _, err := sm.SetMemberRole(adminTokens.AccessToken, member.ID, usermodel.OrganisationRoleAdmin); !errors.Is(err, usermodel.ErrForbidden) {
+		t.Errorf("admin appointed an admin: %v", err)
+	}
+	if _, err := sm.SetMemberRole(adminTokens.AccessToken, owner.ID, usermodel.OrganisationRoleMember); !errors.Is(err, usermodel.ErrOrganisationOwner) {
+		t.Errorf("admin demoted the owner: %v", err)
+	}
+	if _, err := sm.SetMemberRole(ownerTokens.AccessToken, uuid.New(), usermodel.OrganisationRoleMember); !errors.Is(err, usermodel.ErrMemberNotFound) {
+		t.Errorf("SetMemberRole of a stranger: %v", err)
+	}
+	if _, err := sm.SetMemberRole(ownerTokens.AccessToken, member.ID, usermodel.OrganisationRoleOwner); !errors.Is(err, usermodel.ErrInvalidMemberRole) {
+		t.Errorf("SetMemberRole made an owner: %v", err)
+	}
+
+	updated, err := sm.SetMemberRole(ownerTokens.AccessToken, admin.ID, usermodel.OrganisationRoleMember)
+	if err != nil || updated.Role != usermodel.OrganisationRoleMember || updated.Login != "adminUser" {
+		t.Fatalf("SetMemberRole = %+v, %v", updated, err)
+	}
+	tokens, _ := sm.GetJWTByCredentials("adminUser", "ValidPass123", testClient)
+	if user, _ := sm.GetUserByJWT(tokens.AccessToken); user.Role != usermodel.RoleCompanyMember {
+		t.Errorf("demoted admin has role %q", user.Role)
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	env := newTestEnv(t, withTokenUser, withUser("acmeCorp", "owner@acme.example", true))
+	sm, mailer, ownerTokens := env.sm, env.mailer, env.login(t, "acmeCorp")
+	member, _ := joinOrganisation(t, sm, mailer, ownerTokens.AccessToken, "memberUser", usermodel.OrganisationRoleMember)
+	_, leaverTokens := joinOrganisation(t, sm, mailer, ownerTokens.AccessToken, "leaverUser", usermodel.OrganisationRoleMember)
+	owner, _ := sm.GetUserByJWT(ownerTokens.AccessToken)
+
+	if _, err := sm.RemoveMember(ownerTokens.AccessToken, owner.ID); !errors.Is(err, usermodel.ErrOrganisationOwner) {
+		t.Errorf("owner left the organisation: %v", err)
+	}
+	if ok, err := sm.RemoveMember(ownerTokens.AccessToken, member.ID); err != nil || !ok {
+		t.Fatalf("RemoveMember failed: %v, %v", ok, err)
+	}
+	tokens, _ := sm.GetJWTByCredentials("memberUser", "ValidPass123", testClient)
+	if user, _ := sm.GetUserByJWT(tokens.AccessToken); user.IsCompany || user.Role != usermodel.RoleCustomer || user.OrganisationID != uuid.Nil {
+		t.Errorf("removed member = %+v; want a customer", user)
+	}
+
+	leaver, _ := sm.GetUserByJWT(leaverTokens.AccessToken)
+	if ok, err := sm.RemoveMember(leaverTokens.AccessToken, leaver.ID); err != nil || !ok {
+		t.Fatalf("leaving failed: %v, %v", ok, err)
+	}
+	organisation, _ := sm.GetOrganisation(ownerTokens.AccessToken)
+	if len(organisation.Members) != 1 {
+		t.Errorf("members = %+v; want the owner only", organisation.Members)
+	}
+}
+
+func TestTransferOwnership(t *testing.T) {
+	env := newTestEnv(t, withTokenUser, withUser("acmeCorp", "owner@acme.example", true))
+	sm, mailer, ownerTokens := env.sm, env.mailer, env.login(t, "acmeCorp")
+	member, _ := joinOrganisation(t, sm, mailer, ownerTokens.AccessToken, "memberUser", usermodel.OrganisationRoleMember)
+	owner, _ := sm.GetUserByJWT(ownerTokens.AccessToken)
+
+	if _, _, err := sm.DeleteAccount(ownerTokens.AccessToken, "ValidPass123"); !errors.Is(err, usermodel.ErrOrganisationOwner) {
+		t.Fatalf("owner of a shared organisation deleted its account: %v", err)
+	}
+	organisation, err := sm.TransferOwnership(ownerTokens.AccessToken, member.ID)
+	if err != nil {
+		t.Fatalf("TransferOwnership failed: %v", err)
+	}
+	roles := map[uuid.UUID]usermodel.OrganisationRole{}
+	for _, m := range organisation.Members {
+		roles[m.UserID] = m.Role
+	}
+	if roles[member.ID] != usermodel.OrganisationRoleOwner || roles[owner.ID] != usermodel.OrganisationRoleAdmin {
+		t.Errorf("roles after transfer = %v", roles)
+	}
+	if _, err := sm.TransferOwnership(ownerTokens.AccessToken, owner.ID); !errors.Is(err, usermodel.ErrForbidden) {
+		t.Errorf("previous owner transferred again: %v", err)
+	}
+
+	if ok, deleted, err := sm.DeleteAccount(ownerTokens.AccessToken, "ValidPass123"); err != nil || !ok || deleted != uuid.Nil {
+		t.Fatalf("DeleteAccount of an admin = %v, %v, %v", ok, deleted, err)
+	}
+	tokens, _ := sm.GetJWTByCredentials("memberUser", "ValidPass123", testClient)
+	if ok, deleted, err := sm.DeleteAccount(tokens.AccessToken, "ValidPass123"); err != nil || !ok || deleted != organisation.ID {
+		t.Errorf("DeleteAccount of the last owner = %v, %v, %v; want organisation %v deleted", ok, deleted, err, organisation.ID)
+	}
+	var organisationEvents []statsEvent
+	for _, event := range outboxStatsEvents(t, env.storage) {
+		if event.EventType == "organisation_deleted" {
+			organisationEvents = append(organisationEvents, event)
+		}
+	}
+	if len(organisationEvents) != 1 || organisationEvents[0].ObjectID != organisation.ID.String() ||
+		organisationEvents[0].UserID != member.ID.String() {
+		t.Errorf("organisation_deleted events = %+v; want one for organisation %v", organisationEvents, organisation.ID)
+	}
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	authoutbox "authservice/auth_outbox"
+	smimpl "authservice/auth_storage/storage_manager"
+	usermodel "authservice/auth_storage/user_model"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type statsEvent struct {
+	EventType string `json:"event_type"`
+	UserID    string `json:"user_id"`
+	ObjectID  string `json:"object_id"`
+}
+
+func outboxStatsEvents(t *testing.T, storage smimpl.Storage) []statsEvent {
+	messages, err := storage.GetOutboxMessages(100)
+	if err != nil {
+		t.Fatalf("GetOutboxMessages failed: %v", err)
+	}
+	var events []statsEvent
+	for _, message := range messages {
+		if message.Topic != usermodel.OutboxStatsTopic {
+			continue
+		}
+		var event statsEvent
+		if err := json.Unmarshal(message.Value, &event); err != nil {
+			t.Fatalf("outbox message %s is not JSON: %v", message.Value, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// TestOutboxRelayRetries keeps the messages in the outbox while Kafka fails
+// and publishes them in order once it is back.
+func TestOutboxRelayRetries(t *testing.T) {
+	env := newTestEnv(t, withUser("firstUser", "first@example.com", false), withUser("secondUser", "second@example.com", false))
+	first := env.user(t, "firstUser")
+	for _, login := range []string{"firstUser", "secondUser"} {
+		if ok, _, err := env.sm.DeleteAccount(env.login(t, login).AccessToken, testPassword); err != nil || !ok {
+			t.Fatalf("DeleteAccount failed: %v, %v", ok, err)
+		}
+	}
+	writer := authoutbox.NewMemoryWriter()
+	relay := authoutbox.NewRelay(env.storage, writer, 0)
+
+	writer.SetError(errors.New("kafka is down"))
+	if published, err := relay.Flush(context.Background()); err == nil || published != 0 {
+		t.Errorf("Flush = %d, %v; want the Kafka error", published, err)
+	}
+	if events := outboxStatsEvents(t, env.storage); len(events) != 2 {
+		t.Fatalf("outbox holds %d events after a failed flush; want 2", len(events))
+	}
+
+	pending, _ := env.storage.GetOutboxMessages(100)
+	writer.SetError(nil)
+	if published, err := relay.Flush(context.Background()); err != nil || published != len(pending) {
+		t.Errorf("Flush = %d, %v; want %d messages", published, err, len(pending))
+	}
+	if messages, _ := env.storage.GetOutboxMessages(100); len(messages) != 0 {
+		t.Errorf("%d messages stay in the outbox after publishing", len(messages))
+	}
+	var messages []usermodel.OutboxMessage
+	for _, message := range writer.Messages() {
+		if message.Topic == usermodel.OutboxStatsTopic {
+			messages = append(messages, message)
+		}
+	}
+	if len(messages) != 2 || messages[0].ID >= messages[1].ID || messages[0].Key != first.ID.String() {
+		t.Errorf("published %+v; want both events in order", messages)
+	}
+}
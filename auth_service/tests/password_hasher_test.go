@@ -0,0 +1,128 @@
+package tests
+
+import (
+	passwordhasher "authservice/auth_storage/password_hasher"
+	userkeys "authservice/auth_storage/user_keys"
+	usermodel "authservice/auth_storage/user_model"
+	"strings"
+	"testing"
+)
+
+var testArgon2idParams = passwordhasher.Argon2idParams{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := passwordhasher.NewArgon2idHasher(testArgon2idParams)
+
+	first, err := hasher.Hash("ValidPass123")
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	second, err := hasher.Hash("ValidPass123")
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	if !strings.HasPrefix(first, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("Hash is not PHC encoded: %q", first)
+	}
+	if first == second {
+		t.Errorf("Hash did not use a random salt")
+	}
+
+	if ok, err := hasher.Verify("ValidPass123", first); err != nil || !ok {
+		t.Errorf("Verify rejected correct password: %v, %v", ok, err)
+	}
+	if ok, err := hasher.Verify("WrongPass123", first); err != nil || ok {
+		t.Errorf("Verify accepted wrong password: %v, %v", ok, err)
+	}
+	if _, err := hasher.Verify("ValidPass123", "$argon2id$garbage"); err == nil {
+		t.Errorf("Verify accepted malformed hash")
+	}
+
+	if hasher.NeedsRehash(first) {
+		t.Errorf("NeedsRehash reported fresh hash as outdated")
+	}
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+	if !passwordhasher.NewArgon2idHasher(stronger).NeedsRehash(first) {
+		t.Errorf("NeedsRehash ignored weaker parameters")
+	}
+}
+
+func TestBcryptHasher(t *testing.T) {
+	hasher := passwordhasher.NewBcryptHasher(4)
+
+	hash, err := hasher.Hash("ValidPass123")
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	if ok, err := hasher.Verify("ValidPass123", hash); err != nil || !ok {
+		t.Errorf("Verify rejected correct password: %v, %v", ok, err)
+	}
+	if ok, err := hasher.Verify("WrongPass123", hash); err != nil || ok {
+		t.Errorf("Verify accepted wrong password: %v, %v", ok, err)
+	}
+	if !passwordhasher.NewBcryptHasher(5).NeedsRehash(hash) {
+		t.Errorf("NeedsRehash ignored lower cost")
+	}
+}
+
+func TestNewHasher(t *testing.T) {
+	if _, err := passwordhasher.NewHasher("md5"); err == nil {
+		t.Errorf("NewHasher accepted unknown algorithm")
+	}
+
+	hasher, err := passwordhasher.NewHasher(passwordhasher.BcryptAlgorithm)
+	if err != nil {
+		t.Fatalf("NewHasher failed: %v", err)
+	}
+	argon2idHash, err := passwordhasher.NewArgon2idHasher(testArgon2idParams).Hash("ValidPass123")
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	if ok, err := hasher.Verify("ValidPass123", argon2idHash); err != nil || !ok {
+		t.Errorf("Verify rejected hash of a non-preferred algorithm: %v, %v", ok, err)
+	}
+	if !hasher.NeedsRehash(argon2idHash) {
+		t.Errorf("NeedsRehash did not request migration to the preferred algorithm")
+	}
+}
+
+func TestLegacyPasswordUpgrade(t *testing.T) {
+	env := newTestEnv(t)
+	sm, storage := env.sm, env.storage
+
+	login, password := "legacyUser", "ValidPass123"
+	user, err := usermodel.NewUser(login, "legacy@example.com", password, false)
+	if err != nil {
+		t.Fatalf("NewUser failed: %v", err)
+	}
+	legacyHash := userkeys.GetPasswordHash(login, password)
+	if _, err := storage.AddUser(*user, login, legacyHash[:]); err != nil {
+		t.Fatalf("AddUser failed: %v", err)
+	}
+
+	if tokens, err := sm.GetJWTByCredentials(login, "WrongPass123", testClient); err != nil || tokens.AccessToken != "" {
+		t.Fatalf("legacy login accepted wrong password: %q, %v", tokens.AccessToken, err)
+	}
+	if stored, _, _ := storage.GetUserPasswordByLogin(login); string(stored) != string(legacyHash[:]) {
+		t.Fatalf("failed login rehashed the password")
+	}
+
+	if tokens, err := sm.GetJWTByCredentials(login, password, testClient); err != nil || tokens.AccessToken == "" {
+		t.Fatalf("legacy login failed: %v", err)
+	}
+	stored, _, _ := storage.GetUserPasswordByLogin(login)
+	if !strings.HasPrefix(string(stored), "$argon2id$") {
+		t.Fatalf("legacy hash was not upgraded: %q", stored)
+	}
+
+	if tokens, err := sm.GetJWTByCredentials(login, password, testClient); err != nil || tokens.AccessToken == "" {
+		t.Errorf("login after upgrade failed: %v", err)
+	}
+}
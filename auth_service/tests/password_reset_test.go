@@ -0,0 +1,118 @@
+package tests
+
+import (
+	authmailer "authservice/auth_mailer"
+	usermodel "authservice/auth_storage/user_model"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitForMessages waits for the emails sent in the background until there
+// are n of them.
+func waitForMessages(t *testing.T, mailer *authmailer.MemoryMailer, n int) []authmailer.Message {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		messages := mailer.Messages()
+		if len(messages) >= n {
+			return messages
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d emails, got %+v", n, messages)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func resetToken(t *testing.T, body string) string {
+	return linkToken(t, body, "/api/v1/password/reset")
+}
+
+func TestChangePassword(t *testing.T) {
+	sm := newTestEnv(t, withTokenUser).sm
+
+	current, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if err != nil {
+		t.Fatalf("GetJWTByCredentials failed: %v", err)
+	}
+	other, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if err != nil {
+		t.Fatalf("GetJWTByCredentials failed: %v", err)
+	}
+
+	if _, err := sm.ChangePassword(current.AccessToken, "WrongPass123", "NewValidPass1"); !errors.Is(err, usermodel.ErrWrongPassword) {
+		t.Errorf("ChangePassword accepted wrong current password: %v", err)
+	}
+	if _, err := sm.ChangePassword(current.AccessToken, "ValidPass123", "weak"); !errors.Is(err, usermodel.ErrInvalidPassword) {
+		t.Errorf("ChangePassword accepted weak password: %v", err)
+	}
+
+	tokens, err := sm.ChangePassword(current.AccessToken, "ValidPass123", "NewValidPass1")
+	if err != nil || tokens.AccessToken == "" {
+		t.Fatalf("ChangePassword failed: %v", err)
+	}
+	if user, err := sm.GetUserByJWT(tokens.AccessToken); err != nil || user.Login != "tokenUser" {
+		t.Errorf("new JWT is rejected: %+v, %v", user, err)
+	}
+	for _, old := range []usermodel.TokenPair{current, other} {
+		if user, err := sm.GetUserByJWT(old.AccessToken); err != nil || user.Login != "" {
+			t.Errorf("JWT issued before password change is still accepted: %v", err)
+		}
+		if refreshed, err := sm.RefreshJWT(old.RefreshToken, testClient); err != nil || refreshed.AccessToken != "" {
+			t.Errorf("refresh token survived password change: %v", err)
+		}
+	}
+
+	if tokens, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient); err != nil || tokens.AccessToken != "" {
+		t.Errorf("old password is still accepted: %v", err)
+	}
+	if tokens, err := sm.GetJWTByCredentials("tokenUser", "NewValidPass1", testClient); err != nil || tokens.AccessToken == "" {
+		t.Errorf("new password is rejected: %v", err)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	env := newTestEnv(t, withTokenUser)
+	sm, mailer := env.sm, env.mailer
+
+	sm.RequestPasswordReset("unknownUser")
+	sm.RequestPasswordReset("tokenUser")
+	waitForMessages(t, mailer, 2)
+	sm.RequestPasswordReset("tokenUser")
+	messages := waitForMessages(t, mailer, 3)
+	if len(messages) != 3 || messages[1].To != "token@example.com" || messages[2].To != "token@example.com" {
+		t.Fatalf("expected two reset emails, got %+v", messages)
+	}
+	older, token := resetToken(t, messages[1].Body), resetToken(t, messages[2].Body)
+	session, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if err != nil {
+		t.Fatalf("GetJWTByCredentials failed: %v", err)
+	}
+
+	if ok, err := sm.ResetPassword(verificationToken(t, messages[0].Body), "NewValidPass1"); err != nil || ok {
+		t.Errorf("ResetPassword accepted a verification token: %v, %v", ok, err)
+	}
+	if _, err := sm.ResetPassword(token, "weak"); !errors.Is(err, usermodel.ErrInvalidPassword) {
+		t.Errorf("ResetPassword accepted weak password: %v", err)
+	}
+	if ok, err := sm.ResetPassword(token, "NewValidPass1"); err != nil || !ok {
+		t.Fatalf("ResetPassword failed: %v, %v", ok, err)
+	}
+	if ok, err := sm.ResetPassword(token, "OtherValidPass1"); err != nil || ok {
+		t.Errorf("reset token is reusable: %v, %v", ok, err)
+	}
+	if ok, err := sm.ResetPassword(older, "OtherValidPass1"); err != nil || ok {
+		t.Errorf("older reset token survived the reset: %v, %v", ok, err)
+	}
+
+	if user, err := sm.GetUserByJWT(session.AccessToken); err != nil || user.Login != "" {
+		t.Errorf("JWT issued before reset is still accepted: %v", err)
+	}
+	if refreshed, err := sm.RefreshJWT(session.RefreshToken, testClient); err != nil || refreshed.AccessToken != "" {
+		t.Errorf("refresh token survived reset: %v", err)
+	}
+	if tokens, err := sm.GetJWTByCredentials("tokenUser", "NewValidPass1", testClient); err != nil || tokens.AccessToken == "" {
+		t.Errorf("new password is rejected: %v", err)
+	}
+}
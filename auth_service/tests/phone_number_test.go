@@ -0,0 +1,154 @@
+package tests
+
+import (
+	authsms "authservice/auth_sms"
+	usermodel "authservice/auth_storage/user_model"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestParsePhoneNumber(t *testing.T) {
+	tests := []struct {
+		input, region       string
+		expected, expRegion string
+	}{
+		{"8 (900) 123-45-67", "", "+79001234567", "RU"},
+		{"9001234567", "ru", "+79001234567", "RU"},
+		{"+7 701 123 4567", "KZ", "+77011234567", "KZ"},
+		{"+7 701 123 4567", "", "+77011234567", "RU"},
+		{"07911 123456", "GB", "+447911123456", "GB"},
+		{"0049 30 1234567", "", "+49301234567", "DE"},
+		{"(212) 555-0123", "US", "+12125550123", "US"},
+		{"+6841234", "", "+6841234", ""},
+		{"890012345", "", "", ""},
+		{"+7900123", "", "", ""},
+		{"+0123456789", "", "", ""},
+		{"+7900123456a", "", "", ""},
+		{"9001234567", "XX", "", ""},
+		{"", "", "", ""},
+	}
+
+	for _, test := range tests {
+		result, region, ok := usermodel.ParsePhoneNumber(test.input, test.region)
+		if result != test.expected || region != test.expRegion || ok != (test.expected != "") {
+			t.Errorf("ParsePhoneNumber(%q, %q) = %q, %q, %v; want %q, %q", test.input, test.region, result, region, ok,
+				test.expected, test.expRegion)
+		}
+	}
+}
+
+var smsCodeRegex = regexp.MustCompile(`\d{6}`)
+
+func loginWithPhone(t *testing.T, sm usermodel.StorageManager, login, phoneNumber string) string {
+	if _, err := sm.CreateUser(login, "ValidPass123", login+"@example.com", false, testClient); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	tokens, err := sm.GetJWTByCredentials(login, "ValidPass123", testClient)
+	if err != nil {
+		t.Fatalf("GetJWTByCredentials failed: %v", err)
+	}
+	user, err := sm.UpdateUserByJWT(tokens.AccessToken, usermodel.User{PhoneNumber: phoneNumber, PhoneRegion: "RU"}, testClient)
+	if err != nil {
+		t.Fatalf("UpdateUserByJWT failed: %v", err)
+	}
+	if user.PhoneNumber != "+79001234567" || user.PhoneRegion != "RU" || user.PhoneNumberVerified {
+		t.Fatalf("phone number stored as %q, %q, verified %v", user.PhoneNumber, user.PhoneRegion, user.PhoneNumberVerified)
+	}
+	return tokens.AccessToken
+}
+
+func TestPhoneVerification(t *testing.T) {
+	sender := authsms.NewMemorySender()
+	sm := newTestEnv(t, withSMSSender(sender)).sm
+	jwt := loginWithPhone(t, sm, "phoneUser", "8 900 123-45-67")
+
+	if ok, err := sm.RequestPhoneVerification(jwt); err != nil || !ok {
+		t.Fatalf("RequestPhoneVerification failed: %v, %v", ok, err)
+	}
+	if _, err := sm.RequestPhoneVerification(jwt); !errors.Is(err, usermodel.ErrPhoneCodeRecentlySent) {
+		t.Errorf("second code was sent right away: %v", err)
+	}
+	messages := sender.Messages()
+	if len(messages) != 1 || messages[0].To != "+79001234567" {
+		t.Fatalf("sent messages %+v", messages)
+	}
+	code := smsCodeRegex.FindString(messages[0].Text)
+
+	if _, err := sm.ConfirmPhoneNumber(jwt, "000000"+code); !errors.Is(err, usermodel.ErrWrongPhoneCode) {
+		t.Errorf("ConfirmPhoneNumber accepted a wrong code: %v", err)
+	}
+	user, err := sm.ConfirmPhoneNumber(jwt, code)
+	if err != nil || !user.PhoneNumberVerified {
+		t.Fatalf("ConfirmPhoneNumber failed: %v", err)
+	}
+	if _, err := sm.ConfirmPhoneNumber(jwt, code); !errors.Is(err, usermodel.ErrWrongPhoneCode) {
+		t.Errorf("code was accepted twice: %v", err)
+	}
+	if _, err := sm.RequestPhoneVerification(jwt); !errors.Is(err, usermodel.ErrPhoneNumberVerified) {
+		t.Errorf("code was sent to a verified number: %v", err)
+	}
+
+	user, err = sm.UpdateUserByJWT(jwt, usermodel.User{PhoneNumber: "+7 900 765-43-21"}, testClient)
+	if err != nil || user.PhoneNumberVerified {
+		t.Errorf("changed phone number stayed verified: %v", err)
+	}
+}
+
+func TestPhoneCodeAttemptsLimit(t *testing.T) {
+	sender := authsms.NewMemorySender()
+	sm := newTestEnv(t, withSMSSender(sender)).sm
+	jwt := loginWithPhone(t, sm, "phoneUser", "+79001234567")
+	if _, err := sm.RequestPhoneVerification(jwt); err != nil {
+		t.Fatalf("RequestPhoneVerification failed: %v", err)
+	}
+	code := smsCodeRegex.FindString(sender.Messages()[0].Text)
+	for i := 0; i < 5; i++ {
+		sm.ConfirmPhoneNumber(jwt, "wrong")
+	}
+	if _, err := sm.ConfirmPhoneNumber(jwt, code); !errors.Is(err, usermodel.ErrWrongPhoneCode) {
+		t.Errorf("code was accepted after too many wrong guesses: %v", err)
+	}
+}
+
+func TestVerifiedPhoneNumberIsUnique(t *testing.T) {
+	sender := authsms.NewMemorySender()
+	sm := newTestEnv(t, withSMSSender(sender)).sm
+	aliceJWT := loginWithPhone(t, sm, "aliceUser", "89001234567")
+	bobJWT := loginWithPhone(t, sm, "bobUser", "89001234567")
+
+	if _, err := sm.RequestPhoneVerification(aliceJWT); err != nil {
+		t.Fatalf("RequestPhoneVerification failed: %v", err)
+	}
+	if _, err := sm.RequestPhoneVerification(bobJWT); err != nil {
+		t.Fatalf("RequestPhoneVerification failed: %v", err)
+	}
+	messages := sender.Messages()
+	if _, err := sm.ConfirmPhoneNumber(aliceJWT, smsCodeRegex.FindString(messages[0].Text)); err != nil {
+		t.Fatalf("ConfirmPhoneNumber failed: %v", err)
+	}
+
+	if _, err := sm.ConfirmPhoneNumber(bobJWT, smsCodeRegex.FindString(messages[1].Text)); !errors.Is(err, usermodel.ErrPhoneNumberTaken) {
+		t.Errorf("a verified phone number was verified by another user: %v", err)
+	}
+	// Saving the number or asking for a code must not tell whether someone
+	// else verified it.
+	if _, err := sm.UpdateUserByJWT(bobJWT, usermodel.User{PhoneNumber: "+79007654321"}, testClient); err != nil {
+		t.Fatalf("UpdateUserByJWT failed: %v", err)
+	}
+	if _, err := sm.UpdateUserByJWT(bobJWT, usermodel.User{PhoneNumber: "+7 (900) 123-45-67"}, testClient); err != nil {
+		t.Errorf("profile update revealed that the number is verified by another user: %v", err)
+	}
+	carolJWT := loginWithPhone(t, sm, "carolUser", "89001234567")
+	if _, err := sm.RequestPhoneVerification(carolJWT); err != nil {
+		t.Errorf("RequestPhoneVerification revealed that the number is verified by another user: %v", err)
+	}
+}
+
+func TestPhoneVerificationDisabled(t *testing.T) {
+	sm := newTestEnv(t).sm
+	jwt := loginWithPhone(t, sm, "phoneUser", "89001234567")
+	if _, err := sm.RequestPhoneVerification(jwt); !errors.Is(err, usermodel.ErrPhoneVerificationUnavailable) {
+		t.Errorf("RequestPhoneVerification without an SMS sender: %v", err)
+	}
+}
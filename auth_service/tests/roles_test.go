@@ -0,0 +1,83 @@
+package tests
+
+import (
+	userkeys "authservice/auth_storage/user_keys"
+	usermodel "authservice/auth_storage/user_model"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDefaultRoles(t *testing.T) {
+	sm := newTestEnv(t, withTokenUser).sm
+	company, err := sm.CreateUser("companyUser", "ValidPass123", "company@example.com", true, testClient)
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if company.Role != usermodel.RoleCompanyAdmin {
+		t.Errorf("company got role %q", company.Role)
+	}
+
+	tokens, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if err != nil {
+		t.Fatalf("GetJWTByCredentials failed: %v", err)
+	}
+	if claims, ok := userkeys.ParseJWT(tokens.AccessToken); !ok || claims.Role != string(usermodel.RoleCustomer) {
+		t.Errorf("JWT carries role %q", claims.Role)
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	env := newTestEnv(t, withAdminUser, withUser("memberUser", "member@example.com", false))
+	sm, member := env.sm, env.user(t, "memberUser")
+
+	memberTokens, _ := sm.GetJWTByCredentials("memberUser", "ValidPass123", testClient)
+	if _, err := sm.SetUserRole(memberTokens.AccessToken, member.ID, usermodel.RolePlatformAdmin); !errors.Is(err, usermodel.ErrForbidden) {
+		t.Errorf("customer changed a role: %v", err)
+	}
+
+	adminTokens, _ := sm.GetJWTByCredentials("adminUser", "ValidPass123", testClient)
+	if _, err := sm.SetUserRole(adminTokens.AccessToken, member.ID, "owner"); !errors.Is(err, usermodel.ErrInvalidRole) {
+		t.Errorf("SetUserRole accepted an unknown role: %v", err)
+	}
+	if _, err := sm.SetUserRole(adminTokens.AccessToken, uuid.New(), usermodel.RoleCompanyMember); !errors.Is(err, usermodel.ErrUserNotFound) {
+		t.Errorf("SetUserRole accepted an unknown user: %v", err)
+	}
+	user, err := sm.SetUserRole(adminTokens.AccessToken, member.ID, usermodel.RoleCompanyMember)
+	if err != nil || user.Role != usermodel.RoleCompanyMember {
+		t.Fatalf("SetUserRole failed: %+v, %v", user, err)
+	}
+
+	if user, err := sm.GetUserByJWT(memberTokens.AccessToken); err != nil || user.Login != "" {
+		t.Errorf("JWT with the old role is still accepted: %v", err)
+	}
+	memberTokens, _ = sm.GetJWTByCredentials("memberUser", "ValidPass123", testClient)
+	if claims, ok := userkeys.ParseJWT(memberTokens.AccessToken); !ok || claims.Role != string(usermodel.RoleCompanyMember) {
+		t.Errorf("JWT carries role %q after the change", claims.Role)
+	}
+}
+
+func TestSetUserRoleByLogin(t *testing.T) {
+	env := newTestEnv(t, withTokenUser)
+	sm, tokens := env.sm, env.login(t, "tokenUser")
+
+	if _, err := sm.SetUserRoleByLogin("tokenUser", "owner"); !errors.Is(err, usermodel.ErrInvalidRole) {
+		t.Errorf("SetUserRoleByLogin accepted an unknown role: %v", err)
+	}
+	if _, err := sm.SetUserRoleByLogin("nobody", usermodel.RolePlatformAdmin); !errors.Is(err, usermodel.ErrUserNotFound) {
+		t.Errorf("SetUserRoleByLogin accepted an unknown user: %v", err)
+	}
+	user, err := sm.SetUserRoleByLogin("tokenUser", usermodel.RolePlatformAdmin)
+	if err != nil || user.Role != usermodel.RolePlatformAdmin {
+		t.Fatalf("SetUserRoleByLogin failed: %+v, %v", user, err)
+	}
+
+	if refreshed, err := sm.RefreshJWT(tokens.RefreshToken, testClient); err != nil || refreshed.AccessToken != "" {
+		t.Errorf("refresh token issued before the role change is still accepted: %v", err)
+	}
+	events, err := env.storage.GetAuditEvents(usermodel.AuditFilter{UserID: user.ID, Limit: 10})
+	if err != nil || len(events) == 0 || events[0].Action != usermodel.AuditRoleChange || events[0].ActorID != uuid.Nil {
+		t.Errorf("latest audit events = %+v, %v; want a role change without an actor", events, err)
+	}
+}
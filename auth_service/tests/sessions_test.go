@@ -0,0 +1,99 @@
+package tests
+
+import (
+	userkeys "authservice/auth_storage/user_keys"
+	usermodel "authservice/auth_storage/user_model"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestListSessions(t *testing.T) {
+	sm := newTestEnv(t, withTokenUser).sm
+	laptop, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if err != nil {
+		t.Fatalf("GetJWTByCredentials failed: %v", err)
+	}
+	phone, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123",
+		usermodel.ClientInfo{IP: "198.51.100.7", UserAgent: "Mobile Safari"})
+	if err != nil {
+		t.Fatalf("GetJWTByCredentials failed: %v", err)
+	}
+	claims, ok := userkeys.ParseJWT(laptop.AccessToken)
+	if !ok || claims.SessionID == uuid.Nil {
+		t.Fatalf("JWT carries no session")
+	}
+
+	sessions, currentId, err := sm.ListSessions(laptop.AccessToken)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("ListSessions returned %d sessions: %v", len(sessions), err)
+	}
+	if currentId != claims.SessionID {
+		t.Errorf("current session is %v, want %v", currentId, claims.SessionID)
+	}
+	for _, session := range sessions {
+		if session.ID == currentId && (session.IP != testClient.IP || session.UserAgent != testClient.UserAgent) {
+			t.Errorf("session describes the wrong client: %+v", session)
+		}
+	}
+
+	refreshed, err := sm.RefreshJWT(phone.RefreshToken, testClient)
+	if err != nil || refreshed.AccessToken == "" {
+		t.Fatalf("RefreshJWT failed: %v", err)
+	}
+	if sessions, _, _ := sm.ListSessions(laptop.AccessToken); len(sessions) != 2 {
+		t.Errorf("refresh started a new session: %d sessions", len(sessions))
+	}
+	if sessions, _, err := sm.ListSessions("invalid"); err != nil || sessions != nil {
+		t.Errorf("ListSessions accepted an invalid JWT: %v", err)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	sm := newTestEnv(t, withTokenUser).sm
+	laptop, _ := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	phone, _ := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	phoneClaims, _ := userkeys.ParseJWT(phone.AccessToken)
+
+	if _, err := sm.RevokeSession(laptop.AccessToken, uuid.New()); !errors.Is(err, usermodel.ErrSessionNotFound) {
+		t.Errorf("RevokeSession accepted an unknown session: %v", err)
+	}
+	if ok, err := sm.RevokeSession(laptop.AccessToken, phoneClaims.SessionID); err != nil || !ok {
+		t.Fatalf("RevokeSession failed: %v, %v", ok, err)
+	}
+
+	if user, err := sm.GetUserByJWT(phone.AccessToken); err != nil || user.Login != "" {
+		t.Errorf("JWT of a revoked session is still accepted: %v", err)
+	}
+	if refreshed, err := sm.RefreshJWT(phone.RefreshToken, testClient); err != nil || refreshed.AccessToken != "" {
+		t.Errorf("refresh token of a revoked session is still accepted: %v", err)
+	}
+	if introspection, err := sm.IntrospectToken(phone.AccessToken); err != nil || introspection.Active {
+		t.Errorf("revoked session is still active: %v", err)
+	}
+	if user, err := sm.GetUserByJWT(laptop.AccessToken); err != nil || user.Login == "" {
+		t.Errorf("revoking another session signed out the current one: %v", err)
+	}
+	if sessions, _, _ := sm.ListSessions(laptop.AccessToken); len(sessions) != 1 {
+		t.Errorf("ListSessions returned %d sessions after revocation", len(sessions))
+	}
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	sm := newTestEnv(t, withTokenUser).sm
+	tokens, _ := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if introspection, err := sm.IntrospectToken(tokens.AccessToken); err != nil || !introspection.Active {
+		t.Fatalf("IntrospectToken failed: %+v, %v", introspection, err)
+	}
+
+	if ok, err := sm.Logout(tokens.AccessToken, ""); err != nil || !ok {
+		t.Fatalf("Logout failed: %v, %v", ok, err)
+	}
+	if introspection, err := sm.IntrospectToken(tokens.AccessToken); err != nil || introspection.Active {
+		t.Errorf("session is still active after logout: %v", err)
+	}
+	if refreshed, err := sm.RefreshJWT(tokens.RefreshToken, testClient); err != nil || refreshed.AccessToken != "" {
+		t.Errorf("refresh token is still accepted after logout: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+//go:build sqlite
+
+package tests
+
+import (
+	pgstorage "authservice/auth_storage/postgresql_storage"
+	sqlitestorage "authservice/auth_storage/sqlite_storage"
+	smimpl "authservice/auth_storage/storage_manager"
+	usermodel "authservice/auth_storage/user_model"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSQLiteStorageContract(t *testing.T) {
+	runStorageContract(t, func(t *testing.T) smimpl.Storage {
+		storage, err := sqlitestorage.NewStorage(filepath.Join(t.TempDir(), "auth.db"), newContractCipher(t))
+		if err != nil {
+			t.Fatalf("NewStorage failed: %v", err)
+		}
+		return storage
+	})
+}
+
+func TestSQLiteStorageInMemory(t *testing.T) {
+	storage, err := sqlitestorage.NewStorage(":memory:", newContractCipher(t))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	testStorageUsers(t, storage)
+}
+
+// TestSQLiteKeepsDataAcrossRestarts registers a user and logs in after the
+// database is opened again, as after a restart of the service.
+func TestSQLiteKeepsDataAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.db")
+	open := func() usermodel.StorageManager {
+		storage, err := sqlitestorage.NewStorage(path, newContractCipher(t))
+		if err != nil {
+			t.Fatalf("NewStorage failed: %v", err)
+		}
+		return newTestEnv(t, withStorage(storage)).sm
+	}
+
+	if _, err := open().CreateUser("sqliteUser", "ValidPass123", "sqlite@example.com", false, testClient); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	sm := open()
+	tokens, err := sm.GetJWTByCredentials("sqlite@example.com", "ValidPass123", testClient)
+	if err != nil || tokens.AccessToken == "" {
+		t.Fatalf("GetJWTByCredentials after reopening failed: %+v, %v", tokens, err)
+	}
+	if user, err := sm.GetUserByJWT(tokens.AccessToken); err != nil || user.Login != "sqliteUser" {
+		t.Errorf("GetUserByJWT = %+v, %v", user, err)
+	}
+}
+
+// TestSQLiteSchemaMatchesMigrations checks that the schema created from the
+// models has the indexes and foreign keys the migrations leave behind, with
+// the same names and uniqueness.
+func TestSQLiteSchemaMatchesMigrations(t *testing.T) {
+	migrations, err := pgstorage.LoadMigrations()
+	if err != nil {
+		t.Fatalf("LoadMigrations failed: %v", err)
+	}
+	createIndex := regexp.MustCompile(`CREATE (UNIQUE )?INDEX IF NOT EXISTS (\w+)`)
+	dropIndex := regexp.MustCompile(`DROP INDEX IF EXISTS (\w+)`)
+	foreignKey := regexp.MustCompile(`CONSTRAINT (fk_\w+)`)
+	wantIndexes := make(map[string]bool)
+	var wantForeignKeys []string
+	for _, migration := range migrations {
+		for _, match := range createIndex.FindAllStringSubmatch(migration.Up, -1) {
+			wantIndexes[match[2]] = match[1] != ""
+		}
+		for _, match := range dropIndex.FindAllStringSubmatch(migration.Up, -1) {
+			delete(wantIndexes, match[1])
+		}
+		for _, match := range foreignKey.FindAllStringSubmatch(migration.Up, -1) {
+			wantForeignKeys = append(wantForeignKeys, match[1])
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "auth.db")
+	if _, err := sqlitestorage.NewStorage(path, newContractCipher(t)); err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("opening %s failed: %v", path, err)
+	}
+	var objects []struct {
+		Type string
+		Name string
+		SQL  string `gorm:"column:sql"`
+	}
+	err = db.Raw("SELECT type, name, sql FROM sqlite_master WHERE sql IS NOT NULL").Scan(&objects).Error
+	if err != nil {
+		t.Fatalf("reading the schema failed: %v", err)
+	}
+	gotIndexes := make(map[string]bool)
+	var tables strings.Builder
+	for _, object := range objects {
+		switch object.Type {
+		case "index":
+			gotIndexes[object.Name] = strings.HasPrefix(object.SQL, "CREATE UNIQUE INDEX")
+		case "table":
+			tables.WriteString(object.SQL)
+		}
+	}
+
+	for name, unique := range wantIndexes {
+		if got, ok := gotIndexes[name]; !ok || got != unique {
+			t.Errorf("index %s: exists %v, unique %v; want unique %v", name, ok, got, unique)
+		}
+	}
+	for name := range gotIndexes {
+		if _, ok := wantIndexes[name]; !ok {
+			t.Errorf("index %s is not created by the migrations", name)
+		}
+	}
+	for _, name := range wantForeignKeys {
+		if !strings.Contains(tables.String(), "CONSTRAINT `"+name+"` FOREIGN KEY") {
+			t.Errorf("foreign key %s is missing", name)
+		}
+	}
+}
@@ -0,0 +1,784 @@
+package tests
+
+import (
+	mockstorage "authservice/auth_storage/mock_storage"
+	pgstorage "authservice/auth_storage/postgresql_storage"
+	smimpl "authservice/auth_storage/storage_manager"
+	twofactor "authservice/auth_storage/two_factor"
+	usermodel "authservice/auth_storage/user_model"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// storageContract lists the behaviour every smimpl.Storage implementation
+// shares. Each test gets an empty storage.
+var storageContract = []struct {
+	name string
+	run  func(t *testing.T, storage smimpl.Storage)
+}{
+	{"Users", testStorageUsers},
+	{"SearchUsers", testStorageSearchUsers},
+	{"DeleteUser", testStorageDeleteUser},
+	{"Transaction", testStorageTransaction},
+	{"Tokens", testStorageTokens},
+	{"TwoFactor", testStorageTwoFactor},
+	{"LoginAttempts", testStorageLoginAttempts},
+	{"APIKeys", testStorageAPIKeys},
+	{"Sessions", testStorageSessions},
+	{"AuditEvents", testStorageAuditEvents},
+	{"Outbox", testStorageOutbox},
+	{"PhoneVerification", testStoragePhoneVerification},
+	{"Organisations", testStorageOrganisations},
+}
+
+func runStorageContract(t *testing.T, newStorage func(t *testing.T) smimpl.Storage) {
+	for _, test := range storageContract {
+		t.Run(test.name, func(t *testing.T) {
+			test.run(t, newStorage(t))
+		})
+	}
+}
+
+func newContractCipher(t *testing.T) *twofactor.SecretCipher {
+	cipher, err := twofactor.NewSecretCipher(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewSecretCipher failed: %v", err)
+	}
+	return cipher
+}
+
+func TestMockStorageContract(t *testing.T) {
+	runStorageContract(t, func(t *testing.T) smimpl.Storage {
+		return mockstorage.NewStorage()
+	})
+}
+
+// TestPGStorageContract runs against the database in AUTH_TEST_POSTGRES_DSN,
+// which is wiped before every test.
+func TestPGStorageContract(t *testing.T) {
+	dsn := os.Getenv("AUTH_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("AUTH_TEST_POSTGRES_DSN is not set")
+	}
+	migrations, err := pgstorage.LoadMigrations()
+	if err != nil {
+		t.Fatalf("LoadMigrations failed: %v", err)
+	}
+	runStorageContract(t, func(t *testing.T) smimpl.Storage {
+		db, err := pgstorage.OpenDSN(dsn)
+		if err != nil {
+			t.Fatalf("OpenDSN failed: %v", err)
+		}
+		if err := pgstorage.Migrate(db, 0); err != nil {
+			t.Fatalf("migrating down failed: %v", err)
+		}
+		if err := pgstorage.Migrate(db, len(migrations)); err != nil {
+			t.Fatalf("migrating up failed: %v", err)
+		}
+		return pgstorage.NewStorageWithDB(db, newContractCipher(t))
+	})
+}
+
+// contractNow is rounded to milliseconds, which every storage keeps exactly.
+func contractNow() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func addContractUser(t *testing.T, storage smimpl.Storage, login, email string) usermodel.User {
+	user, err := usermodel.NewUser(login, email, "ValidPass123", false)
+	if err != nil {
+		t.Fatalf("NewUser failed: %v", err)
+	}
+	user.CreationDate, user.UpdateDate = contractNow(), contractNow()
+	user.ID, err = storage.AddUser(*user, login, []byte("hash-"+login))
+	if err != nil {
+		t.Fatalf("AddUser(%s) failed: %v", login, err)
+	}
+	return *user
+}
+
+func testStorageUsers(t *testing.T, storage smimpl.Storage) {
+	alice := addContractUser(t, storage, "aliceSmith", "Alice@example.com")
+	user, err := storage.GetUserById(alice.ID)
+	if err != nil || user.Login != "aliceSmith" || user.Email != alice.Email || user.Role != alice.Role ||
+		!user.CreationDate.Equal(alice.CreationDate) {
+		t.Errorf("GetUserById = %+v, %v; want %+v", user, err, alice)
+	}
+	if user, err := storage.GetUserByLogin("aliceSmith"); err != nil || user.ID != alice.ID {
+		t.Errorf("GetUserByLogin = %v, %v; want %v", user.ID, err, alice.ID)
+	}
+	if user, err := storage.GetUserByLogin("nobody"); err != nil || user.Login != "" {
+		t.Errorf("GetUserByLogin of a missing user = %q, %v", user.Login, err)
+	}
+	if user, err := storage.GetUserByEmail("alice@EXAMPLE.com"); err != nil || user.ID != alice.ID {
+		t.Errorf("GetUserByEmail ignoring case = %v, %v; want %v", user.ID, err, alice.ID)
+	}
+	if user, err := storage.GetUserByEmail("bob@example.com"); err != nil || user.Login != "" {
+		t.Errorf("GetUserByEmail of a missing user = %q, %v", user.Login, err)
+	}
+
+	conflicts := []usermodel.User{
+		{Login: "aliceSmith", Email: "other@example.com", Role: usermodel.RoleCustomer},
+		{Login: "aliceOther", Email: "Alice@example.com", Role: usermodel.RoleCustomer},
+		{Login: "aliceLower", Email: "alice@EXAMPLE.com", Role: usermodel.RoleCustomer},
+	}
+	for _, conflict := range conflicts {
+		if _, err := storage.AddUser(conflict, conflict.Login, []byte("hash")); !errors.Is(err, usermodel.ErrUserExists) {
+			t.Errorf("AddUser(%s, %s) = %v; want ErrUserExists", conflict.Login, conflict.Email, err)
+		}
+	}
+
+	if password, ok, err := storage.GetUserPasswordByLogin("aliceSmith"); err != nil || !ok || string(password) != "hash-aliceSmith" {
+		t.Errorf("GetUserPasswordByLogin = %q, %v, %v", password, ok, err)
+	}
+	if err := storage.UpdateUserPassword("aliceSmith", []byte("new-hash")); err != nil {
+		t.Fatalf("UpdateUserPassword failed: %v", err)
+	}
+	if password, _, _ := storage.GetUserPasswordByLogin("aliceSmith"); string(password) != "new-hash" {
+		t.Errorf("password after UpdateUserPassword = %q", password)
+	}
+	if _, ok, err := storage.GetUserPasswordByLogin("nobody"); err != nil || ok {
+		t.Errorf("GetUserPasswordByLogin of a missing user = %v, %v", ok, err)
+	}
+
+	alice.FirstName = "Alice"
+	alice.PhoneNumber, alice.PhoneRegion, alice.PhoneNumberVerified = "+79001234567", "RU", true
+	alice.Role = usermodel.RolePlatformAdmin
+	if err := storage.UpdateUser(alice); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	user, _ = storage.GetUserById(alice.ID)
+	if user.FirstName != "Alice" || user.PhoneNumber != "+79001234567" || user.PhoneRegion != "RU" ||
+		!user.PhoneNumberVerified || user.Role != usermodel.RolePlatformAdmin {
+		t.Errorf("user after UpdateUser = %+v", user)
+	}
+	if user, err := storage.GetUserByVerifiedPhoneNumber("+79001234567"); err != nil || user.ID != alice.ID {
+		t.Errorf("GetUserByVerifiedPhoneNumber = %v, %v; want %v", user.ID, err, alice.ID)
+	}
+	if user, err := storage.GetUserByVerifiedPhoneNumber("+79007654321"); err != nil || user.Login != "" {
+		t.Errorf("GetUserByVerifiedPhoneNumber of an unknown number = %q, %v", user.Login, err)
+	}
+
+	bob := addContractUser(t, storage, "bobJones", "bob@example.com")
+	bob.PhoneNumber, bob.PhoneRegion = "+79001234567", "RU"
+	if err := storage.UpdateUser(bob); err != nil {
+		t.Errorf("UpdateUser with a number verified by another user failed: %v", err)
+	}
+	bob.PhoneNumberVerified = true
+	if err := storage.UpdateUser(bob); !errors.Is(err, usermodel.ErrUserExists) {
+		t.Errorf("UpdateUser verifying a number verified by another user = %v; want ErrUserExists", err)
+	}
+}
+
+func testStorageSearchUsers(t *testing.T, storage smimpl.Storage) {
+	now := contractNow()
+	var users []usermodel.User
+	for i, login := range []string{"aliceSmith", "bobJones", "carolWhite"} {
+		user := addContractUser(t, storage, login, login+"@example.com")
+		user.CreationDate = now.Add(time.Duration(i) * time.Second)
+		user.IsCompany = login == "bobJones"
+		user.Suspended = login == "carolWhite"
+		if err := storage.UpdateUser(user); err != nil {
+			t.Fatalf("UpdateUser failed: %v", err)
+		}
+		users = append(users, user)
+	}
+	ids := func(users []usermodel.User) []uuid.UUID {
+		var result []uuid.UUID
+		for _, user := range users {
+			result = append(result, user.ID)
+		}
+		return result
+	}
+	isCompany := false
+	filters := []struct {
+		filter   usermodel.UserFilter
+		expected []uuid.UUID
+	}{
+		{usermodel.UserFilter{Limit: 10}, ids(users)},
+		{usermodel.UserFilter{Offset: 1, Limit: 1}, []uuid.UUID{users[1].ID}},
+		{usermodel.UserFilter{Login: "SMITH", Limit: 10}, []uuid.UUID{users[0].ID}},
+		{usermodel.UserFilter{Email: "WHITE@", Limit: 10}, []uuid.UUID{users[2].ID}},
+		{usermodel.UserFilter{Email: "_", Limit: 10}, nil},
+		{usermodel.UserFilter{IsCompany: &isCompany, Limit: 10}, []uuid.UUID{users[0].ID, users[2].ID}},
+		{usermodel.UserFilter{CreatedFrom: now.Add(time.Second), CreatedTo: now.Add(time.Second * 2), Limit: 10}, []uuid.UUID{users[1].ID}},
+	}
+	for _, test := range filters {
+		found, err := storage.SearchUsers(test.filter)
+		if err != nil || !slices.Equal(ids(found), test.expected) {
+			t.Errorf("SearchUsers(%+v) = %v, %v; want %v", test.filter, ids(found), err, test.expected)
+		}
+	}
+	found, _ := storage.SearchUsers(usermodel.UserFilter{Login: "carol", Limit: 1})
+	if len(found) != 1 || found[0].Login != "carolWhite" || !found[0].Suspended || !found[0].CreationDate.Equal(users[2].CreationDate) {
+		t.Errorf("SearchUsers = %+v; want %+v", found, users[2])
+	}
+}
+
+// testStorageDeleteUser checks everything owned by the user goes with it,
+// except the audit log.
+func testStorageDeleteUser(t *testing.T, storage smimpl.Storage) {
+	alice := addContractUser(t, storage, "aliceSmith", "alice@example.com")
+	bob := addContractUser(t, storage, "bobJones", "bob@example.com")
+	now := contractNow()
+	for _, user := range []usermodel.User{alice, bob} {
+		steps := []error{
+			storage.AddSession(usermodel.Session{ID: uuid.New(), UserID: user.ID, CreationDate: now, LastSeenDate: now}),
+			storage.AddRefreshToken(usermodel.RefreshToken{ID: uuid.New(), UserID: user.ID, TokenHash: []byte("refresh-" + user.Login),
+				ExpiresAt: now.Add(time.Hour), CreationDate: now}),
+			storage.AddAPIKey(usermodel.APIKey{ID: uuid.New(), UserID: user.ID, Name: "key", KeyHash: []byte("key-" + user.Login),
+				Scopes: []string{"stats:read"}, CreationDate: now}),
+			storage.SaveTwoFactor(usermodel.TwoFactor{UserID: user.ID, Secret: "SECRET", CreationDate: now}),
+			storage.SavePhoneVerification(usermodel.PhoneVerification{UserID: user.ID, PhoneNumber: "+79001234567",
+				CodeHash: []byte("code"), ExpiresAt: now.Add(time.Minute), CreationDate: now}),
+			storage.AddAuditEvent(usermodel.AuditEvent{ID: uuid.New(), UserID: user.ID, Login: user.Login,
+				Action: usermodel.AuditRegister, Outcome: usermodel.AuditSuccess, CreationDate: now}),
+		}
+		if err := errors.Join(steps...); err != nil {
+			t.Fatalf("filling storage failed: %v", err)
+		}
+	}
+
+	if err := storage.DeleteUser(alice.ID); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+	for _, user := range []usermodel.User{alice, bob} {
+		deleted := user.ID == alice.ID
+		found, _ := storage.GetUserById(user.ID)
+		_, hasPassword, _ := storage.GetUserPasswordByLogin(user.Login)
+		sessions, _ := storage.GetUserSessions(user.ID)
+		_, hasToken, _ := storage.GetRefreshToken([]byte("refresh-" + user.Login))
+		_, hasKey, _ := storage.GetAPIKeyByHash([]byte("key-" + user.Login))
+		_, hasTwoFactor, _ := storage.GetTwoFactor(user.ID)
+		_, hasVerification, _ := storage.GetPhoneVerification(user.ID)
+		kept := []bool{found.Login != "", hasPassword, len(sessions) == 1, hasToken, hasKey, hasTwoFactor, hasVerification}
+		for i, ok := range kept {
+			if ok == deleted {
+				t.Errorf("data %d of %s kept = %v after deleting alice", i, user.Login, ok)
+			}
+		}
+		events, err := storage.GetAuditEvents(usermodel.AuditFilter{UserID: user.ID, Limit: 10})
+		if err != nil || len(events) != 1 {
+			t.Errorf("audit events of %s = %d, %v; want 1", user.Login, len(events), err)
+		}
+	}
+}
+
+func testStorageTransaction(t *testing.T, storage smimpl.Storage) {
+	errRollback := errors.New("rollback")
+	err := storage.Transaction(func(tx smimpl.Storage) error {
+		user := usermodel.User{Login: "aliceSmith", Email: "alice@example.com", Role: usermodel.RoleCustomer}
+		if _, err := tx.AddUser(user, user.Login, []byte("hash")); err != nil {
+			return err
+		}
+		if user, _ := tx.GetUserByLogin("aliceSmith"); user.Login == "" {
+			t.Errorf("user added in the transaction is not visible inside it")
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Errorf("Transaction = %v; want the error of fn", err)
+	}
+	if user, _ := storage.GetUserByLogin("aliceSmith"); user.Login != "" {
+		t.Errorf("user added by a failed transaction was kept")
+	}
+
+	err = storage.Transaction(func(tx smimpl.Storage) error {
+		user := usermodel.User{Login: "aliceSmith", Email: "alice@example.com", Role: usermodel.RoleCustomer}
+		_, err := tx.AddUser(user, user.Login, []byte("hash"))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	if user, _ := storage.GetUserByLogin("aliceSmith"); user.Login == "" {
+		t.Errorf("user added by a committed transaction is missing")
+	}
+}
+
+func testStorageTokens(t *testing.T, storage smimpl.Storage) {
+	alice := addContractUser(t, storage, "aliceSmith", "alice@example.com")
+	now := contractNow()
+	session := usermodel.Session{ID: uuid.New(), UserID: alice.ID, CreationDate: now, LastSeenDate: now}
+	if err := storage.AddSession(session); err != nil {
+		t.Fatalf("AddSession failed: %v", err)
+	}
+	tokens := []usermodel.RefreshToken{
+		{ID: uuid.New(), UserID: alice.ID, SessionID: session.ID, TokenHash: []byte("first"), ExpiresAt: now.Add(time.Hour), CreationDate: now},
+		{ID: uuid.New(), UserID: alice.ID, TokenHash: []byte("second"), ExpiresAt: now.Add(time.Hour), CreationDate: now},
+	}
+	for _, token := range tokens {
+		if err := storage.AddRefreshToken(token); err != nil {
+			t.Fatalf("AddRefreshToken failed: %v", err)
+		}
+	}
+	token, ok, err := storage.GetRefreshToken([]byte("first"))
+	if err != nil || !ok || token.ID != tokens[0].ID || token.SessionID != session.ID || !token.ExpiresAt.Equal(tokens[0].ExpiresAt) ||
+		!token.RevokedAt.IsZero() {
+		t.Errorf("GetRefreshToken = %+v, %v, %v; want %+v", token, ok, err, tokens[0])
+	}
+	if _, ok, err := storage.GetRefreshToken([]byte("missing")); err != nil || ok {
+		t.Errorf("GetRefreshToken of a missing token = %v, %v", ok, err)
+	}
+
+	if revoked, err := storage.RevokeRefreshToken(tokens[0].ID); err != nil || !revoked {
+		t.Fatalf("RevokeRefreshToken = %v, %v", revoked, err)
+	}
+	if revoked, err := storage.RevokeRefreshToken(tokens[0].ID); err != nil || revoked {
+		t.Errorf("RevokeRefreshToken of a revoked token = %v, %v", revoked, err)
+	}
+	first, _, _ := storage.GetRefreshToken([]byte("first"))
+	second, _, _ := storage.GetRefreshToken([]byte("second"))
+	if first.RevokedAt.IsZero() || !second.RevokedAt.IsZero() {
+		t.Errorf("RevokeRefreshToken revoked first %v, second %v", first.RevokedAt, second.RevokedAt)
+	}
+	if err := storage.RevokeUserRefreshTokens(alice.ID); err != nil {
+		t.Fatalf("RevokeUserRefreshTokens failed: %v", err)
+	}
+	if second, _, _ := storage.GetRefreshToken([]byte("second")); second.RevokedAt.IsZero() {
+		t.Errorf("RevokeUserRefreshTokens left a token active")
+	}
+
+	tokenId := uuid.New()
+	if revoked, err := storage.IsTokenRevoked(tokenId); err != nil || revoked {
+		t.Errorf("IsTokenRevoked before revoking = %v, %v", revoked, err)
+	}
+	if err := storage.RevokeToken(tokenId, now.Add(time.Hour)); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if err := storage.RevokeToken(tokenId, now.Add(time.Hour)); err != nil {
+		t.Errorf("revoking a token twice failed: %v", err)
+	}
+	if revoked, err := storage.IsTokenRevoked(tokenId); err != nil || !revoked {
+		t.Errorf("IsTokenRevoked = %v, %v; want true", revoked, err)
+	}
+
+	if generation, err := storage.GetUserTokenGeneration(alice.ID); err != nil || generation != 0 {
+		t.Errorf("GetUserTokenGeneration before revoking = %v, %v", generation, err)
+	}
+	for range 2 {
+		if err := storage.RevokeUserTokens(alice.ID); err != nil {
+			t.Fatalf("RevokeUserTokens failed: %v", err)
+		}
+	}
+	if generation, err := storage.GetUserTokenGeneration(alice.ID); err != nil || generation != 2 {
+		t.Errorf("GetUserTokenGeneration = %v, %v; want 2", generation, err)
+	}
+}
+
+func testStorageTwoFactor(t *testing.T, storage smimpl.Storage) {
+	alice := addContractUser(t, storage, "aliceSmith", "alice@example.com")
+	if _, ok, err := storage.GetTwoFactor(alice.ID); err != nil || ok {
+		t.Errorf("GetTwoFactor before enrolment = %v, %v", ok, err)
+	}
+	saved := usermodel.TwoFactor{UserID: alice.ID, Secret: "JBSWY3DPEHPK3PXP", RecoveryCodes: []string{"a", "b"}, CreationDate: contractNow()}
+	if err := storage.SaveTwoFactor(saved); err != nil {
+		t.Fatalf("SaveTwoFactor failed: %v", err)
+	}
+	saved.Enabled, saved.LastUsedStep, saved.RecoveryCodes = true, 42, []string{"b"}
+	if err := storage.SaveTwoFactor(saved); err != nil {
+		t.Fatalf("updating two-factor settings failed: %v", err)
+	}
+	twoFactor, ok, err := storage.GetTwoFactor(alice.ID)
+	if err != nil || !ok || twoFactor.Secret != saved.Secret || !twoFactor.Enabled || twoFactor.LastUsedStep != 42 ||
+		!slices.Equal(twoFactor.RecoveryCodes, saved.RecoveryCodes) {
+		t.Errorf("GetTwoFactor = %+v, %v, %v; want %+v", twoFactor, ok, err, saved)
+	}
+	for _, step := range []int64{42, 41} {
+		if used, err := storage.UseTwoFactorStep(alice.ID, step); err != nil || used {
+			t.Errorf("UseTwoFactorStep(%d) after step 42 = %v, %v", step, used, err)
+		}
+	}
+	if used, err := storage.UseTwoFactorStep(alice.ID, 43); err != nil || !used {
+		t.Errorf("UseTwoFactorStep(43) = %v, %v", used, err)
+	}
+	if used, err := storage.UseRecoveryCode(alice.ID, "a"); err != nil || used {
+		t.Errorf("UseRecoveryCode of a used code = %v, %v", used, err)
+	}
+	if used, err := storage.UseRecoveryCode(alice.ID, "b"); err != nil || !used {
+		t.Errorf("UseRecoveryCode = %v, %v", used, err)
+	}
+	if used, err := storage.UseRecoveryCode(alice.ID, "b"); err != nil || used {
+		t.Errorf("UseRecoveryCode used a code twice: %v", err)
+	}
+	twoFactor, _, _ = storage.GetTwoFactor(alice.ID)
+	if twoFactor.LastUsedStep != 43 || len(twoFactor.RecoveryCodes) != 0 || twoFactor.Secret != saved.Secret {
+		t.Errorf("GetTwoFactor after using codes = %+v", twoFactor)
+	}
+
+	apiKey := usermodel.APIKey{ID: uuid.New(), UserID: alice.ID, Name: "key", KeyHash: []byte("key"), Scopes: []string{"stats:read"},
+		CreationDate: contractNow()}
+	if err := storage.AddAPIKey(apiKey); err != nil {
+		t.Fatalf("AddAPIKey failed: %v", err)
+	}
+	if err := storage.DeleteTwoFactor(alice.ID); err != nil {
+		t.Fatalf("DeleteTwoFactor failed: %v", err)
+	}
+	if _, ok, err := storage.GetTwoFactor(alice.ID); err != nil || ok {
+		t.Errorf("GetTwoFactor after DeleteTwoFactor = %v, %v", ok, err)
+	}
+	if _, ok, _ := storage.GetAPIKeyByHash(apiKey.KeyHash); !ok {
+		t.Errorf("DeleteTwoFactor deleted the API keys of the user")
+	}
+}
+
+func testStorageLoginAttempts(t *testing.T, storage smimpl.Storage) {
+	now := contractNow()
+	if attempts, err := storage.GetLoginAttempts("login:alice"); err != nil || attempts.Failures != 0 {
+		t.Errorf("GetLoginAttempts of a new key = %+v, %v", attempts, err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := storage.AddLoginFailure("login:alice", now.Add(time.Duration(i)*time.Second), now.Add(-time.Hour)); err != nil {
+			t.Fatalf("AddLoginFailure failed: %v", err)
+		}
+	}
+	attempts, err := storage.GetLoginAttempts("login:alice")
+	if err != nil || attempts.Failures != 2 || !attempts.LastFailure.Equal(now.Add(time.Second)) {
+		t.Errorf("GetLoginAttempts = %+v, %v; want 2 failures at %v", attempts, err, now.Add(time.Second))
+	}
+	if err := storage.AddLoginFailure("login:alice", now.Add(time.Hour), now.Add(time.Minute)); err != nil {
+		t.Fatalf("AddLoginFailure failed: %v", err)
+	}
+	if attempts, _ := storage.GetLoginAttempts("login:alice"); attempts.Failures != 1 {
+		t.Errorf("failures after the reset period = %d; want 1", attempts.Failures)
+	}
+	if err := storage.ResetLoginAttempts("login:alice"); err != nil {
+		t.Fatalf("ResetLoginAttempts failed: %v", err)
+	}
+	if attempts, _ := storage.GetLoginAttempts("login:alice"); attempts.Failures != 0 {
+		t.Errorf("failures after ResetLoginAttempts = %d", attempts.Failures)
+	}
+
+	for key, failedAt := range map[string]time.Time{"login:alice": now, "ip:203.0.113.10": now.Add(time.Hour)} {
+		if err := storage.AddLoginFailure(key, failedAt, now.Add(-time.Hour)); err != nil {
+			t.Fatalf("AddLoginFailure failed: %v", err)
+		}
+	}
+	if err := storage.DeleteLoginAttempts(now.Add(time.Minute)); err != nil {
+		t.Fatalf("DeleteLoginAttempts failed: %v", err)
+	}
+	if attempts, _ := storage.GetLoginAttempts("login:alice"); attempts.Failures != 0 {
+		t.Errorf("DeleteLoginAttempts kept a stale key: %+v", attempts)
+	}
+	if attempts, _ := storage.GetLoginAttempts("ip:203.0.113.10"); attempts.Failures != 1 {
+		t.Errorf("DeleteLoginAttempts dropped a recent key: %+v", attempts)
+	}
+}
+
+func testStorageAPIKeys(t *testing.T, storage smimpl.Storage) {
+	alice := addContractUser(t, storage, "aliceSmith", "alice@example.com")
+	bob := addContractUser(t, storage, "bobJones", "bob@example.com")
+	now := contractNow()
+	keys := []usermodel.APIKey{
+		{ID: uuid.New(), UserID: alice.ID, Name: "second", KeyHash: []byte("second"), Scopes: []string{"stats:read"}, CreationDate: now.Add(time.Second)},
+		{ID: uuid.New(), UserID: alice.ID, Name: "first", KeyHash: []byte("first"), Scopes: []string{"promos:write", "stats:read"}, CreationDate: now},
+	}
+	for _, key := range keys {
+		if err := storage.AddAPIKey(key); err != nil {
+			t.Fatalf("AddAPIKey failed: %v", err)
+		}
+	}
+	listed, err := storage.GetUserAPIKeys(alice.ID)
+	if err != nil || len(listed) != 2 || listed[0].Name != "first" || listed[1].Name != "second" ||
+		!slices.Equal(listed[0].Scopes, keys[1].Scopes) {
+		t.Errorf("GetUserAPIKeys = %+v, %v; want the keys oldest first", listed, err)
+	}
+	if listed, err := storage.GetUserAPIKeys(bob.ID); err != nil || len(listed) != 0 {
+		t.Errorf("GetUserAPIKeys of another user = %d keys, %v", len(listed), err)
+	}
+	key, ok, err := storage.GetAPIKeyByHash([]byte("first"))
+	if err != nil || !ok || key.ID != keys[1].ID || key.UserID != alice.ID || !key.LastUsedDate.IsZero() {
+		t.Errorf("GetAPIKeyByHash = %+v, %v, %v", key, ok, err)
+	}
+	if err := storage.UpdateAPIKeyLastUsed(keys[1].ID, now); err != nil {
+		t.Fatalf("UpdateAPIKeyLastUsed failed: %v", err)
+	}
+	if key, _, _ := storage.GetAPIKeyByHash([]byte("first")); !key.LastUsedDate.Equal(now) {
+		t.Errorf("LastUsedDate = %v; want %v", key.LastUsedDate, now)
+	}
+	if deleted, err := storage.DeleteAPIKey(bob.ID, keys[1].ID); err != nil || deleted {
+		t.Errorf("DeleteAPIKey of another user = %v, %v; want false", deleted, err)
+	}
+	if deleted, err := storage.DeleteAPIKey(alice.ID, keys[1].ID); err != nil || !deleted {
+		t.Errorf("DeleteAPIKey = %v, %v; want true", deleted, err)
+	}
+	if _, ok, _ := storage.GetAPIKeyByHash([]byte("first")); ok {
+		t.Errorf("deleted API key is still found")
+	}
+}
+
+func testStorageSessions(t *testing.T, storage smimpl.Storage) {
+	alice := addContractUser(t, storage, "aliceSmith", "alice@example.com")
+	bob := addContractUser(t, storage, "bobJones", "bob@example.com")
+	now := contractNow()
+	sessions := []usermodel.Session{
+		{ID: uuid.New(), UserID: alice.ID, UserAgent: "curl", IP: "10.0.0.1", CreationDate: now, LastSeenDate: now, TokenGeneration: 3},
+		{ID: uuid.New(), UserID: alice.ID, UserAgent: "firefox", IP: "10.0.0.2", CreationDate: now, LastSeenDate: now.Add(time.Second)},
+	}
+	for _, session := range sessions {
+		if err := storage.AddSession(session); err != nil {
+			t.Fatalf("AddSession failed: %v", err)
+		}
+	}
+	token := usermodel.RefreshToken{ID: uuid.New(), UserID: alice.ID, SessionID: sessions[0].ID, TokenHash: []byte("token"),
+		ExpiresAt: now.Add(time.Hour), CreationDate: now}
+	if err := storage.AddRefreshToken(token); err != nil {
+		t.Fatalf("AddRefreshToken failed: %v", err)
+	}
+	session, ok, err := storage.GetSession(sessions[0].ID)
+	if err != nil || !ok || session.UserAgent != "curl" || session.IP != "10.0.0.1" || !session.LastSeenDate.Equal(now) ||
+		session.TokenGeneration != 3 {
+		t.Errorf("GetSession = %+v, %v, %v; want %+v", session, ok, err, sessions[0])
+	}
+	if _, ok, err := storage.GetSession(uuid.New()); err != nil || ok {
+		t.Errorf("GetSession of a missing session = %v, %v", ok, err)
+	}
+
+	if err := storage.UpdateSessionLastSeen(sessions[0].ID, now.Add(time.Minute)); err != nil {
+		t.Fatalf("UpdateSessionLastSeen failed: %v", err)
+	}
+	listed, err := storage.GetUserSessions(alice.ID)
+	if err != nil || len(listed) != 2 || listed[0].ID != sessions[0].ID || !listed[0].LastSeenDate.Equal(now.Add(time.Minute)) {
+		t.Errorf("GetUserSessions = %+v, %v; want the last seen first", listed, err)
+	}
+
+	if revoked, err := storage.RevokeSession(bob.ID, sessions[0].ID, now); err != nil || revoked {
+		t.Errorf("RevokeSession of another user = %v, %v; want false", revoked, err)
+	}
+	if revoked, err := storage.RevokeSession(alice.ID, sessions[0].ID, now); err != nil || !revoked {
+		t.Errorf("RevokeSession = %v, %v; want true", revoked, err)
+	}
+	if revoked, err := storage.RevokeSession(alice.ID, sessions[0].ID, now); err != nil || revoked {
+		t.Errorf("revoking a session twice = %v, %v; want false", revoked, err)
+	}
+	if session, _, _ := storage.GetSession(sessions[0].ID); !session.RevokedAt.Equal(now) {
+		t.Errorf("RevokedAt = %v; want %v", session.RevokedAt, now)
+	}
+	if token, _, _ := storage.GetRefreshToken([]byte("token")); token.RevokedAt.IsZero() {
+		t.Errorf("RevokeSession left the refresh token of the session active")
+	}
+}
+
+func testStorageAuditEvents(t *testing.T, storage smimpl.Storage) {
+	alice, bob := uuid.New(), uuid.New()
+	now := contractNow()
+	events := []usermodel.AuditEvent{
+		{ID: uuid.New(), UserID: alice, Login: "aliceSmith", Action: usermodel.AuditRegister, Outcome: usermodel.AuditSuccess,
+			CreationDate: now},
+		{ID: uuid.New(), UserID: bob, Login: "bobJones", Action: usermodel.AuditLogin, Outcome: usermodel.AuditFailure,
+			CreationDate: now.Add(time.Second)},
+		{ID: uuid.New(), ActorID: bob, UserID: alice, Login: "aliceSmith", Action: usermodel.AuditProfileUpdate, IP: "10.0.0.1",
+			Outcome: usermodel.AuditSuccess, Changes: []usermodel.FieldChange{{Field: "first_name", Old: "", New: "Alice"}},
+			CreationDate: now.Add(time.Second * 2)},
+		{ID: uuid.New(), Login: "nobody", Action: usermodel.AuditLogin, Outcome: usermodel.AuditFailure, CreationDate: now.Add(time.Second * 3)},
+	}
+	for _, event := range events {
+		if err := storage.AddAuditEvent(event); err != nil {
+			t.Fatalf("AddAuditEvent failed: %v", err)
+		}
+	}
+	ids := func(events []usermodel.AuditEvent) []uuid.UUID {
+		var result []uuid.UUID
+		for _, event := range events {
+			result = append(result, event.ID)
+		}
+		return result
+	}
+	filters := []struct {
+		filter   usermodel.AuditFilter
+		expected []uuid.UUID
+	}{
+		{usermodel.AuditFilter{Limit: 10}, []uuid.UUID{events[3].ID, events[2].ID, events[1].ID, events[0].ID}},
+		{usermodel.AuditFilter{Limit: 2}, []uuid.UUID{events[3].ID, events[2].ID}},
+		{usermodel.AuditFilter{UserID: alice, Limit: 10}, []uuid.UUID{events[2].ID, events[0].ID}},
+		{usermodel.AuditFilter{From: now.Add(time.Second), To: now.Add(time.Second * 3), Limit: 10}, []uuid.UUID{events[2].ID, events[1].ID}},
+	}
+	for _, test := range filters {
+		found, err := storage.GetAuditEvents(test.filter)
+		if err != nil || !slices.Equal(ids(found), test.expected) {
+			t.Errorf("GetAuditEvents(%+v) = %v, %v; want %v", test.filter, ids(found), err, test.expected)
+		}
+	}
+
+	found, _ := storage.GetAuditEvents(usermodel.AuditFilter{UserID: alice, Limit: 1})
+	if len(found) != 1 || found[0].ActorID != bob || found[0].IP != "10.0.0.1" || found[0].Action != usermodel.AuditProfileUpdate ||
+		!slices.Equal(found[0].Changes, events[2].Changes) || !found[0].CreationDate.Equal(events[2].CreationDate) {
+		t.Errorf("GetAuditEvents = %+v; want %+v", found, events[2])
+	}
+	found, _ = storage.GetAuditEvents(usermodel.AuditFilter{Limit: 1})
+	if len(found) != 1 || found[0].UserID != uuid.Nil || found[0].ActorID != uuid.Nil || found[0].Changes != nil {
+		t.Errorf("GetAuditEvents of an event without users = %+v", found)
+	}
+}
+
+func testStorageOutbox(t *testing.T, storage smimpl.Storage) {
+	now := contractNow()
+	for _, key := range []string{"first", "second", "third"} {
+		err := storage.AddOutboxMessage(usermodel.OutboxMessage{Topic: usermodel.OutboxStatsTopic, Key: key, Value: []byte(`{}`),
+			CreationDate: now})
+		if err != nil {
+			t.Fatalf("AddOutboxMessage failed: %v", err)
+		}
+	}
+	err := storage.Transaction(func(tx smimpl.Storage) error {
+		if err := tx.AddOutboxMessage(usermodel.OutboxMessage{Topic: usermodel.OutboxStatsTopic, Key: "rolled back",
+			Value: []byte(`{}`), CreationDate: now}); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	if err == nil {
+		t.Fatalf("Transaction ignored the error")
+	}
+
+	messages, err := storage.GetOutboxMessages(2)
+	if err != nil || len(messages) != 2 || messages[0].Key != "first" || messages[1].Key != "second" ||
+		messages[0].ID >= messages[1].ID || messages[0].Topic != usermodel.OutboxStatsTopic ||
+		string(messages[0].Value) != `{}` || !messages[0].CreationDate.Equal(now) {
+		t.Fatalf("GetOutboxMessages(2) = %+v, %v; want the first two in order", messages, err)
+	}
+	if err := storage.DeleteOutboxMessages([]int64{messages[0].ID, messages[1].ID}); err != nil {
+		t.Fatalf("DeleteOutboxMessages failed: %v", err)
+	}
+	messages, _ = storage.GetOutboxMessages(10)
+	if len(messages) != 1 || messages[0].Key != "third" {
+		t.Errorf("GetOutboxMessages after deleting = %+v; want only the third", messages)
+	}
+}
+
+func testStoragePhoneVerification(t *testing.T, storage smimpl.Storage) {
+	alice := addContractUser(t, storage, "aliceSmith", "alice@example.com")
+	if _, ok, err := storage.GetPhoneVerification(alice.ID); err != nil || ok {
+		t.Errorf("GetPhoneVerification before saving = %v, %v", ok, err)
+	}
+	saved := usermodel.PhoneVerification{UserID: alice.ID, PhoneNumber: "+79001234567", CodeHash: []byte("code"),
+		ExpiresAt: contractNow().Add(time.Minute), CreationDate: contractNow()}
+	if err := storage.SavePhoneVerification(saved); err != nil {
+		t.Fatalf("SavePhoneVerification failed: %v", err)
+	}
+	saved.Attempts = 3
+	if err := storage.SavePhoneVerification(saved); err != nil {
+		t.Fatalf("updating the phone verification failed: %v", err)
+	}
+	verification, ok, err := storage.GetPhoneVerification(alice.ID)
+	if err != nil || !ok || verification.PhoneNumber != saved.PhoneNumber || string(verification.CodeHash) != "code" ||
+		verification.Attempts != 3 || !verification.ExpiresAt.Equal(saved.ExpiresAt) {
+		t.Errorf("GetPhoneVerification = %+v, %v, %v; want %+v", verification, ok, err, saved)
+	}
+	if err := storage.DeletePhoneVerification(alice.ID); err != nil {
+		t.Fatalf("DeletePhoneVerification failed: %v", err)
+	}
+	if _, ok, err := storage.GetPhoneVerification(alice.ID); err != nil || ok {
+		t.Errorf("GetPhoneVerification after deleting = %v, %v", ok, err)
+	}
+}
+
+// testStorageOrganisations checks memberships and invitations go with the
+// organisation, and a membership with its user.
+func testStorageOrganisations(t *testing.T, storage smimpl.Storage) {
+	alice := addContractUser(t, storage, "aliceSmith", "alice@example.com")
+	bob := addContractUser(t, storage, "bobJones", "bob@example.com")
+	carol := addContractUser(t, storage, "carolWhite", "carol@example.com")
+	now := contractNow()
+	organisation := usermodel.Organisation{ID: uuid.New(), Name: "Acme", CreationDate: now, UpdateDate: now}
+	if err := storage.AddOrganisation(organisation); err != nil {
+		t.Fatalf("AddOrganisation failed: %v", err)
+	}
+	if found, ok, err := storage.GetOrganisation(organisation.ID); err != nil || !ok || found.Name != "Acme" ||
+		!found.CreationDate.Equal(now) {
+		t.Errorf("GetOrganisation = %+v, %v, %v", found, ok, err)
+	}
+	if _, ok, err := storage.GetOrganisation(uuid.New()); err != nil || ok {
+		t.Errorf("GetOrganisation of a missing organisation = %v, %v", ok, err)
+	}
+	if err := storage.UpdateOrganisation(usermodel.Organisation{ID: organisation.ID, Name: "Acme Corp", UpdateDate: now.Add(time.Second)}); err != nil {
+		t.Fatalf("UpdateOrganisation failed: %v", err)
+	}
+	if found, _, _ := storage.GetOrganisation(organisation.ID); found.Name != "Acme Corp" || !found.UpdateDate.Equal(now.Add(time.Second)) ||
+		!found.CreationDate.Equal(now) {
+		t.Errorf("organisation after UpdateOrganisation = %+v", found)
+	}
+
+	members := []usermodel.OrganisationMember{
+		{OrganisationID: organisation.ID, UserID: alice.ID, Role: usermodel.OrganisationRoleOwner, CreationDate: now},
+		{OrganisationID: organisation.ID, UserID: bob.ID, Role: usermodel.OrganisationRoleMember, CreationDate: now.Add(time.Second)},
+	}
+	for _, member := range members {
+		if err := storage.SaveMembership(member); err != nil {
+			t.Fatalf("SaveMembership failed: %v", err)
+		}
+	}
+	secondOwner := usermodel.OrganisationMember{OrganisationID: organisation.ID, UserID: carol.ID,
+		Role: usermodel.OrganisationRoleOwner, CreationDate: now}
+	if err := storage.SaveMembership(secondOwner); err == nil {
+		t.Errorf("SaveMembership accepted a second owner")
+	}
+	members[1].Role = usermodel.OrganisationRoleAdmin
+	if err := storage.SaveMembership(members[1]); err != nil {
+		t.Fatalf("SaveMembership of an existing member failed: %v", err)
+	}
+	if member, ok, err := storage.GetMembership(bob.ID); err != nil || !ok || member.Role != usermodel.OrganisationRoleAdmin ||
+		member.OrganisationID != organisation.ID {
+		t.Errorf("GetMembership = %+v, %v, %v", member, ok, err)
+	}
+	if _, ok, err := storage.GetMembership(carol.ID); err != nil || ok {
+		t.Errorf("GetMembership of a non-member = %v, %v", ok, err)
+	}
+	listed, err := storage.GetOrganisationMembers(organisation.ID)
+	if err != nil || len(listed) != 2 || listed[0].UserID != alice.ID || listed[1].UserID != bob.ID {
+		t.Errorf("GetOrganisationMembers = %+v, %v; want the members oldest first", listed, err)
+	}
+
+	invitations := []usermodel.OrganisationInvitation{
+		{ID: uuid.New(), OrganisationID: organisation.ID, Email: "old@example.com", Role: usermodel.OrganisationRoleMember,
+			InvitedBy: alice.ID, TokenHash: []byte("expired"), ExpiresAt: now.Add(-time.Hour), CreationDate: now.Add(-time.Hour * 2)},
+		{ID: uuid.New(), OrganisationID: organisation.ID, Email: "carol@example.com", Role: usermodel.OrganisationRoleAdmin,
+			InvitedBy: alice.ID, TokenHash: []byte("pending"), ExpiresAt: now.Add(time.Hour), CreationDate: now},
+	}
+	for _, invitation := range invitations {
+		if err := storage.AddInvitation(invitation); err != nil {
+			t.Fatalf("AddInvitation failed: %v", err)
+		}
+	}
+	invitation, ok, err := storage.GetInvitationByHash([]byte("pending"))
+	if err != nil || !ok || invitation.ID != invitations[1].ID || invitation.Role != usermodel.OrganisationRoleAdmin ||
+		invitation.InvitedBy != alice.ID || !invitation.ExpiresAt.Equal(invitations[1].ExpiresAt) {
+		t.Errorf("GetInvitationByHash = %+v, %v, %v", invitation, ok, err)
+	}
+	if listed, err := storage.GetOrganisationInvitations(organisation.ID); err != nil || len(listed) != 1 {
+		t.Errorf("GetOrganisationInvitations = %+v, %v; want the expired invitation dropped", listed, err)
+	}
+	if deleted, err := storage.DeleteInvitation(uuid.New(), invitations[1].ID); err != nil || deleted {
+		t.Errorf("DeleteInvitation of another organisation = %v, %v; want false", deleted, err)
+	}
+
+	if err := storage.DeleteUser(bob.ID); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+	if _, ok, _ := storage.GetMembership(bob.ID); ok {
+		t.Errorf("membership of a deleted user is kept")
+	}
+	if err := storage.DeleteMembership(alice.ID); err != nil {
+		t.Fatalf("DeleteMembership failed: %v", err)
+	}
+	if err := storage.SaveMembership(secondOwner); err != nil {
+		t.Fatalf("SaveMembership of a new owner failed: %v", err)
+	}
+	if err := storage.DeleteOrganisation(organisation.ID); err != nil {
+		t.Fatalf("DeleteOrganisation failed: %v", err)
+	}
+	if _, ok, _ := storage.GetMembership(carol.ID); ok {
+		t.Errorf("membership of a deleted organisation is kept")
+	}
+	if _, ok, _ := storage.GetInvitationByHash([]byte("pending")); ok {
+		t.Errorf("invitation of a deleted organisation is kept")
+	}
+	if user, _ := storage.GetUserById(carol.ID); user.Login == "" {
+		t.Errorf("member account was deleted with the organisation")
+	}
+}
@@ -0,0 +1,151 @@
+package tests
+
+import (
+	authmailer "authservice/auth_mailer"
+	authsms "authservice/auth_sms"
+	loginthrottle "authservice/auth_storage/login_throttle"
+	mockstorage "authservice/auth_storage/mock_storage"
+	passwordhasher "authservice/auth_storage/password_hasher"
+	smimpl "authservice/auth_storage/storage_manager"
+	twofactor "authservice/auth_storage/two_factor"
+	usermodel "authservice/auth_storage/user_model"
+	"testing"
+)
+
+const (
+	testPublicURL = "http://localhost:8081"
+	testPassword  = "ValidPass123"
+)
+
+var testClient = usermodel.ClientInfo{IP: "203.0.113.10", UserAgent: "Mozilla/5.0 (X11; Linux x86_64)"}
+
+// testEnv is a storage manager together with its storage and the fake it
+// sends mail to.
+type testEnv struct {
+	sm      usermodel.StorageManager
+	storage smimpl.Storage
+	mailer  *authmailer.MemoryMailer
+}
+
+type testUser struct {
+	login, email string
+	isCompany    bool
+	role         usermodel.Role
+}
+
+type testConfig struct {
+	storage         smimpl.Storage
+	hasher          passwordhasher.Hasher
+	twoFactorPolicy twofactor.Policy
+	throttlePolicy  loginthrottle.Policy
+	smsSender       authsms.Sender
+	users           []testUser
+}
+
+type testOption func(*testConfig)
+
+func withStorage(storage smimpl.Storage) testOption {
+	return func(c *testConfig) { c.storage = storage }
+}
+
+func withHasher(hasher passwordhasher.Hasher) testOption {
+	return func(c *testConfig) { c.hasher = hasher }
+}
+
+func withTwoFactorPolicy(policy twofactor.Policy) testOption {
+	return func(c *testConfig) { c.twoFactorPolicy = policy }
+}
+
+func withThrottlePolicy(policy loginthrottle.Policy) testOption {
+	return func(c *testConfig) { c.throttlePolicy = policy }
+}
+
+func withSMSSender(sender authsms.Sender) testOption {
+	return func(c *testConfig) { c.smsSender = sender }
+}
+
+// withUser registers an account with testPassword. Company accounts own a
+// new organisation.
+func withUser(login, email string, isCompany bool) testOption {
+	return func(c *testConfig) {
+		c.users = append(c.users, testUser{login: login, email: email, isCompany: isCompany})
+	}
+}
+
+// withPlatformAdmin registers an account and makes it a platform admin.
+func withPlatformAdmin(login, email string) testOption {
+	return func(c *testConfig) {
+		c.users = append(c.users, testUser{login: login, email: email, role: usermodel.RolePlatformAdmin})
+	}
+}
+
+var (
+	withTokenUser   = withUser("tokenUser", "token@example.com", false)
+	withCompanyUser = withUser("companyUser", "company@example.com", true)
+	withAdminUser   = withPlatformAdmin("adminUser", "admin@example.com")
+)
+
+// newTestEnv builds a storage manager over a mock storage unless options say
+// otherwise, and registers the users given with withUser in order.
+func newTestEnv(t *testing.T, options ...testOption) testEnv {
+	t.Helper()
+	config := testConfig{
+		storage:        mockstorage.NewStorage(),
+		hasher:         passwordhasher.NewArgon2idHasher(testArgon2idParams),
+		throttlePolicy: loginthrottle.DefaultPolicy,
+	}
+	for _, option := range options {
+		option(&config)
+	}
+	var smsNotifier *authsms.Notifier
+	if config.smsSender != nil {
+		smsNotifier = authsms.NewNotifier(config.smsSender)
+	}
+	env := testEnv{storage: config.storage, mailer: authmailer.NewMemoryMailer()}
+	env.sm = smimpl.NewStorageManager(config.storage, config.hasher, authmailer.NewNotifier(env.mailer, testPublicURL),
+		config.twoFactorPolicy, config.throttlePolicy, smsNotifier)
+	for _, user := range config.users {
+		created := env.createUser(t, user.login, user.email, user.isCompany)
+		if user.role != "" {
+			env.setRole(t, created, user.role)
+		}
+	}
+	return env
+}
+
+func (env testEnv) createUser(t *testing.T, login, email string, isCompany bool) usermodel.User {
+	t.Helper()
+	user, err := env.sm.CreateUser(login, testPassword, email, isCompany, testClient)
+	if err != nil {
+		t.Fatalf("CreateUser(%q) failed: %v", login, err)
+	}
+	return user
+}
+
+// setRole changes the role directly in the storage, bypassing the audit log
+// and token revocation.
+func (env testEnv) setRole(t *testing.T, user usermodel.User, role usermodel.Role) {
+	t.Helper()
+	user.Role = role
+	if err := env.storage.UpdateUser(user); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+}
+
+func (env testEnv) login(t *testing.T, login string) usermodel.TokenPair {
+	t.Helper()
+	tokens, err := env.sm.GetJWTByCredentials(login, testPassword, testClient)
+	if err != nil || tokens.AccessToken == "" {
+		t.Fatalf("GetJWTByCredentials(%q) = %+v, %v", login, tokens, err)
+	}
+	return tokens
+}
+
+func (env testEnv) user(t *testing.T, login string) usermodel.User {
+	t.Helper()
+	user, err := env.storage.GetUserByLogin(login)
+	if err != nil || user.Login == "" {
+		t.Fatalf("GetUserByLogin(%q) = %+v, %v", login, user, err)
+	}
+	return user
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	usermodel "authservice/auth_storage/user_model"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestRefreshRotatesToken(t *testing.T) {
+	sm := newTestEnv(t, withTokenUser).sm
+
+	tokens, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if err != nil || tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatalf("GetJWTByCredentials failed: %+v, %v", tokens, err)
+	}
+	if !tokens.AccessExpiresAt.Before(tokens.RefreshExpiresAt) {
+		t.Errorf("access token outlives refresh token")
+	}
+
+	refreshed, err := sm.RefreshJWT(tokens.RefreshToken, testClient)
+	if err != nil || refreshed.AccessToken == "" {
+		t.Fatalf("RefreshJWT failed: %v", err)
+	}
+	if refreshed.RefreshToken == tokens.RefreshToken {
+		t.Errorf("RefreshJWT did not rotate the refresh token")
+	}
+
+	if reused, err := sm.RefreshJWT(tokens.RefreshToken, testClient); err != nil || reused.AccessToken != "" {
+		t.Errorf("RefreshJWT accepted a rotated token: %v", err)
+	}
+	if afterReuse, err := sm.RefreshJWT(refreshed.RefreshToken, testClient); err != nil || afterReuse.AccessToken != "" {
+		t.Errorf("token reuse did not revoke the token family: %v", err)
+	}
+}
+
+func TestConcurrentRefreshRotatesOnce(t *testing.T) {
+	env := newTestEnv(t, withTokenUser)
+	tokens := env.login(t, "tokenUser")
+
+	results := make([]usermodel.TokenPair, 10)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			refreshed, err := env.sm.RefreshJWT(tokens.RefreshToken, testClient)
+			if err != nil {
+				t.Errorf("RefreshJWT failed: %v", err)
+			}
+			results[i] = refreshed
+		}()
+	}
+	wg.Wait()
+	issued := 0
+	for _, refreshed := range results {
+		if refreshed.AccessToken != "" {
+			issued++
+		}
+	}
+	if issued != 1 {
+		t.Errorf("one refresh token was rotated %d times", issued)
+	}
+}
+
+func TestLogoutRevokesTokens(t *testing.T) {
+	sm := newTestEnv(t, withTokenUser).sm
+
+	tokens, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient)
+	if err != nil {
+		t.Fatalf("GetJWTByCredentials failed: %v", err)
+	}
+	if user, err := sm.GetUserByJWT(tokens.AccessToken); err != nil || user.Login != "tokenUser" {
+		t.Fatalf("GetUserByJWT failed: %+v, %v", user, err)
+	}
+
+	if ok, err := sm.Logout(tokens.AccessToken, tokens.RefreshToken); err != nil || !ok {
+		t.Fatalf("Logout failed: %v, %v", ok, err)
+	}
+	if user, err := sm.GetUserByJWT(tokens.AccessToken); err != nil || user.Login != "" {
+		t.Errorf("revoked JWT is still accepted: %+v, %v", user, err)
+	}
+	if refreshed, err := sm.RefreshJWT(tokens.RefreshToken, testClient); err != nil || refreshed.AccessToken != "" {
+		t.Errorf("refresh token survived logout: %v", err)
+	}
+	if ok, err := sm.Logout(tokens.AccessToken, ""); err != nil || ok {
+		t.Errorf("Logout accepted a revoked JWT: %v, %v", ok, err)
+	}
+}
+
+func TestLoginByEmail(t *testing.T) {
+	sm := newTestEnv(t, withTokenUser).sm
+	for _, identifier := range []string{"token@example.com", "Token@Example.COM"} {
+		tokens, err := sm.GetJWTByCredentials(identifier, "ValidPass123", testClient)
+		if err != nil || tokens.AccessToken == "" {
+			t.Errorf("login with %q failed: %v", identifier, err)
+		}
+	}
+	if tokens, err := sm.GetJWTByCredentials("token@example.com", "WrongPass123", testClient); err != nil || tokens.AccessToken != "" {
+		t.Errorf("login by email accepted a wrong password: %v", err)
+	}
+	if tokens, err := sm.GetJWTByCredentials("missing@example.com", "ValidPass123", testClient); err != nil || tokens.AccessToken != "" {
+		t.Errorf("login with an unknown email succeeded: %v", err)
+	}
+
+	if _, err := sm.CreateUser("other@example.com", "OtherPass123", "other.user@example.com", false, testClient); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if tokens, err := sm.GetJWTByCredentials("other@example.com", "OtherPass123", testClient); err != nil || tokens.AccessToken == "" {
+		t.Errorf("a login that looks like an email is not preferred: %v", err)
+	}
+}
+
+func TestLoginByEmailSharesLockout(t *testing.T) {
+	sm := newTestEnv(t, withThrottlePolicy(testThrottlePolicy), withTokenUser).sm
+	for _, identifier := range []string{"token@example.com", "TOKEN@example.com", "tokenUser"} {
+		sm.GetJWTByCredentials(identifier, "WrongPass123", testClient)
+	}
+	if _, err := sm.GetJWTByCredentials("tokenUser", "ValidPass123", testClient); !errors.Is(err, usermodel.ErrTooManyLoginAttempts) {
+		t.Errorf("failures by email do not count towards the login lockout: %v", err)
+	}
+}
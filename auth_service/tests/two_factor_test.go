@@ -0,0 +1,210 @@
+package tests
+
+import (
+	twofactor "authservice/auth_storage/two_factor"
+	userkeys "authservice/auth_storage/user_keys"
+	usermodel "authservice/auth_storage/user_model"
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	code, err := totp.GenerateCode(secret, at)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+	return code
+}
+
+// enableTwoFactor returns the secret, the recovery codes and the tokens that
+// replace jwt.
+func enableTwoFactor(t *testing.T, sm usermodel.StorageManager, jwt string, at time.Time) (string, []string, usermodel.TokenPair) {
+	enrollment, err := sm.EnrollTwoFactor(jwt)
+	if err != nil || enrollment.Secret == "" {
+		t.Fatalf("EnrollTwoFactor failed: %v", err)
+	}
+	if _, _, err := sm.ConfirmTwoFactor(jwt, "000000", testClient); !errors.Is(err, usermodel.ErrWrongTwoFactorCode) {
+		t.Errorf("ConfirmTwoFactor accepted a wrong code: %v", err)
+	}
+	recoveryCodes, tokens, err := sm.ConfirmTwoFactor(jwt, totpCode(t, enrollment.Secret, at), testClient)
+	if err != nil || tokens.AccessToken == "" {
+		t.Fatalf("ConfirmTwoFactor failed: %v", err)
+	}
+	if len(recoveryCodes) != twofactor.RecoveryCodeCount {
+		t.Fatalf("expected %d recovery codes, got %d", twofactor.RecoveryCodeCount, len(recoveryCodes))
+	}
+	return enrollment.Secret, recoveryCodes, tokens
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	sm := newTestEnv(t, withCompanyUser).sm
+
+	tokens, err := sm.GetJWTByCredentials("companyUser", "ValidPass123", testClient)
+	if err != nil || tokens.AccessToken == "" || tokens.TwoFactorSetupRequired {
+		t.Fatalf("GetJWTByCredentials failed: %+v, %v", tokens, err)
+	}
+	otherDevice, _ := sm.GetJWTByCredentials("companyUser", "ValidPass123", testClient)
+	confirmedAt := time.Now()
+	secret, recoveryCodes, enabled := enableTwoFactor(t, sm, tokens.AccessToken, confirmedAt)
+	for _, old := range []usermodel.TokenPair{tokens, otherDevice} {
+		if user, err := sm.GetUserByJWT(old.AccessToken); err != nil || user.Login != "" {
+			t.Errorf("JWT issued before enabling 2FA is still accepted: %v", err)
+		}
+		if refreshed, err := sm.RefreshJWT(old.RefreshToken, testClient); err != nil || refreshed.AccessToken != "" {
+			t.Errorf("refresh token issued before enabling 2FA is still accepted: %v", err)
+		}
+	}
+	if user, _ := sm.GetUserByJWT(enabled.AccessToken); !user.TwoFactorEnabled {
+		t.Errorf("profile does not report two-factor authentication")
+	}
+	if _, err := sm.EnrollTwoFactor(enabled.AccessToken); !errors.Is(err, usermodel.ErrTwoFactorAlreadyEnabled) {
+		t.Errorf("EnrollTwoFactor replaced an enabled secret: %v", err)
+	}
+
+	challenge, err := sm.GetJWTByCredentials("companyUser", "ValidPass123", testClient)
+	if err != nil || challenge.AccessToken != "" || challenge.TwoFactorChallenge == "" {
+		t.Fatalf("login with 2FA did not return a challenge: %+v, %v", challenge, err)
+	}
+	if user, err := sm.GetUserByJWT(challenge.TwoFactorChallenge); err != nil || user.Login != "" {
+		t.Errorf("challenge is accepted as an access token: %v", err)
+	}
+	if tokens, err := sm.LoginTwoFactor(challenge.TwoFactorChallenge, totpCode(t, secret, confirmedAt), testClient); err != nil || tokens.AccessToken != "" {
+		t.Errorf("LoginTwoFactor accepted a replayed code: %v", err)
+	}
+	if tokens, err := sm.LoginTwoFactor(challenge.TwoFactorChallenge, totpCode(t, secret, time.Now().Add(30*time.Second)), testClient); err != nil || tokens.AccessToken != "" {
+		t.Errorf("challenge is reusable after a wrong code: %v", err)
+	}
+
+	challenge, _ = sm.GetJWTByCredentials("companyUser", "ValidPass123", testClient)
+	nextCode := totpCode(t, secret, time.Now().Add(30*time.Second))
+	if tokens, err := sm.LoginTwoFactor(challenge.TwoFactorChallenge, nextCode, testClient); err != nil || tokens.AccessToken == "" {
+		t.Fatalf("LoginTwoFactor failed: %v", err)
+	}
+	if tokens, err := sm.LoginTwoFactor(challenge.TwoFactorChallenge, nextCode, testClient); err != nil || tokens.AccessToken != "" {
+		t.Errorf("challenge is reusable: %v", err)
+	}
+
+	challenge, _ = sm.GetJWTByCredentials("companyUser", "ValidPass123", testClient)
+	if tokens, err := sm.LoginTwoFactor(challenge.TwoFactorChallenge, recoveryCodes[0], testClient); err != nil || tokens.AccessToken == "" {
+		t.Fatalf("LoginTwoFactor rejected a recovery code: %v", err)
+	}
+	challenge, _ = sm.GetJWTByCredentials("companyUser", "ValidPass123", testClient)
+	if tokens, err := sm.LoginTwoFactor(challenge.TwoFactorChallenge, recoveryCodes[0], testClient); err != nil || tokens.AccessToken != "" {
+		t.Errorf("recovery code is reusable: %v", err)
+	}
+
+	if ok, err := sm.DisableTwoFactor(enabled.AccessToken, recoveryCodes[1], testClient); err != nil || !ok {
+		t.Fatalf("DisableTwoFactor failed: %v, %v", ok, err)
+	}
+	events, _, _ := sm.ListAuditEvents(enabled.AccessToken, usermodel.AuditFilter{Limit: 1})
+	if len(events) != 1 || events[0].Action != usermodel.AuditTwoFactorOff || events[0].Outcome != usermodel.AuditSuccess {
+		t.Errorf("audit log has %+v", events)
+	}
+	if tokens, err := sm.GetJWTByCredentials("companyUser", "ValidPass123", testClient); err != nil || tokens.AccessToken == "" {
+		t.Errorf("login still asks for 2FA after disabling it: %+v, %v", tokens, err)
+	}
+}
+
+// TestConcurrentTwoFactorCodeUsedOnce logs in with the same code from several
+// challenges at once, only one of them may succeed.
+func TestConcurrentTwoFactorCodeUsedOnce(t *testing.T) {
+	env := newTestEnv(t, withCompanyUser)
+	secret, _, _ := enableTwoFactor(t, env.sm, env.login(t, "companyUser").AccessToken, time.Now())
+	code := totpCode(t, secret, time.Now().Add(30*time.Second))
+
+	challenges := make([]string, 4)
+	for i := range challenges {
+		tokens, err := env.sm.GetJWTByCredentials("companyUser", "ValidPass123", testClient)
+		if err != nil || tokens.TwoFactorChallenge == "" {
+			t.Fatalf("login with 2FA did not return a challenge: %+v, %v", tokens, err)
+		}
+		challenges[i] = tokens.TwoFactorChallenge
+	}
+	results := make([]usermodel.TokenPair, len(challenges))
+	var wg sync.WaitGroup
+	for i, challenge := range challenges {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tokens, err := env.sm.LoginTwoFactor(challenge, code, testClient)
+			if err != nil {
+				t.Errorf("LoginTwoFactor failed: %v", err)
+			}
+			results[i] = tokens
+		}()
+	}
+	wg.Wait()
+	issued := 0
+	for _, tokens := range results {
+		if tokens.AccessToken != "" {
+			issued++
+		}
+	}
+	if issued != 1 {
+		t.Errorf("one code logged in %d times", issued)
+	}
+}
+
+func TestTwoFactorPolicy(t *testing.T) {
+	sm := newTestEnv(t, withTwoFactorPolicy(twofactor.Policy{RequiredForCompanies: true}), withCompanyUser,
+		withUser("customerUser", "customer@example.com", false)).sm
+
+	customer, err := sm.GetJWTByCredentials("customerUser", "ValidPass123", testClient)
+	if err != nil || customer.TwoFactorSetupRequired {
+		t.Errorf("policy applies to customers: %+v, %v", customer, err)
+	}
+	if _, err := sm.EnrollTwoFactor(customer.AccessToken); !errors.Is(err, usermodel.ErrTwoFactorUnavailable) {
+		t.Errorf("customer enrolled in 2FA: %v", err)
+	}
+
+	tokens, err := sm.GetJWTByCredentials("companyUser", "ValidPass123", testClient)
+	if err != nil || tokens.AccessToken == "" || !tokens.TwoFactorSetupRequired {
+		t.Fatalf("company login does not require 2FA setup: %+v, %v", tokens, err)
+	}
+	if claims, ok := userkeys.ParseJWT(tokens.AccessToken); !ok || !claims.TwoFactorSetupRequired {
+		t.Errorf("JWT does not carry the setup requirement")
+	}
+
+	secret, _, _ := enableTwoFactor(t, sm, tokens.AccessToken, time.Now())
+	challenge, _ := sm.GetJWTByCredentials("companyUser", "ValidPass123", testClient)
+	tokens, err = sm.LoginTwoFactor(challenge.TwoFactorChallenge, totpCode(t, secret, time.Now().Add(30*time.Second)), testClient)
+	if err != nil || tokens.AccessToken == "" || tokens.TwoFactorSetupRequired {
+		t.Fatalf("LoginTwoFactor failed: %+v, %v", tokens, err)
+	}
+	if _, err := sm.DisableTwoFactor(tokens.AccessToken, totpCode(t, secret, time.Now()), testClient); !errors.Is(err, usermodel.ErrTwoFactorRequired) {
+		t.Errorf("DisableTwoFactor ignored the policy: %v", err)
+	}
+}
+
+func TestSecretCipher(t *testing.T) {
+	if _, err := twofactor.NewSecretCipher(make([]byte, 16)); err == nil {
+		t.Errorf("NewSecretCipher accepted a short key")
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	cipher, err := twofactor.NewSecretCipher(key)
+	if err != nil {
+		t.Fatalf("NewSecretCipher failed: %v", err)
+	}
+
+	encrypted, err := cipher.Encrypt("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if bytes.Contains(encrypted, []byte("JBSWY3DPEHPK3PXP")) {
+		t.Errorf("secret is stored in plain text")
+	}
+	if secret, err := cipher.Decrypt(encrypted); err != nil || secret != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Decrypt failed: %q, %v", secret, err)
+	}
+	encrypted[len(encrypted)-1] ^= 1
+	if _, err := cipher.Decrypt(encrypted); err == nil {
+		t.Errorf("Decrypt accepted a tampered secret")
+	}
+}
@@ -1,7 +1,11 @@
 package tests
 
 import (
+	mockstorage "authservice/auth_storage/mock_storage"
+	smimpl "authservice/auth_storage/storage_manager"
 	usermodel "authservice/auth_storage/user_model"
+	"errors"
+	"sync"
 	"testing"
 
 	"github.com/google/uuid"
@@ -18,10 +22,11 @@ func TestNewUser(t *testing.T) {
 		{"validUser", "test@example.com", "ValidPass123", false, true},
 		{"usr", "test@example.com", "ValidPass123", false, false},
 		{"validUser", "test@example.com", "short", false, false},
+		{"validUser", "not-an-email", "ValidPass123", false, false},
 	}
 
 	for _, test := range tests {
-		user := usermodel.NewUser(test.login, test.email, test.password, test.isCompany)
+		user, _ := usermodel.NewUser(test.login, test.email, test.password, test.isCompany)
 		if (user != nil) != test.expected {
 			t.Errorf("NewUser(%q, %q, %q, %v) = %v; want %v", test.login, test.email, test.password, test.isCompany, user != nil, test.expected)
 		}
@@ -30,11 +35,12 @@ func TestNewUser(t *testing.T) {
 
 func TestMergeUserInfo(t *testing.T) {
 	oldUser := usermodel.User{
-		ID:          uuid.New(),
-		FirstName:   "Alice",
-		SecondName:  "Brown",
-		Email:       "alice.old@example.com",
-		PhoneNumber: "89991234567",
+		ID:            uuid.New(),
+		FirstName:     "Alice",
+		SecondName:    "Brown",
+		Email:         "alice.old@example.com",
+		EmailVerified: true,
+		PhoneNumber:   "89991234567",
 	}
 
 	newUser := usermodel.User{
@@ -43,11 +49,28 @@ func TestMergeUserInfo(t *testing.T) {
 		Email:      "bob.new@example.com",
 	}
 
-	updatedUser := usermodel.MergeUserInfo(oldUser, newUser)
-
+	updatedUser, err := usermodel.MergeUserInfo(oldUser, newUser)
+	if err != nil {
+		t.Fatalf("MergeUserInfo failed: %v", err)
+	}
 	if updatedUser.FirstName != "Bob" || updatedUser.SecondName != "Green" || updatedUser.Email != "bob.new@example.com" {
 		t.Errorf("MergeUserInfo did not correctly update fields")
 	}
+	if updatedUser.EmailVerified {
+		t.Errorf("MergeUserInfo kept email verified after email change")
+	}
+}
+
+func TestMergeUserInfoRejectsInvalidFields(t *testing.T) {
+	oldUser := usermodel.User{Email: "alice@example.com", PhoneNumber: "89991234567"}
+	_, err := usermodel.MergeUserInfo(oldUser, usermodel.User{FirstName: "Bob", Email: "not-an-email", PhoneNumber: "+7999"})
+	var validationErr *usermodel.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("MergeUserInfo accepted invalid fields: %v", err)
+	}
+	if len(validationErr.Violations) != 2 || validationErr.Violations[0].Field != "email" || validationErr.Violations[1].Field != "phone_number" {
+		t.Errorf("MergeUserInfo reported %+v", validationErr.Violations)
+	}
 }
 
 func TestFetchUserPublicInfo(t *testing.T) {
@@ -64,3 +87,56 @@ func TestFetchUserPublicInfo(t *testing.T) {
 		t.Errorf("FetchUserPublicInfo did not return correct public data")
 	}
 }
+
+func TestCreateUserConflicts(t *testing.T) {
+	sm := newTestEnv(t, withTokenUser).sm
+	if _, err := sm.CreateUser("tokenUser", "ValidPass123", "new@example.com", false, testClient); !errors.Is(err, usermodel.ErrUserExists) {
+		t.Errorf("duplicate login was registered: %v", err)
+	}
+	if _, err := sm.CreateUser("newUser", "ValidPass123", "Token@Example.com", false, testClient); !errors.Is(err, usermodel.ErrUserExists) {
+		t.Errorf("duplicate email was registered: %v", err)
+	}
+}
+
+func TestConcurrentRegistration(t *testing.T) {
+	sm := newTestEnv(t, withTokenUser).sm
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = sm.CreateUser("racingUser", "ValidPass123", "racing@example.com", false, testClient)
+		}()
+	}
+	wg.Wait()
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, usermodel.ErrUserExists):
+			t.Errorf("racing registration failed with %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("%d racing registrations succeeded", created)
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	storage := mockstorage.NewStorage()
+	errAbort := errors.New("abort")
+	err := storage.Transaction(func(tx smimpl.Storage) error {
+		if _, err := tx.AddUser(usermodel.User{Email: "rollback@example.com"}, "rollbackUser", []byte("hash")); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Transaction returned %v", err)
+	}
+	if user, _ := storage.GetUserByLogin("rollbackUser"); user.Login != "" || user.ID != uuid.Nil {
+		t.Errorf("user added in a failed transaction was kept: %+v", user)
+	}
+}
@@ -2,6 +2,8 @@ package tests
 
 import (
 	usermodel "authservice/auth_storage/user_model"
+	"errors"
+	"slices"
 	"testing"
 )
 
@@ -69,6 +71,8 @@ func TestIsValidPhoneNumber(t *testing.T) {
 		{"70001234567", false},
 		{"8phone12345", false},
 		{"81112223344", true},
+		{"", false},
+		{"+442071234567", true},
 	}
 
 	for _, test := range tests {
@@ -77,3 +81,35 @@ func TestIsValidPhoneNumber(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateNewUser(t *testing.T) {
+	err := usermodel.ValidateNewUser("usr", "not-an-email", "short")
+	var validationErr *usermodel.ValidationError
+	if !errors.As(err, &validationErr) || !errors.Is(err, usermodel.ErrInvalidUser) {
+		t.Fatalf("ValidateNewUser returned %v", err)
+	}
+	want := []usermodel.FieldViolation{
+		{Field: "login", Reason: usermodel.ReasonTooShort},
+		{Field: "password", Reason: usermodel.ReasonTooShort},
+		{Field: "password", Reason: usermodel.ReasonMissingDigit},
+		{Field: "password", Reason: usermodel.ReasonMissingUppercase},
+		{Field: "email", Reason: usermodel.ReasonInvalidFormat},
+	}
+	got := make([]usermodel.FieldViolation, 0, len(validationErr.Violations))
+	for _, violation := range validationErr.Violations {
+		if violation.Description == "" {
+			t.Errorf("violation of %s has no description", violation.Field)
+		}
+		got = append(got, usermodel.FieldViolation{Field: violation.Field, Reason: violation.Reason})
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ValidateNewUser reported %+v; want %+v", got, want)
+	}
+
+	if err := usermodel.ValidateNewUser("validUser", "test@example.com", "ValidPass123"); err != nil {
+		t.Errorf("ValidateNewUser rejected valid data: %v", err)
+	}
+	if err := usermodel.ValidatePassword("new_password", ""); !errors.Is(err, usermodel.ErrInvalidPassword) {
+		t.Errorf("ValidatePassword accepted an empty password: %v", err)
+	}
+}
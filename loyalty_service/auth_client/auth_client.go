@@ -0,0 +1,85 @@
+// Package authclient resolves the credentials the gateway forwards through
+// the auth service, which knows about revoked tokens, suspended users and API
+// keys.
+package authclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	protoauth "loyaltyservice/proto/auth"
+	accesspolicy "shared/access_policy"
+	ttlcache "shared/ttl_cache"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxPrincipalCacheSize = 100000
+
+// Authenticator keeps the principal of a JWT for the cache_ttl_seconds of its
+// introspection, so a request the gateway has just authenticated does not
+// cost another call to the auth service.
+type Authenticator struct {
+	client     protoauth.AuthServiceClient
+	principals *ttlcache.Cache[accesspolicy.Principal]
+}
+
+func NewAuthenticator(conn grpc.ClientConnInterface) *Authenticator {
+	return &Authenticator{client: protoauth.NewAuthServiceClient(conn),
+		principals: ttlcache.New[accesspolicy.Principal](maxPrincipalCacheSize)}
+}
+
+func (a *Authenticator) Authenticate(ctx context.Context, credentials accesspolicy.Credentials) (accesspolicy.Principal, error) {
+	if credentials.APIKey != "" {
+		return a.authenticateAPIKey(ctx, credentials.APIKey)
+	}
+	if credentials.JWT == "" {
+		return accesspolicy.Principal{}, status.Error(codes.Unauthenticated, "missing JWT")
+	}
+	// The token is not verified here, so it is cached by its hash rather than
+	// by its ID.
+	hash := sha256.Sum256([]byte(credentials.JWT))
+	key := hex.EncodeToString(hash[:])
+	now := time.Now()
+	principal, generation, ok := a.principals.Get(key, now)
+	if ok {
+		return principal, nil
+	}
+	introspection, err := a.client.IntrospectToken(ctx, &protoauth.IntrospectTokenRequest{Jwt: credentials.JWT})
+	if err != nil {
+		return accesspolicy.Principal{}, err
+	}
+	if !introspection.Active {
+		return accesspolicy.Principal{}, status.Error(codes.Unauthenticated, "token is not active")
+	}
+	principal = accesspolicy.Principal{
+		UserID:                 introspection.UserId,
+		Role:                   accesspolicy.Role(introspection.Role),
+		OrganisationID:         introspection.OrganisationId,
+		EmailVerified:          introspection.EmailVerified,
+		TwoFactorSetupRequired: introspection.TwoFactorSetupRequired,
+	}
+	a.principals.Put(generation, key, principal, time.Duration(introspection.CacheTtlSeconds)*time.Second, now)
+	return principal, nil
+}
+
+func (a *Authenticator) authenticateAPIKey(ctx context.Context, key string) (accesspolicy.Principal, error) {
+	principal, err := a.client.AuthenticateAPIKey(ctx, &protoauth.AuthenticateAPIKeyRequest{Key: key})
+	if err != nil {
+		return accesspolicy.Principal{}, err
+	}
+	scopes := make([]accesspolicy.Permission, 0, len(principal.Scopes))
+	for _, scope := range principal.Scopes {
+		scopes = append(scopes, accesspolicy.Permission(scope))
+	}
+	return accesspolicy.Principal{
+		UserID:         principal.UserId,
+		Role:           accesspolicy.Role(principal.Role),
+		OrganisationID: principal.OrganisationId,
+		EmailVerified:  principal.EmailVerified,
+		Scopes:         scopes,
+	}, nil
+}
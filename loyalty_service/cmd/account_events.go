@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	loyaltyconfig "loyaltyservice/loyalty_config"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	accountDeletedEvent      = "account_deleted"
+	organisationDeletedEvent = "organisation_deleted"
+)
+
+// A cleanup is tried cleanupAttempts times, waiting twice as long after every
+// failure up to maxCleanupBackoff, about 8 minutes in all.
+const (
+	cleanupAttempts   = 10
+	minCleanupBackoff = time.Second
+	maxCleanupBackoff = 5 * time.Minute
+)
+
+type accountEvent struct {
+	EventType string `json:"event_type"`
+	UserID    string `json:"user_id"`
+	ObjectID  string `json:"object_id"`
+}
+
+// consumeAccountEvents cleans up after deleted accounts and organisations. A
+// message is committed once the cleanup succeeded or ran out of attempts, so
+// a cleanup that keeps failing does not hold up the events after it.
+func consumeAccountEvents(s *promoServer, cfg loyaltyconfig.Kafka) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  cfg.Brokers,
+		Topic:    cfg.Topic,
+		GroupID:  cfg.GroupID,
+		MinBytes: 10e3,
+		MaxBytes: 10e6,
+	})
+	defer reader.Close()
+
+	ctx := context.Background()
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			log.Printf("Could not read account event: %v", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		var event accountEvent
+		if err := json.Unmarshal(msg.Value, &event); err == nil {
+			switch event.EventType {
+			case accountDeletedEvent:
+				retryCleanup("deleted account "+event.UserID, func() error { return s.deleteAuthorData(event.UserID) })
+			case organisationDeletedEvent:
+				retryCleanup("deleted organisation "+event.ObjectID, func() error { return s.deleteOrganisationData(event.ObjectID) })
+			}
+		}
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			log.Printf("Could not commit account event: %v", err)
+		}
+	}
+}
+
+func retryCleanup(subject string, cleanup func() error) {
+	backoff := minCleanupBackoff
+	for attempt := 1; ; attempt++ {
+		err := cleanup()
+		if err == nil {
+			return
+		}
+		if attempt == cleanupAttempts {
+			log.Printf("Giving up cleaning up data of %s after %d attempts, it has to be removed by hand: %v", subject, attempt, err)
+			return
+		}
+		log.Printf("Failed to clean up data of %s, retrying in %v: %v", subject, backoff, err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxCleanupBackoff)
+	}
+}
+
+// deleteOrganisationData removes the promos of a deleted organisation together
+// with their comments, including promos written before organisations existed
+// by the company account whose ID the organisation took over.
+func (s *promoServer) deleteOrganisationData(organisationId string) error {
+	var promoIds []gocql.UUID
+	iter := s.session.Query("SELECT id FROM promos WHERE organisation_id = ?", organisationId).Iter()
+	var promoId gocql.UUID
+	for iter.Scan(&promoId) {
+		promoIds = append(promoIds, promoId)
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	iter = s.session.Query("SELECT id, organisation_id FROM promos WHERE author_id = ?", organisationId).Iter()
+	var promoOrganisationId string
+	for iter.Scan(&promoId, &promoOrganisationId) {
+		if promoOrganisationId == "" {
+			promoIds = append(promoIds, promoId)
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	for _, id := range promoIds {
+		if err := s.session.Query("DELETE FROM comments WHERE promo_id = ?", id).Exec(); err != nil {
+			return err
+		}
+		if err := s.session.Query("DELETE FROM promos WHERE id = ?", id).Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteAuthorData keeps the promos of a deleted account with its organisation
+// and its comments, all without an author.
+func (s *promoServer) deleteAuthorData(authorId string) error {
+	type promoKey struct {
+		id             gocql.UUID
+		organisationId string
+	}
+	var promos []promoKey
+	iter := s.session.Query("SELECT id, organisation_id FROM promos WHERE author_id = ?", authorId).Iter()
+	var promo promoKey
+	for iter.Scan(&promo.id, &promo.organisationId) {
+		promos = append(promos, promo)
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	for _, promo := range promos {
+		err := s.session.Query("UPDATE promos SET author_id = null, organisation_id = ? WHERE id = ?",
+			promoOrganisation(authorId, promo.organisationId), promo.id).Exec()
+		if err != nil {
+			return err
+		}
+	}
+
+	type commentKey struct{ promoId, id gocql.UUID }
+	var comments []commentKey
+	iter = s.session.Query("SELECT promo_id, id FROM comments WHERE author_id = ?", authorId).Iter()
+	var key commentKey
+	for iter.Scan(&key.promoId, &key.id) {
+		comments = append(comments, key)
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		err := s.session.Query("UPDATE comments SET author_id = null WHERE promo_id = ? AND id = ?",
+			comment.promoId, comment.id).Exec()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,16 +2,23 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
+	authclient "loyaltyservice/auth_client"
+	loyaltyconfig "loyaltyservice/loyalty_config"
 	protopromo "loyaltyservice/proto/promo"
 	"net"
+	"os"
+	accesspolicy "shared/access_policy"
 	"time"
 
 	"github.com/gocql/gocql"
 	"github.com/golang/protobuf/ptypes/empty"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -20,56 +27,101 @@ type promoServer struct {
 	session *gocql.Session
 }
 
+// companyChangeForbidden rejects promo changes from company callers that have
+// not verified the email or not set up two-factor authentication required by
+// the auth service policy, as the gateway does.
+func companyChangeForbidden(principal accesspolicy.Principal) error {
+	switch {
+	case accesspolicy.IsCompanyRole(principal.Role) && !principal.EmailVerified:
+		return status.Error(codes.PermissionDenied, "verify your email before publishing promos")
+	case principal.TwoFactorSetupRequired:
+		return status.Error(codes.PermissionDenied, "set up two-factor authentication before publishing promos")
+	}
+	return nil
+}
+
 func (s *promoServer) CreatePromo(ctx context.Context, req *protopromo.CreatePromoRequest) (*protopromo.Promo, error) {
+	principal, err := accesspolicy.Require(ctx, accesspolicy.PromosWrite)
+	if err != nil {
+		return nil, err
+	}
+	if err := companyChangeForbidden(principal); err != nil {
+		return nil, err
+	}
+	if req.AuthorId != principal.UserID {
+		return nil, status.Error(codes.PermissionDenied, "promos can only be created on behalf of the caller")
+	}
+	if principal.OrganisationID == "" {
+		return nil, status.Error(codes.FailedPrecondition, "promos can only be created by members of an organisation")
+	}
 	id := gocql.TimeUUID()
 	creationTime := time.Now()
 	if err := s.session.Query(
-		"INSERT INTO promos (id, title, description, author_id, discount_rate, promo_code, creation_date, update_date) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
-		id, req.Title, req.Description, req.AuthorId, req.DiscountRate, req.PromoCode, creationTime, creationTime,
+		"INSERT INTO promos (id, title, description, author_id, organisation_id, discount_rate, promo_code, creation_date, update_date) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		id, req.Title, req.Description, req.AuthorId, principal.OrganisationID, req.DiscountRate, req.PromoCode, creationTime, creationTime,
 	).Exec(); err != nil {
 		return nil, err
 	}
 	return &protopromo.Promo{
-		Id:           id.String(),
-		Title:        req.Title,
-		Description:  req.Description,
-		AuthorId:     req.AuthorId,
-		DiscountRate: req.DiscountRate,
-		PromoCode:    req.PromoCode,
-		CreationDate: timestamppb.New(creationTime),
-		UpdateDate:   timestamppb.New(creationTime),
+		Id:             id.String(),
+		Title:          req.Title,
+		Description:    req.Description,
+		AuthorId:       req.AuthorId,
+		OrganisationId: principal.OrganisationID,
+		DiscountRate:   req.DiscountRate,
+		PromoCode:      req.PromoCode,
+		CreationDate:   timestamppb.New(creationTime),
+		UpdateDate:     timestamppb.New(creationTime),
 	}, nil
 }
 
+// promoOrganisation returns the organisation owning a promo. Promos created
+// before organisations existed have none and belong to the organisation of
+// their author's company account, which was given the ID of that account.
+func promoOrganisation(authorId, organisationId string) string {
+	if organisationId == "" {
+		return authorId
+	}
+	return organisationId
+}
+
 func (s *promoServer) GetPromo(ctx context.Context, req *protopromo.GetPromoRequest) (*protopromo.Promo, error) {
 	var p protopromo.Promo
 	var creationDate, updateDate time.Time
 	if err := s.session.Query(
-		"SELECT id, title, description, author_id, discount_rate, promo_code, creation_date, update_date FROM promos WHERE id = ? LIMIT 1",
+		"SELECT id, title, description, author_id, organisation_id, discount_rate, promo_code, creation_date, update_date FROM promos WHERE id = ? LIMIT 1",
 		req.Id,
-	).Scan(&p.Id, &p.Title, &p.Description, &p.AuthorId, &p.DiscountRate, &p.PromoCode, &creationDate, &updateDate); err != nil {
+	).Scan(&p.Id, &p.Title, &p.Description, &p.AuthorId, &p.OrganisationId, &p.DiscountRate, &p.PromoCode, &creationDate, &updateDate); err != nil {
 		return nil, err
 	}
+	p.OrganisationId = promoOrganisation(p.AuthorId, p.OrganisationId)
 	p.CreationDate = timestamppb.New(creationDate)
 	p.UpdateDate = timestamppb.New(updateDate)
 	return &p, nil
 }
 
 func (s *promoServer) UpdatePromo(ctx context.Context, req *protopromo.UpdatePromoRequest) (*protopromo.Promo, error) {
-	var authorId string
-	err := s.session.Query("SELECT author_id FROM promos WHERE id = ?", req.Id).Scan(&authorId)
+	principal, err := accesspolicy.Require(ctx, accesspolicy.PromosWrite)
+	if err != nil {
+		return nil, err
+	}
+	if err := companyChangeForbidden(principal); err != nil {
+		return nil, err
+	}
+	var authorId, organisationId string
+	err = s.session.Query("SELECT author_id, organisation_id FROM promos WHERE id = ?", req.Id).Scan(&authorId, &organisationId)
 	if err != nil {
 		return nil, fmt.Errorf("promo not found or database error: %v", err)
 	}
 
-	if authorId != req.AuthorId {
-		return nil, fmt.Errorf("permission denied: only the author can update this promo")
+	if principal.OrganisationID == "" || promoOrganisation(authorId, organisationId) != principal.OrganisationID {
+		return nil, status.Error(codes.PermissionDenied, "only members of the owning organisation can update this promo")
 	}
 
 	updateTime := time.Now()
 	if err := s.session.Query(
 		"UPDATE promos SET title = ?, description = ?, discount_rate = ?, update_date = ?, promo_code = ? WHERE id = ?",
-		req.Title, req.Description, req.DiscountRate, updateTime, req.Id,
+		req.Title, req.Description, req.DiscountRate, updateTime, req.PromoCode, req.Id,
 	).Exec(); err != nil {
 		return nil, err
 	}
@@ -78,14 +130,22 @@ func (s *promoServer) UpdatePromo(ctx context.Context, req *protopromo.UpdatePro
 }
 
 func (s *promoServer) DeletePromo(ctx context.Context, req *protopromo.DeletePromoRequest) (*empty.Empty, error) {
-	var authorId string
-	err := s.session.Query("SELECT author_id FROM promos WHERE id = ?", req.Id).Scan(&authorId)
+	principal, err := accesspolicy.Require(ctx, accesspolicy.PromosWrite, accesspolicy.PromosModerate)
+	if err != nil {
+		return nil, err
+	}
+	if err := companyChangeForbidden(principal); err != nil {
+		return nil, err
+	}
+	var authorId, organisationId string
+	err = s.session.Query("SELECT author_id, organisation_id FROM promos WHERE id = ?", req.Id).Scan(&authorId, &organisationId)
 	if err != nil {
 		return nil, fmt.Errorf("promo not found or database error: %v", err)
 	}
 
-	if authorId != req.AuthorId {
-		return nil, fmt.Errorf("permission denied: only the author can delete this promo")
+	ownedByCaller := principal.OrganisationID != "" && promoOrganisation(authorId, organisationId) == principal.OrganisationID
+	if !ownedByCaller && !principal.Can(accesspolicy.PromosModerate) {
+		return nil, status.Error(codes.PermissionDenied, "only members of the owning organisation or a moderator can delete this promo")
 	}
 
 	if err := s.session.Query("DELETE FROM promos WHERE id = ?", req.Id).Exec(); err != nil {
@@ -97,13 +157,14 @@ func (s *promoServer) DeletePromo(ctx context.Context, req *protopromo.DeletePro
 
 func (s *promoServer) ListPromos(ctx context.Context, req *protopromo.ListPromosRequest) (*protopromo.ListPromosResponse, error) {
 	var promos []*protopromo.Promo
-	iter := s.session.Query("SELECT id, title, description, author_id, discount_rate, promo_code, creation_date, update_date FROM promos").Iter()
+	iter := s.session.Query("SELECT id, title, description, author_id, organisation_id, discount_rate, promo_code, creation_date, update_date FROM promos").Iter()
 	for {
 		var p protopromo.Promo
 		var creationDate, updateDate time.Time
-		if !iter.Scan(&p.Id, &p.Title, &p.Description, &p.AuthorId, &p.DiscountRate, &p.PromoCode, &creationDate, &updateDate) {
+		if !iter.Scan(&p.Id, &p.Title, &p.Description, &p.AuthorId, &p.OrganisationId, &p.DiscountRate, &p.PromoCode, &creationDate, &updateDate) {
 			break
 		}
+		p.OrganisationId = promoOrganisation(p.AuthorId, p.OrganisationId)
 		p.CreationDate = timestamppb.New(creationDate)
 		p.UpdateDate = timestamppb.New(updateDate)
 		promos = append(promos, &p)
@@ -115,6 +176,13 @@ func (s *promoServer) ListPromos(ctx context.Context, req *protopromo.ListPromos
 }
 
 func (s *promoServer) AddComment(ctx context.Context, req *protopromo.AddCommentRequest) (*protopromo.Comment, error) {
+	principal, err := accesspolicy.Require(ctx, accesspolicy.CommentsWrite)
+	if err != nil {
+		return nil, err
+	}
+	if req.AuthorId != principal.UserID {
+		return nil, status.Error(codes.PermissionDenied, "comments can only be added on behalf of the caller")
+	}
 	id := gocql.TimeUUID()
 	creationTime := time.Now()
 
@@ -193,12 +261,86 @@ func (s *promoServer) ListComments(ctx context.Context, req *protopromo.ListComm
 	}, nil
 }
 
-func connectToCassandra(host string, port int, keyspace string) *gocql.Session {
-	cluster := gocql.NewCluster(host)
-	cluster.Port = port
+func (s *promoServer) DeleteComment(ctx context.Context, req *protopromo.DeleteCommentRequest) (*empty.Empty, error) {
+	principal, err := accesspolicy.Require(ctx, accesspolicy.CommentsWrite, accesspolicy.CommentsModerate)
+	if err != nil {
+		return nil, err
+	}
+	var promoId, authorId string
+	err = s.session.Query("SELECT promo_id, author_id FROM comments WHERE id = ?", req.CommentId).Scan(&promoId, &authorId)
+	if err != nil {
+		return nil, fmt.Errorf("comment not found or database error: %v", err)
+	}
+
+	if authorId != principal.UserID && !principal.Can(accesspolicy.CommentsModerate) {
+		return nil, status.Error(codes.PermissionDenied, "only the author or a moderator can delete this comment")
+	}
+
+	if err := s.session.Query("DELETE FROM comments WHERE promo_id = ? AND id = ?", promoId, req.CommentId).Exec(); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *promoServer) ExportAuthorData(ctx context.Context, req *protopromo.ExportAuthorDataRequest) (*protopromo.AuthorData, error) {
+	principal, ok := accesspolicy.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing caller identity")
+	}
+	if req.AuthorId != principal.UserID && !principal.Can(accesspolicy.UsersManage) {
+		return nil, status.Error(codes.PermissionDenied, "only the author can export their data")
+	}
+
+	data := &protopromo.AuthorData{}
+	iter := s.session.Query(
+		"SELECT id, title, description, author_id, organisation_id, discount_rate, promo_code, creation_date, update_date FROM promos WHERE author_id = ?",
+		req.AuthorId,
+	).Iter()
+	for {
+		var p protopromo.Promo
+		var creationDate, updateDate time.Time
+		if !iter.Scan(&p.Id, &p.Title, &p.Description, &p.AuthorId, &p.OrganisationId, &p.DiscountRate, &p.PromoCode, &creationDate, &updateDate) {
+			break
+		}
+		p.OrganisationId = promoOrganisation(p.AuthorId, p.OrganisationId)
+		p.CreationDate = timestamppb.New(creationDate)
+		p.UpdateDate = timestamppb.New(updateDate)
+		data.Promos = append(data.Promos, &p)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	iter = s.session.Query(
+		"SELECT id, promo_id, author_id, content, creation_date FROM comments WHERE author_id = ?",
+		req.AuthorId,
+	).Iter()
+	for {
+		var c protopromo.Comment
+		var creationDate time.Time
+		if !iter.Scan(&c.Id, &c.PromoId, &c.AuthorId, &c.Content, &creationDate) {
+			break
+		}
+		c.CreationDate = timestamppb.New(creationDate)
+		data.Comments = append(data.Comments, &c)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func connectToCassandra(cfg loyaltyconfig.Cassandra) *gocql.Session {
+	keyspace := cfg.Keyspace
+	cluster := gocql.NewCluster(cfg.Hosts...)
+	cluster.Port = cfg.Port
 	cluster.Consistency = gocql.Quorum
-	cluster.ConnectTimeout = 10 * time.Second
-	cluster.Timeout = 10 * time.Second
+	cluster.ConnectTimeout = cfg.Timeout
+	cluster.Timeout = cfg.Timeout
+	if cfg.Username != "" {
+		cluster.Authenticator = gocql.PasswordAuthenticator{Username: cfg.Username, Password: cfg.Password}
+	}
 
 	var session *gocql.Session
 	var err error
@@ -227,7 +369,7 @@ func connectToCassandra(host string, port int, keyspace string) *gocql.Session {
 	err = session.Query("SELECT COUNT(*) FROM system_schema.keyspaces WHERE keyspace_name = ?", keyspace).Scan(&count)
 	if err != nil {
 		session.Close()
-		log.Fatal("failed to check system_schema.keyspaces: %v", err)
+		log.Fatalf("failed to check system_schema.keyspaces: %v", err)
 		return nil
 	}
 
@@ -274,12 +416,13 @@ func connectToCassandra(host string, port int, keyspace string) *gocql.Session {
 	return session
 }
 
-func initializeDatabase(session *gocql.Session) {
+func initializeDatabase(session *gocql.Session, keyspace string) {
 	queries := []string{`CREATE TABLE IF NOT EXISTS promos (
 		id UUID PRIMARY KEY,
 		title TEXT,
 		description TEXT,
 		author_id UUID,
+		organisation_id UUID,
 		discount_rate DOUBLE,
 		promo_code TEXT,
 		creation_date TIMESTAMP,
@@ -293,31 +436,73 @@ func initializeDatabase(session *gocql.Session) {
 		creation_date TIMESTAMP,
 		PRIMARY KEY (promo_id, id)
 	)`,
-		`CREATE INDEX IF NOT EXISTS comments_id_idx ON comments (id)`}
+		`CREATE INDEX IF NOT EXISTS comments_id_idx ON comments (id)`,
+		`CREATE INDEX IF NOT EXISTS comments_author_id_idx ON comments (author_id)`,
+		`CREATE INDEX IF NOT EXISTS promos_author_id_idx ON promos (author_id)`}
 
 	for _, query := range queries {
 		if err := session.Query(query).Exec(); err != nil {
 			log.Fatal("Failed to initialize database:", err)
 		}
 	}
+
+	// Tables created before organisations lack the column, and Cassandra has
+	// no ADD COLUMN IF NOT EXISTS.
+	var count int
+	err := session.Query("SELECT COUNT(*) FROM system_schema.columns WHERE keyspace_name = ? AND table_name = 'promos' AND column_name = 'organisation_id'",
+		keyspace).Scan(&count)
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	if count == 0 {
+		if err := session.Query("ALTER TABLE promos ADD organisation_id UUID").Exec(); err != nil {
+			log.Fatal("Failed to initialize database:", err)
+		}
+	}
+	if err := session.Query("CREATE INDEX IF NOT EXISTS promos_organisation_id_idx ON promos (organisation_id)").Exec(); err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
 }
 
 func main() {
-	session := connectToCassandra("cassandra", 9042, "loyalty_service")
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML config file, overridden by the environment")
+	printConfig := flag.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+	flag.Parse()
+	cfg, err := loyaltyconfig.Load(*configPath)
+	if err != nil {
+		log.Fatal("Invalid config: ", err)
+	}
+	if *printConfig {
+		if err := loyaltyconfig.Print(os.Stdout, cfg); err != nil {
+			log.Fatal("Failed to print config: ", err)
+		}
+		return
+	}
+
+	session := connectToCassandra(cfg.Cassandra)
 	defer session.Close()
 
-	initializeDatabase(session)
+	initializeDatabase(session, cfg.Cassandra.Keyspace)
+
+	promoSrv := &promoServer{session: session}
+	go consumeAccountEvents(promoSrv, cfg.Kafka)
+
+	connAuth, err := grpc.Dial(cfg.AuthServiceAddress, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		log.Fatal("Failed to connect to auth service: ", err)
+	}
+	defer connAuth.Close()
 
-	server := grpc.NewServer()
-	protopromo.RegisterPromoServiceServer(server, &promoServer{session: session})
+	server := grpc.NewServer(grpc.UnaryInterceptor(accesspolicy.UnaryServerInterceptor(authclient.NewAuthenticator(connAuth))))
+	protopromo.RegisterPromoServiceServer(server, promoSrv)
 	reflection.Register(server)
 
-	listener, err := net.Listen("tcp", ":8083")
+	listener, err := net.Listen("tcp", cfg.ListenAddress)
 	if err != nil {
 		log.Fatal("Failed to listen:", err)
 	}
 
-	log.Println("gRPC server started on :8083")
+	log.Println("gRPC server started on", cfg.ListenAddress)
 	if err := server.Serve(listener); err != nil {
 		log.Fatal("Failed to serve:", err)
 	}
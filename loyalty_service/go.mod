@@ -7,8 +7,11 @@ toolchain go1.24.0
 require (
 	github.com/gocql/gocql v1.7.0
 	github.com/golang/protobuf v1.5.4
+	github.com/segmentio/kafka-go v0.4.47
 	google.golang.org/grpc v1.71.1
 	google.golang.org/protobuf v1.36.6
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+	shared v0.0.0
 )
 
 require (
@@ -21,4 +24,10 @@ require (
 	gopkg.in/inf.v0 v0.9.1 // indirect
 )
 
-require golang.org/x/text v0.22.0 // indirect
+require (
+	github.com/klauspost/compress v1.15.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	golang.org/x/text v0.22.0 // indirect
+)
+
+replace shared => ../shared
@@ -0,0 +1,105 @@
+package loyaltyconfig
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	configloader "shared/config_loader"
+	"time"
+)
+
+// keyspacePattern is what Cassandra accepts unquoted, the keyspace name is
+// interpolated into CREATE KEYSPACE.
+var keyspacePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,47}$`)
+
+// Config is the effective configuration of the loyalty service. Every field
+// can be set in the YAML file under its yaml key and overridden by the
+// environment variable in its env tag. Fields tagged secret are redacted when
+// the configuration is printed.
+type Config struct {
+	ListenAddress      string    `yaml:"listen_address" env:"LISTEN_ADDRESS"`
+	AuthServiceAddress string    `yaml:"auth_service_address" env:"AUTH_SERVICE_ADDRESS"`
+	Cassandra          Cassandra `yaml:"cassandra"`
+	Kafka              Kafka     `yaml:"kafka"`
+}
+
+// Cassandra connects without authentication while Username is empty.
+type Cassandra struct {
+	Hosts    []string      `yaml:"hosts" env:"CASSANDRA_HOSTS"`
+	Port     int           `yaml:"port" env:"CASSANDRA_PORT"`
+	Keyspace string        `yaml:"keyspace" env:"CASSANDRA_KEYSPACE"`
+	Username string        `yaml:"username" env:"CASSANDRA_USERNAME"`
+	Password string        `yaml:"password" env:"CASSANDRA_PASSWORD" secret:"true"`
+	Timeout  time.Duration `yaml:"timeout" env:"CASSANDRA_TIMEOUT"`
+}
+
+// Kafka is where the account events the service cleans up after are read.
+type Kafka struct {
+	Brokers []string `yaml:"brokers" env:"KAFKA_BROKERS"`
+	Topic   string   `yaml:"topic" env:"KAFKA_STATS_TOPIC"`
+	GroupID string   `yaml:"group_id" env:"KAFKA_GROUP_ID"`
+}
+
+// Load starts from Default, applies the YAML file at path unless path is
+// empty, then the environment, and validates the result.
+func Load(path string) (Config, error) {
+	return configloader.Load(path, Default())
+}
+
+// Print writes the redacted configuration as YAML that Load accepts back.
+func Print(w io.Writer, c Config) error {
+	return configloader.Print(w, c)
+}
+
+// Default matches the docker-compose setup.
+func Default() Config {
+	return Config{
+		ListenAddress:      ":8083",
+		AuthServiceAddress: "auth-service:8080",
+		Cassandra: Cassandra{
+			Hosts:    []string{"cassandra"},
+			Port:     9042,
+			Keyspace: "loyalty_service",
+			Timeout:  10 * time.Second,
+		},
+		Kafka: Kafka{
+			Brokers: []string{"kafka:9092"},
+			Topic:   "stats",
+			GroupID: "loyalty-account-cleanup",
+		},
+	}
+}
+
+// Validate reports every invalid value at once.
+func (c Config) Validate() error {
+	var errs []error
+	if c.ListenAddress == "" {
+		errs = append(errs, errors.New("listen_address is empty"))
+	}
+	if c.AuthServiceAddress == "" {
+		errs = append(errs, errors.New("auth_service_address is empty"))
+	}
+	if len(c.Cassandra.Hosts) == 0 {
+		errs = append(errs, errors.New("cassandra.hosts is empty"))
+	}
+	if c.Cassandra.Port < 1 || c.Cassandra.Port > 65535 {
+		errs = append(errs, fmt.Errorf("cassandra.port %d is out of range", c.Cassandra.Port))
+	}
+	if !keyspacePattern.MatchString(c.Cassandra.Keyspace) {
+		errs = append(errs, fmt.Errorf("cassandra.keyspace %q is not a valid keyspace name", c.Cassandra.Keyspace))
+	}
+	if c.Cassandra.Timeout <= 0 {
+		errs = append(errs, errors.New("cassandra.timeout must be positive"))
+	}
+	if len(c.Kafka.Brokers) == 0 {
+		errs = append(errs, errors.New("kafka.brokers is empty"))
+	}
+	if c.Kafka.Topic == "" {
+		errs = append(errs, errors.New("kafka.topic is empty"))
+	}
+	if c.Kafka.GroupID == "" {
+		errs = append(errs, errors.New("kafka.group_id is empty"))
+	}
+	return errors.Join(errs...)
+}
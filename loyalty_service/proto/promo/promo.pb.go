@@ -2,7 +2,7 @@
 // versions:
 // 	protoc-gen-go v1.36.6
 // 	protoc        v3.12.4
-// source: promo.proto
+// source: proto/promo/promo.proto
 
 package loyalty_service
 
@@ -24,22 +24,23 @@ const (
 )
 
 type Promo struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	AuthorId      string                 `protobuf:"bytes,4,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
-	DiscountRate  float64                `protobuf:"fixed64,5,opt,name=discount_rate,json=discountRate,proto3" json:"discount_rate,omitempty"`
-	PromoCode     string                 `protobuf:"bytes,6,opt,name=promo_code,json=promoCode,proto3" json:"promo_code,omitempty"`
-	CreationDate  *timestamp.Timestamp   `protobuf:"bytes,7,opt,name=creation_date,json=creationDate,proto3" json:"creation_date,omitempty"`
-	UpdateDate    *timestamp.Timestamp   `protobuf:"bytes,8,opt,name=update_date,json=updateDate,proto3" json:"update_date,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title          string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Description    string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	AuthorId       string                 `protobuf:"bytes,4,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	DiscountRate   float64                `protobuf:"fixed64,5,opt,name=discount_rate,json=discountRate,proto3" json:"discount_rate,omitempty"`
+	PromoCode      string                 `protobuf:"bytes,6,opt,name=promo_code,json=promoCode,proto3" json:"promo_code,omitempty"`
+	CreationDate   *timestamp.Timestamp   `protobuf:"bytes,7,opt,name=creation_date,json=creationDate,proto3" json:"creation_date,omitempty"`
+	UpdateDate     *timestamp.Timestamp   `protobuf:"bytes,8,opt,name=update_date,json=updateDate,proto3" json:"update_date,omitempty"`
+	OrganisationId string                 `protobuf:"bytes,9,opt,name=organisation_id,json=organisationId,proto3" json:"organisation_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *Promo) Reset() {
 	*x = Promo{}
-	mi := &file_promo_proto_msgTypes[0]
+	mi := &file_proto_promo_promo_proto_msgTypes[0]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -51,7 +52,7 @@ func (x *Promo) String() string {
 func (*Promo) ProtoMessage() {}
 
 func (x *Promo) ProtoReflect() protoreflect.Message {
-	mi := &file_promo_proto_msgTypes[0]
+	mi := &file_proto_promo_promo_proto_msgTypes[0]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -64,7 +65,7 @@ func (x *Promo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Promo.ProtoReflect.Descriptor instead.
 func (*Promo) Descriptor() ([]byte, []int) {
-	return file_promo_proto_rawDescGZIP(), []int{0}
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{0}
 }
 
 func (x *Promo) GetId() string {
@@ -123,6 +124,13 @@ func (x *Promo) GetUpdateDate() *timestamp.Timestamp {
 	return nil
 }
 
+func (x *Promo) GetOrganisationId() string {
+	if x != nil {
+		return x.OrganisationId
+	}
+	return ""
+}
+
 type CreatePromoRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
@@ -136,7 +144,7 @@ type CreatePromoRequest struct {
 
 func (x *CreatePromoRequest) Reset() {
 	*x = CreatePromoRequest{}
-	mi := &file_promo_proto_msgTypes[1]
+	mi := &file_proto_promo_promo_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -148,7 +156,7 @@ func (x *CreatePromoRequest) String() string {
 func (*CreatePromoRequest) ProtoMessage() {}
 
 func (x *CreatePromoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_promo_proto_msgTypes[1]
+	mi := &file_proto_promo_promo_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -161,7 +169,7 @@ func (x *CreatePromoRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreatePromoRequest.ProtoReflect.Descriptor instead.
 func (*CreatePromoRequest) Descriptor() ([]byte, []int) {
-	return file_promo_proto_rawDescGZIP(), []int{1}
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *CreatePromoRequest) GetTitle() string {
@@ -208,7 +216,7 @@ type GetPromoRequest struct {
 
 func (x *GetPromoRequest) Reset() {
 	*x = GetPromoRequest{}
-	mi := &file_promo_proto_msgTypes[2]
+	mi := &file_proto_promo_promo_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -220,7 +228,7 @@ func (x *GetPromoRequest) String() string {
 func (*GetPromoRequest) ProtoMessage() {}
 
 func (x *GetPromoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_promo_proto_msgTypes[2]
+	mi := &file_proto_promo_promo_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -233,7 +241,7 @@ func (x *GetPromoRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetPromoRequest.ProtoReflect.Descriptor instead.
 func (*GetPromoRequest) Descriptor() ([]byte, []int) {
-	return file_promo_proto_rawDescGZIP(), []int{2}
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *GetPromoRequest) GetId() string {
@@ -257,7 +265,7 @@ type UpdatePromoRequest struct {
 
 func (x *UpdatePromoRequest) Reset() {
 	*x = UpdatePromoRequest{}
-	mi := &file_promo_proto_msgTypes[3]
+	mi := &file_proto_promo_promo_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -269,7 +277,7 @@ func (x *UpdatePromoRequest) String() string {
 func (*UpdatePromoRequest) ProtoMessage() {}
 
 func (x *UpdatePromoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_promo_proto_msgTypes[3]
+	mi := &file_proto_promo_promo_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -282,7 +290,7 @@ func (x *UpdatePromoRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdatePromoRequest.ProtoReflect.Descriptor instead.
 func (*UpdatePromoRequest) Descriptor() ([]byte, []int) {
-	return file_promo_proto_rawDescGZIP(), []int{3}
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *UpdatePromoRequest) GetId() string {
@@ -337,7 +345,7 @@ type DeletePromoRequest struct {
 
 func (x *DeletePromoRequest) Reset() {
 	*x = DeletePromoRequest{}
-	mi := &file_promo_proto_msgTypes[4]
+	mi := &file_proto_promo_promo_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -349,7 +357,7 @@ func (x *DeletePromoRequest) String() string {
 func (*DeletePromoRequest) ProtoMessage() {}
 
 func (x *DeletePromoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_promo_proto_msgTypes[4]
+	mi := &file_proto_promo_promo_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -362,7 +370,7 @@ func (x *DeletePromoRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeletePromoRequest.ProtoReflect.Descriptor instead.
 func (*DeletePromoRequest) Descriptor() ([]byte, []int) {
-	return file_promo_proto_rawDescGZIP(), []int{4}
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *DeletePromoRequest) GetId() string {
@@ -389,7 +397,7 @@ type ListPromosRequest struct {
 
 func (x *ListPromosRequest) Reset() {
 	*x = ListPromosRequest{}
-	mi := &file_promo_proto_msgTypes[5]
+	mi := &file_proto_promo_promo_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -401,7 +409,7 @@ func (x *ListPromosRequest) String() string {
 func (*ListPromosRequest) ProtoMessage() {}
 
 func (x *ListPromosRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_promo_proto_msgTypes[5]
+	mi := &file_proto_promo_promo_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -414,7 +422,7 @@ func (x *ListPromosRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListPromosRequest.ProtoReflect.Descriptor instead.
 func (*ListPromosRequest) Descriptor() ([]byte, []int) {
-	return file_promo_proto_rawDescGZIP(), []int{5}
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *ListPromosRequest) GetPage() int32 {
@@ -440,7 +448,7 @@ type ListPromosResponse struct {
 
 func (x *ListPromosResponse) Reset() {
 	*x = ListPromosResponse{}
-	mi := &file_promo_proto_msgTypes[6]
+	mi := &file_proto_promo_promo_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -452,7 +460,7 @@ func (x *ListPromosResponse) String() string {
 func (*ListPromosResponse) ProtoMessage() {}
 
 func (x *ListPromosResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_promo_proto_msgTypes[6]
+	mi := &file_proto_promo_promo_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -465,7 +473,7 @@ func (x *ListPromosResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListPromosResponse.ProtoReflect.Descriptor instead.
 func (*ListPromosResponse) Descriptor() ([]byte, []int) {
-	return file_promo_proto_rawDescGZIP(), []int{6}
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *ListPromosResponse) GetPromos() []*Promo {
@@ -488,7 +496,7 @@ type Comment struct {
 
 func (x *Comment) Reset() {
 	*x = Comment{}
-	mi := &file_promo_proto_msgTypes[7]
+	mi := &file_proto_promo_promo_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -500,7 +508,7 @@ func (x *Comment) String() string {
 func (*Comment) ProtoMessage() {}
 
 func (x *Comment) ProtoReflect() protoreflect.Message {
-	mi := &file_promo_proto_msgTypes[7]
+	mi := &file_proto_promo_promo_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -513,7 +521,7 @@ func (x *Comment) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Comment.ProtoReflect.Descriptor instead.
 func (*Comment) Descriptor() ([]byte, []int) {
-	return file_promo_proto_rawDescGZIP(), []int{7}
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *Comment) GetId() string {
@@ -562,7 +570,7 @@ type AddCommentRequest struct {
 
 func (x *AddCommentRequest) Reset() {
 	*x = AddCommentRequest{}
-	mi := &file_promo_proto_msgTypes[8]
+	mi := &file_proto_promo_promo_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -574,7 +582,7 @@ func (x *AddCommentRequest) String() string {
 func (*AddCommentRequest) ProtoMessage() {}
 
 func (x *AddCommentRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_promo_proto_msgTypes[8]
+	mi := &file_proto_promo_promo_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -587,7 +595,7 @@ func (x *AddCommentRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddCommentRequest.ProtoReflect.Descriptor instead.
 func (*AddCommentRequest) Descriptor() ([]byte, []int) {
-	return file_promo_proto_rawDescGZIP(), []int{8}
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *AddCommentRequest) GetPromoId() string {
@@ -620,7 +628,7 @@ type GetCommentRequest struct {
 
 func (x *GetCommentRequest) Reset() {
 	*x = GetCommentRequest{}
-	mi := &file_promo_proto_msgTypes[9]
+	mi := &file_proto_promo_promo_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -632,7 +640,7 @@ func (x *GetCommentRequest) String() string {
 func (*GetCommentRequest) ProtoMessage() {}
 
 func (x *GetCommentRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_promo_proto_msgTypes[9]
+	mi := &file_proto_promo_promo_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -645,7 +653,7 @@ func (x *GetCommentRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetCommentRequest.ProtoReflect.Descriptor instead.
 func (*GetCommentRequest) Descriptor() ([]byte, []int) {
-	return file_promo_proto_rawDescGZIP(), []int{9}
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *GetCommentRequest) GetCommentId() string {
@@ -666,7 +674,7 @@ type ListCommentsRequest struct {
 
 func (x *ListCommentsRequest) Reset() {
 	*x = ListCommentsRequest{}
-	mi := &file_promo_proto_msgTypes[10]
+	mi := &file_proto_promo_promo_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -678,7 +686,7 @@ func (x *ListCommentsRequest) String() string {
 func (*ListCommentsRequest) ProtoMessage() {}
 
 func (x *ListCommentsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_promo_proto_msgTypes[10]
+	mi := &file_proto_promo_promo_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -691,7 +699,7 @@ func (x *ListCommentsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListCommentsRequest.ProtoReflect.Descriptor instead.
 func (*ListCommentsRequest) Descriptor() ([]byte, []int) {
-	return file_promo_proto_rawDescGZIP(), []int{10}
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ListCommentsRequest) GetPromoId() string {
@@ -724,7 +732,7 @@ type ListCommentsResponse struct {
 
 func (x *ListCommentsResponse) Reset() {
 	*x = ListCommentsResponse{}
-	mi := &file_promo_proto_msgTypes[11]
+	mi := &file_proto_promo_promo_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -736,7 +744,7 @@ func (x *ListCommentsResponse) String() string {
 func (*ListCommentsResponse) ProtoMessage() {}
 
 func (x *ListCommentsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_promo_proto_msgTypes[11]
+	mi := &file_proto_promo_promo_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -749,7 +757,7 @@ func (x *ListCommentsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListCommentsResponse.ProtoReflect.Descriptor instead.
 func (*ListCommentsResponse) Descriptor() ([]byte, []int) {
-	return file_promo_proto_rawDescGZIP(), []int{11}
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ListCommentsResponse) GetComments() []*Comment {
@@ -759,11 +767,159 @@ func (x *ListCommentsResponse) GetComments() []*Comment {
 	return nil
 }
 
-var File_promo_proto protoreflect.FileDescriptor
+type DeleteCommentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CommentId     string                 `protobuf:"bytes,1,opt,name=comment_id,json=commentId,proto3" json:"comment_id,omitempty"`
+	AuthorId      string                 `protobuf:"bytes,2,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCommentRequest) Reset() {
+	*x = DeleteCommentRequest{}
+	mi := &file_proto_promo_promo_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCommentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCommentRequest) ProtoMessage() {}
+
+func (x *DeleteCommentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_promo_promo_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCommentRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCommentRequest) Descriptor() ([]byte, []int) {
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DeleteCommentRequest) GetCommentId() string {
+	if x != nil {
+		return x.CommentId
+	}
+	return ""
+}
+
+func (x *DeleteCommentRequest) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+type ExportAuthorDataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AuthorId      string                 `protobuf:"bytes,1,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportAuthorDataRequest) Reset() {
+	*x = ExportAuthorDataRequest{}
+	mi := &file_proto_promo_promo_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportAuthorDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportAuthorDataRequest) ProtoMessage() {}
+
+func (x *ExportAuthorDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_promo_promo_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportAuthorDataRequest.ProtoReflect.Descriptor instead.
+func (*ExportAuthorDataRequest) Descriptor() ([]byte, []int) {
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ExportAuthorDataRequest) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+type AuthorData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Promos        []*Promo               `protobuf:"bytes,1,rep,name=promos,proto3" json:"promos,omitempty"`
+	Comments      []*Comment             `protobuf:"bytes,2,rep,name=comments,proto3" json:"comments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthorData) Reset() {
+	*x = AuthorData{}
+	mi := &file_proto_promo_promo_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthorData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthorData) ProtoMessage() {}
+
+func (x *AuthorData) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_promo_promo_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthorData.ProtoReflect.Descriptor instead.
+func (*AuthorData) Descriptor() ([]byte, []int) {
+	return file_proto_promo_promo_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *AuthorData) GetPromos() []*Promo {
+	if x != nil {
+		return x.Promos
+	}
+	return nil
+}
+
+func (x *AuthorData) GetComments() []*Comment {
+	if x != nil {
+		return x.Comments
+	}
+	return nil
+}
+
+var File_proto_promo_promo_proto protoreflect.FileDescriptor
 
-const file_promo_proto_rawDesc = "" +
+const file_proto_promo_promo_proto_rawDesc = "" +
 	"\n" +
-	"\vpromo.proto\x12\x05promo\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bgoogle/protobuf/empty.proto\"\xae\x02\n" +
+	"\x17proto/promo/promo.proto\x12\x05promo\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bgoogle/protobuf/empty.proto\"\xd7\x02\n" +
 	"\x05Promo\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
@@ -774,7 +930,8 @@ const file_promo_proto_rawDesc = "" +
 	"promo_code\x18\x06 \x01(\tR\tpromoCode\x12?\n" +
 	"\rcreation_date\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\fcreationDate\x12;\n" +
 	"\vupdate_date\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
-	"updateDate\"\xad\x01\n" +
+	"updateDate\x12'\n" +
+	"\x0forganisation_id\x18\t \x01(\tR\x0eorganisationId\"\xad\x01\n" +
 	"\x12CreatePromoRequest\x12\x14\n" +
 	"\x05title\x18\x01 \x01(\tR\x05title\x12 \n" +
 	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x1b\n" +
@@ -818,7 +975,17 @@ const file_promo_proto_rawDesc = "" +
 	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
 	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\"B\n" +
 	"\x14ListCommentsResponse\x12*\n" +
-	"\bcomments\x18\x01 \x03(\v2\x0e.promo.CommentR\bcomments2\xf8\x03\n" +
+	"\bcomments\x18\x01 \x03(\v2\x0e.promo.CommentR\bcomments\"R\n" +
+	"\x14DeleteCommentRequest\x12\x1d\n" +
+	"\n" +
+	"comment_id\x18\x01 \x01(\tR\tcommentId\x12\x1b\n" +
+	"\tauthor_id\x18\x02 \x01(\tR\bauthorId\"6\n" +
+	"\x17ExportAuthorDataRequest\x12\x1b\n" +
+	"\tauthor_id\x18\x01 \x01(\tR\bauthorId\"^\n" +
+	"\n" +
+	"AuthorData\x12$\n" +
+	"\x06promos\x18\x01 \x03(\v2\f.promo.PromoR\x06promos\x12*\n" +
+	"\bcomments\x18\x02 \x03(\v2\x0e.promo.CommentR\bcomments2\x85\x05\n" +
 	"\fPromoService\x128\n" +
 	"\vCreatePromo\x12\x19.promo.CreatePromoRequest\x1a\f.promo.Promo\"\x00\x122\n" +
 	"\bGetPromo\x12\x16.promo.GetPromoRequest\x1a\f.promo.Promo\"\x00\x128\n" +
@@ -830,86 +997,97 @@ const file_promo_proto_rawDesc = "" +
 	"AddComment\x12\x18.promo.AddCommentRequest\x1a\x0e.promo.Comment\x126\n" +
 	"\n" +
 	"GetComment\x12\x18.promo.GetCommentRequest\x1a\x0e.promo.Comment\x12G\n" +
-	"\fListComments\x12\x1a.promo.ListCommentsRequest\x1a\x1b.promo.ListCommentsResponseB5Z3/home/user/loyalty-program-platform/loyalty_serviceb\x06proto3"
+	"\fListComments\x12\x1a.promo.ListCommentsRequest\x1a\x1b.promo.ListCommentsResponse\x12D\n" +
+	"\rDeleteComment\x12\x1b.promo.DeleteCommentRequest\x1a\x16.google.protobuf.Empty\x12E\n" +
+	"\x10ExportAuthorData\x12\x1e.promo.ExportAuthorDataRequest\x1a\x11.promo.AuthorDataB5Z3/home/user/loyalty-program-platform/loyalty_serviceb\x06proto3"
 
 var (
-	file_promo_proto_rawDescOnce sync.Once
-	file_promo_proto_rawDescData []byte
+	file_proto_promo_promo_proto_rawDescOnce sync.Once
+	file_proto_promo_promo_proto_rawDescData []byte
 )
 
-func file_promo_proto_rawDescGZIP() []byte {
-	file_promo_proto_rawDescOnce.Do(func() {
-		file_promo_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_promo_proto_rawDesc), len(file_promo_proto_rawDesc)))
+func file_proto_promo_promo_proto_rawDescGZIP() []byte {
+	file_proto_promo_promo_proto_rawDescOnce.Do(func() {
+		file_proto_promo_promo_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_promo_promo_proto_rawDesc), len(file_proto_promo_promo_proto_rawDesc)))
 	})
-	return file_promo_proto_rawDescData
-}
-
-var file_promo_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
-var file_promo_proto_goTypes = []any{
-	(*Promo)(nil),                // 0: promo.Promo
-	(*CreatePromoRequest)(nil),   // 1: promo.CreatePromoRequest
-	(*GetPromoRequest)(nil),      // 2: promo.GetPromoRequest
-	(*UpdatePromoRequest)(nil),   // 3: promo.UpdatePromoRequest
-	(*DeletePromoRequest)(nil),   // 4: promo.DeletePromoRequest
-	(*ListPromosRequest)(nil),    // 5: promo.ListPromosRequest
-	(*ListPromosResponse)(nil),   // 6: promo.ListPromosResponse
-	(*Comment)(nil),              // 7: promo.Comment
-	(*AddCommentRequest)(nil),    // 8: promo.AddCommentRequest
-	(*GetCommentRequest)(nil),    // 9: promo.GetCommentRequest
-	(*ListCommentsRequest)(nil),  // 10: promo.ListCommentsRequest
-	(*ListCommentsResponse)(nil), // 11: promo.ListCommentsResponse
-	(*timestamp.Timestamp)(nil),  // 12: google.protobuf.Timestamp
-	(*empty.Empty)(nil),          // 13: google.protobuf.Empty
-}
-var file_promo_proto_depIdxs = []int32{
-	12, // 0: promo.Promo.creation_date:type_name -> google.protobuf.Timestamp
-	12, // 1: promo.Promo.update_date:type_name -> google.protobuf.Timestamp
+	return file_proto_promo_promo_proto_rawDescData
+}
+
+var file_proto_promo_promo_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_proto_promo_promo_proto_goTypes = []any{
+	(*Promo)(nil),                   // 0: promo.Promo
+	(*CreatePromoRequest)(nil),      // 1: promo.CreatePromoRequest
+	(*GetPromoRequest)(nil),         // 2: promo.GetPromoRequest
+	(*UpdatePromoRequest)(nil),      // 3: promo.UpdatePromoRequest
+	(*DeletePromoRequest)(nil),      // 4: promo.DeletePromoRequest
+	(*ListPromosRequest)(nil),       // 5: promo.ListPromosRequest
+	(*ListPromosResponse)(nil),      // 6: promo.ListPromosResponse
+	(*Comment)(nil),                 // 7: promo.Comment
+	(*AddCommentRequest)(nil),       // 8: promo.AddCommentRequest
+	(*GetCommentRequest)(nil),       // 9: promo.GetCommentRequest
+	(*ListCommentsRequest)(nil),     // 10: promo.ListCommentsRequest
+	(*ListCommentsResponse)(nil),    // 11: promo.ListCommentsResponse
+	(*DeleteCommentRequest)(nil),    // 12: promo.DeleteCommentRequest
+	(*ExportAuthorDataRequest)(nil), // 13: promo.ExportAuthorDataRequest
+	(*AuthorData)(nil),              // 14: promo.AuthorData
+	(*timestamp.Timestamp)(nil),     // 15: google.protobuf.Timestamp
+	(*empty.Empty)(nil),             // 16: google.protobuf.Empty
+}
+var file_proto_promo_promo_proto_depIdxs = []int32{
+	15, // 0: promo.Promo.creation_date:type_name -> google.protobuf.Timestamp
+	15, // 1: promo.Promo.update_date:type_name -> google.protobuf.Timestamp
 	0,  // 2: promo.ListPromosResponse.promos:type_name -> promo.Promo
-	12, // 3: promo.Comment.creation_date:type_name -> google.protobuf.Timestamp
+	15, // 3: promo.Comment.creation_date:type_name -> google.protobuf.Timestamp
 	7,  // 4: promo.ListCommentsResponse.comments:type_name -> promo.Comment
-	1,  // 5: promo.PromoService.CreatePromo:input_type -> promo.CreatePromoRequest
-	2,  // 6: promo.PromoService.GetPromo:input_type -> promo.GetPromoRequest
-	3,  // 7: promo.PromoService.UpdatePromo:input_type -> promo.UpdatePromoRequest
-	4,  // 8: promo.PromoService.DeletePromo:input_type -> promo.DeletePromoRequest
-	5,  // 9: promo.PromoService.ListPromos:input_type -> promo.ListPromosRequest
-	8,  // 10: promo.PromoService.AddComment:input_type -> promo.AddCommentRequest
-	9,  // 11: promo.PromoService.GetComment:input_type -> promo.GetCommentRequest
-	10, // 12: promo.PromoService.ListComments:input_type -> promo.ListCommentsRequest
-	0,  // 13: promo.PromoService.CreatePromo:output_type -> promo.Promo
-	0,  // 14: promo.PromoService.GetPromo:output_type -> promo.Promo
-	0,  // 15: promo.PromoService.UpdatePromo:output_type -> promo.Promo
-	13, // 16: promo.PromoService.DeletePromo:output_type -> google.protobuf.Empty
-	6,  // 17: promo.PromoService.ListPromos:output_type -> promo.ListPromosResponse
-	7,  // 18: promo.PromoService.AddComment:output_type -> promo.Comment
-	7,  // 19: promo.PromoService.GetComment:output_type -> promo.Comment
-	11, // 20: promo.PromoService.ListComments:output_type -> promo.ListCommentsResponse
-	13, // [13:21] is the sub-list for method output_type
-	5,  // [5:13] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
-}
-
-func init() { file_promo_proto_init() }
-func file_promo_proto_init() {
-	if File_promo_proto != nil {
+	0,  // 5: promo.AuthorData.promos:type_name -> promo.Promo
+	7,  // 6: promo.AuthorData.comments:type_name -> promo.Comment
+	1,  // 7: promo.PromoService.CreatePromo:input_type -> promo.CreatePromoRequest
+	2,  // 8: promo.PromoService.GetPromo:input_type -> promo.GetPromoRequest
+	3,  // 9: promo.PromoService.UpdatePromo:input_type -> promo.UpdatePromoRequest
+	4,  // 10: promo.PromoService.DeletePromo:input_type -> promo.DeletePromoRequest
+	5,  // 11: promo.PromoService.ListPromos:input_type -> promo.ListPromosRequest
+	8,  // 12: promo.PromoService.AddComment:input_type -> promo.AddCommentRequest
+	9,  // 13: promo.PromoService.GetComment:input_type -> promo.GetCommentRequest
+	10, // 14: promo.PromoService.ListComments:input_type -> promo.ListCommentsRequest
+	12, // 15: promo.PromoService.DeleteComment:input_type -> promo.DeleteCommentRequest
+	13, // 16: promo.PromoService.ExportAuthorData:input_type -> promo.ExportAuthorDataRequest
+	0,  // 17: promo.PromoService.CreatePromo:output_type -> promo.Promo
+	0,  // 18: promo.PromoService.GetPromo:output_type -> promo.Promo
+	0,  // 19: promo.PromoService.UpdatePromo:output_type -> promo.Promo
+	16, // 20: promo.PromoService.DeletePromo:output_type -> google.protobuf.Empty
+	6,  // 21: promo.PromoService.ListPromos:output_type -> promo.ListPromosResponse
+	7,  // 22: promo.PromoService.AddComment:output_type -> promo.Comment
+	7,  // 23: promo.PromoService.GetComment:output_type -> promo.Comment
+	11, // 24: promo.PromoService.ListComments:output_type -> promo.ListCommentsResponse
+	16, // 25: promo.PromoService.DeleteComment:output_type -> google.protobuf.Empty
+	14, // 26: promo.PromoService.ExportAuthorData:output_type -> promo.AuthorData
+	17, // [17:27] is the sub-list for method output_type
+	7,  // [7:17] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_proto_promo_promo_proto_init() }
+func file_proto_promo_promo_proto_init() {
+	if File_proto_promo_promo_proto != nil {
 		return
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: unsafe.Slice(unsafe.StringData(file_promo_proto_rawDesc), len(file_promo_proto_rawDesc)),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_promo_promo_proto_rawDesc), len(file_proto_promo_promo_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   12,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
-		GoTypes:           file_promo_proto_goTypes,
-		DependencyIndexes: file_promo_proto_depIdxs,
-		MessageInfos:      file_promo_proto_msgTypes,
+		GoTypes:           file_proto_promo_promo_proto_goTypes,
+		DependencyIndexes: file_proto_promo_promo_proto_depIdxs,
+		MessageInfos:      file_proto_promo_promo_proto_msgTypes,
 	}.Build()
-	File_promo_proto = out.File
-	file_promo_proto_goTypes = nil
-	file_promo_proto_depIdxs = nil
+	File_proto_promo_promo_proto = out.File
+	file_proto_promo_promo_proto_goTypes = nil
+	file_proto_promo_promo_proto_depIdxs = nil
 }
@@ -2,7 +2,7 @@
 // versions:
 // - protoc-gen-go-grpc v1.5.1
 // - protoc             v3.12.4
-// source: promo.proto
+// source: proto/promo/promo.proto
 
 package loyalty_service
 
@@ -20,14 +20,16 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	PromoService_CreatePromo_FullMethodName  = "/promo.PromoService/CreatePromo"
-	PromoService_GetPromo_FullMethodName     = "/promo.PromoService/GetPromo"
-	PromoService_UpdatePromo_FullMethodName  = "/promo.PromoService/UpdatePromo"
-	PromoService_DeletePromo_FullMethodName  = "/promo.PromoService/DeletePromo"
-	PromoService_ListPromos_FullMethodName   = "/promo.PromoService/ListPromos"
-	PromoService_AddComment_FullMethodName   = "/promo.PromoService/AddComment"
-	PromoService_GetComment_FullMethodName   = "/promo.PromoService/GetComment"
-	PromoService_ListComments_FullMethodName = "/promo.PromoService/ListComments"
+	PromoService_CreatePromo_FullMethodName      = "/promo.PromoService/CreatePromo"
+	PromoService_GetPromo_FullMethodName         = "/promo.PromoService/GetPromo"
+	PromoService_UpdatePromo_FullMethodName      = "/promo.PromoService/UpdatePromo"
+	PromoService_DeletePromo_FullMethodName      = "/promo.PromoService/DeletePromo"
+	PromoService_ListPromos_FullMethodName       = "/promo.PromoService/ListPromos"
+	PromoService_AddComment_FullMethodName       = "/promo.PromoService/AddComment"
+	PromoService_GetComment_FullMethodName       = "/promo.PromoService/GetComment"
+	PromoService_ListComments_FullMethodName     = "/promo.PromoService/ListComments"
+	PromoService_DeleteComment_FullMethodName    = "/promo.PromoService/DeleteComment"
+	PromoService_ExportAuthorData_FullMethodName = "/promo.PromoService/ExportAuthorData"
 )
 
 // PromoServiceClient is the client API for PromoService service.
@@ -42,6 +44,8 @@ type PromoServiceClient interface {
 	AddComment(ctx context.Context, in *AddCommentRequest, opts ...grpc.CallOption) (*Comment, error)
 	GetComment(ctx context.Context, in *GetCommentRequest, opts ...grpc.CallOption) (*Comment, error)
 	ListComments(ctx context.Context, in *ListCommentsRequest, opts ...grpc.CallOption) (*ListCommentsResponse, error)
+	DeleteComment(ctx context.Context, in *DeleteCommentRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	ExportAuthorData(ctx context.Context, in *ExportAuthorDataRequest, opts ...grpc.CallOption) (*AuthorData, error)
 }
 
 type promoServiceClient struct {
@@ -132,6 +136,26 @@ func (c *promoServiceClient) ListComments(ctx context.Context, in *ListCommentsR
 	return out, nil
 }
 
+func (c *promoServiceClient) DeleteComment(ctx context.Context, in *DeleteCommentRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, PromoService_DeleteComment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *promoServiceClient) ExportAuthorData(ctx context.Context, in *ExportAuthorDataRequest, opts ...grpc.CallOption) (*AuthorData, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AuthorData)
+	err := c.cc.Invoke(ctx, PromoService_ExportAuthorData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PromoServiceServer is the server API for PromoService service.
 // All implementations must embed UnimplementedPromoServiceServer
 // for forward compatibility.
@@ -144,6 +168,8 @@ type PromoServiceServer interface {
 	AddComment(context.Context, *AddCommentRequest) (*Comment, error)
 	GetComment(context.Context, *GetCommentRequest) (*Comment, error)
 	ListComments(context.Context, *ListCommentsRequest) (*ListCommentsResponse, error)
+	DeleteComment(context.Context, *DeleteCommentRequest) (*empty.Empty, error)
+	ExportAuthorData(context.Context, *ExportAuthorDataRequest) (*AuthorData, error)
 	mustEmbedUnimplementedPromoServiceServer()
 }
 
@@ -178,6 +204,12 @@ func (UnimplementedPromoServiceServer) GetComment(context.Context, *GetCommentRe
 func (UnimplementedPromoServiceServer) ListComments(context.Context, *ListCommentsRequest) (*ListCommentsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListComments not implemented")
 }
+func (UnimplementedPromoServiceServer) DeleteComment(context.Context, *DeleteCommentRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteComment not implemented")
+}
+func (UnimplementedPromoServiceServer) ExportAuthorData(context.Context, *ExportAuthorDataRequest) (*AuthorData, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportAuthorData not implemented")
+}
 func (UnimplementedPromoServiceServer) mustEmbedUnimplementedPromoServiceServer() {}
 func (UnimplementedPromoServiceServer) testEmbeddedByValue()                      {}
 
@@ -343,6 +375,42 @@ func _PromoService_ListComments_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PromoService_DeleteComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PromoServiceServer).DeleteComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PromoService_DeleteComment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PromoServiceServer).DeleteComment(ctx, req.(*DeleteCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PromoService_ExportAuthorData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportAuthorDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PromoServiceServer).ExportAuthorData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PromoService_ExportAuthorData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PromoServiceServer).ExportAuthorData(ctx, req.(*ExportAuthorDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PromoService_ServiceDesc is the grpc.ServiceDesc for PromoService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -382,7 +450,15 @@ var PromoService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListComments",
 			Handler:    _PromoService_ListComments_Handler,
 		},
+		{
+			MethodName: "DeleteComment",
+			Handler:    _PromoService_DeleteComment_Handler,
+		},
+		{
+			MethodName: "ExportAuthorData",
+			Handler:    _PromoService_ExportAuthorData_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
-	Metadata: "promo.proto",
+	Metadata: "proto/promo/promo.proto",
 }
@@ -0,0 +1,162 @@
+// Package accesspolicy maps roles to permissions for the gateway and the
+// backend services, which check the same rules on both sides.
+package accesspolicy
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type Role string
+
+const (
+	Customer      Role = "customer"
+	CompanyMember Role = "company_member"
+	CompanyAdmin  Role = "company_admin"
+	PlatformAdmin Role = "platform_admin"
+)
+
+type Permission string
+
+const (
+	PromosWrite      Permission = "promos:write"
+	PromosModerate   Permission = "promos:moderate"
+	CommentsWrite    Permission = "comments:write"
+	CommentsModerate Permission = "comments:moderate"
+	UsersManage      Permission = "users:manage"
+	StatsRead        Permission = "stats:read"
+)
+
+// Members run the promos of their organisation, its statistics are only shown
+// to admins.
+var rolePermissions = map[Role][]Permission{
+	Customer:      {CommentsWrite},
+	CompanyMember: {PromosWrite, CommentsWrite},
+	CompanyAdmin:  {PromosWrite, CommentsWrite, StatsRead},
+	PlatformAdmin: {PromosModerate, CommentsWrite, CommentsModerate, UsersManage},
+}
+
+// The gateway forwards the credentials of the caller in these metadata keys
+// and backend services resolve them through the auth service, so a forged
+// caller is rejected even if a service is reachable directly.
+const (
+	AuthorizationMetadataKey = "authorization"
+	APIKeyMetadataKey        = "x-api-key"
+)
+
+const bearerPrefix = "Bearer "
+
+// Credentials hold either the JWT or the API key of a caller.
+type Credentials struct {
+	JWT    string
+	APIKey string
+}
+
+// Principal is the authenticated caller. Scopes is nil unless the caller used
+// an API key, which is then limited to them.
+type Principal struct {
+	UserID                 string
+	Role                   Role
+	OrganisationID         string
+	EmailVerified          bool
+	TwoFactorSetupRequired bool
+	Scopes                 []Permission
+}
+
+// Authenticator resolves forwarded credentials, it fails with
+// codes.Unauthenticated if they are not valid.
+type Authenticator interface {
+	Authenticate(ctx context.Context, credentials Credentials) (Principal, error)
+}
+
+type principalContextKey struct{}
+
+func Allows(role Role, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func AllowsAny(role Role, permissions ...Permission) bool {
+	for _, permission := range permissions {
+		if Allows(role, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+func IsCompanyRole(role Role) bool {
+	return role == CompanyMember || role == CompanyAdmin
+}
+
+func (p Principal) Can(permission Permission) bool {
+	return Allows(p.Role, permission) && (p.Scopes == nil || slices.Contains(p.Scopes, permission))
+}
+
+func AppendCredentials(ctx context.Context, credentials Credentials) context.Context {
+	switch {
+	case credentials.APIKey != "":
+		return metadata.AppendToOutgoingContext(ctx, APIKeyMetadataKey, credentials.APIKey)
+	case credentials.JWT != "":
+		return metadata.AppendToOutgoingContext(ctx, AuthorizationMetadataKey, bearerPrefix+credentials.JWT)
+	}
+	return ctx
+}
+
+func credentialsFromContext(ctx context.Context) (Credentials, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return Credentials{}, false
+	}
+	if keys := md.Get(APIKeyMetadataKey); len(keys) > 0 && keys[0] != "" {
+		return Credentials{APIKey: keys[0]}, true
+	}
+	if values := md.Get(AuthorizationMetadataKey); len(values) > 0 && strings.HasPrefix(values[0], bearerPrefix) {
+		return Credentials{JWT: strings.TrimPrefix(values[0], bearerPrefix)}, true
+	}
+	return Credentials{}, false
+}
+
+// UnaryServerInterceptor authenticates the forwarded credentials before the
+// handler runs. Calls without credentials go through anonymously, handlers
+// that need a caller use Require.
+func UnaryServerInterceptor(authenticator Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		credentials, ok := credentialsFromContext(ctx)
+		if !ok {
+			return handler(ctx, req)
+		}
+		principal, err := authenticator.Authenticate(ctx, credentials)
+		if err != nil {
+			return nil, err
+		}
+		return handler(context.WithValue(ctx, principalContextKey{}, principal), req)
+	}
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+	return principal, ok
+}
+
+// Require returns the caller if it has any of the permissions.
+func Require(ctx context.Context, permissions ...Permission) (Principal, error) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return Principal{}, status.Error(codes.Unauthenticated, "missing caller identity")
+	}
+	if !slices.ContainsFunc(permissions, principal.Can) {
+		return Principal{}, status.Errorf(codes.PermissionDenied, "role %q is not allowed to do this", principal.Role)
+	}
+	return principal, nil
+}
@@ -0,0 +1,139 @@
+// Package configloader fills the configuration structs of every service from
+// their defaults, an optional YAML file and the environment.
+package configloader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+// Config is a configuration struct that checks its own values.
+type Config interface {
+	Validate() error
+}
+
+// Load starts from defaults, applies the YAML file at path unless path is
+// empty, then the environment, and validates the result.
+func Load[T Config](path string, defaults T) (T, error) {
+	var zero T
+	cfg := defaults
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return zero, err
+		}
+		defer file.Close()
+		decoder := yaml.NewDecoder(file)
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return zero, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(&cfg).Elem(), ""); err != nil {
+		return zero, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return zero, err
+	}
+	return cfg, nil
+}
+
+// applyEnv prefixes the variables of a nested struct with the env tag of its
+// field, so a struct type can be reused for several sections.
+func applyEnv(v reflect.Value, prefix string) error {
+	for i := 0; i < v.NumField(); i++ {
+		field, fieldType := v.Field(i), v.Type().Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, prefix+fieldType.Tag.Get("env")); err != nil {
+				return err
+			}
+			continue
+		}
+		name := fieldType.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		name = prefix + name
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case []string:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// Redacted returns a copy of cfg with the secret fields that are set replaced.
+func Redacted[T Config](cfg T) T {
+	redact(reflect.ValueOf(&cfg).Elem())
+	return cfg
+}
+
+func redact(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			redact(field)
+		} else if v.Type().Field(i).Tag.Get("secret") == "true" && field.String() != "" {
+			field.SetString(redacted)
+		}
+	}
+}
+
+// Print writes the redacted configuration as YAML that Load accepts back.
+func Print[T Config](w io.Writer, cfg T) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(Redacted(cfg)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
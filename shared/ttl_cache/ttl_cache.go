@@ -0,0 +1,76 @@
+// Package ttlcache keeps answers of the auth service for as long as it allows
+// them to be reused, so the gateway and the backend services do not ask it
+// again on every request.
+package ttlcache
+
+import (
+	"sync"
+	"time"
+)
+
+type entry[V any] struct {
+	value       V
+	cachedUntil time.Time
+}
+
+// Cache holds up to maxSize values. Every Forget bumps the generation, so a
+// value looked up while it ran is not put back by Put.
+type Cache[V any] struct {
+	entries    map[string]entry[V]
+	maxSize    int
+	generation uint64
+	mx         sync.Mutex
+}
+
+func New[V any](maxSize int) *Cache[V] {
+	return &Cache[V]{entries: make(map[string]entry[V]), maxSize: maxSize}
+}
+
+// Get returns the value of key unless it expired, along with the generation
+// to pass to Put once a missing value is looked up.
+func (c *Cache[V]) Get(key string, now time.Time) (V, uint64, bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	cached, ok := c.entries[key]
+	if ok && !now.Before(cached.cachedUntil) {
+		delete(c.entries, key)
+		var zero V
+		return zero, c.generation, false
+	}
+	return cached.value, c.generation, ok
+}
+
+// Put keeps value for ttl, it does nothing for a ttl that is not positive.
+func (c *Cache[V]) Put(generation uint64, key string, value V, ttl time.Duration, now time.Time) {
+	if ttl <= 0 {
+		return
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if len(c.entries) >= c.maxSize {
+		for key, cached := range c.entries {
+			if !now.Before(cached.cachedUntil) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= c.maxSize {
+			clear(c.entries)
+		}
+	}
+	c.entries[key] = entry[V]{value: value, cachedUntil: now.Add(ttl)}
+}
+
+// Forget drops the values that match.
+func (c *Cache[V]) Forget(match func(key string, value V) bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.generation++
+	for key, cached := range c.entries {
+		if match(key, cached.value) {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package ttlcache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheExpires(t *testing.T) {
+	cache := New[string](10)
+	now := time.Unix(1700000000, 0)
+	_, generation, ok := cache.Get("token", now)
+	if ok {
+		t.Fatal("empty cache returned a value")
+	}
+	cache.Put(generation, "token", "answer", time.Second*10, now)
+	if value, _, ok := cache.Get("token", now.Add(time.Second*9)); !ok || value != "answer" {
+		t.Fatalf("got %q, %v before the TTL, want the cached answer", value, ok)
+	}
+	if _, _, ok := cache.Get("token", now.Add(time.Second*10)); ok {
+		t.Fatal("value is returned after the TTL")
+	}
+	cache.Put(generation, "uncacheable", "answer", 0, now)
+	if _, _, ok := cache.Get("uncacheable", now); ok {
+		t.Fatal("value with no TTL was cached")
+	}
+}
+
+func TestCacheForgetDropsRacingPut(t *testing.T) {
+	cache := New[string](10)
+	now := time.Unix(1700000000, 0)
+	_, generation, _ := cache.Get("token", now)
+	cache.Put(generation, "token", "user", time.Minute, now)
+	_, generation, _ = cache.Get("other", now)
+	cache.Forget(func(_ string, value string) bool { return value == "user" })
+	if _, _, ok := cache.Get("token", now); ok {
+		t.Fatal("forgotten value is still cached")
+	}
+	// Looked up before Forget, the answer may predate the revocation.
+	cache.Put(generation, "other", "user", time.Minute, now)
+	if _, _, ok := cache.Get("other", now); ok {
+		t.Fatal("value looked up before Forget was cached")
+	}
+}
+
+func TestCacheBoundedSize(t *testing.T) {
+	cache := New[int](2)
+	now := time.Unix(1700000000, 0)
+	cache.Put(0, "a", 1, time.Second, now)
+	cache.Put(0, "b", 2, time.Minute, now)
+	cache.Put(0, "c", 3, time.Minute, now.Add(time.Second))
+	if _, _, ok := cache.Get("a", now); ok {
+		t.Fatal("expired value was not evicted")
+	}
+	if _, _, ok := cache.Get("b", now); !ok {
+		t.Fatal("live value was evicted while an expired one could go")
+	}
+	cache.Put(0, "d", 4, time.Minute, now)
+	if len(cache.entries) > 2 {
+		t.Fatalf("cache holds %d values, want at most 2", len(cache.entries))
+	}
+}
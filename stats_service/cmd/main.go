@@ -2,29 +2,128 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	accesspolicy "shared/access_policy"
+	eventstore "statsservice/event_store"
+	protoauth "statsservice/proto/auth"
+	statsconfig "statsservice/stats_config"
+	"strings"
+	"time"
 
 	"github.com/segmentio/kafka-go"
+	"google.golang.org/grpc"
 )
 
+const bearerPrefix = "Bearer "
+
+type statsServer struct {
+	store      *eventstore.Store
+	authClient protoauth.AuthServiceClient
+}
+
+// authorize lets users read their own statistics and platform admins read
+// anyone's. The JWT is introspected so revoked tokens and suspended users are
+// refused.
+func (s *statsServer) authorize(r *http.Request, userID string) (int, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return http.StatusUnauthorized, fmt.Errorf("missing bearer token")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+	introspection, err := s.authClient.IntrospectToken(ctx, &protoauth.IntrospectTokenRequest{Jwt: strings.TrimPrefix(header, bearerPrefix)})
+	if err != nil {
+		return http.StatusServiceUnavailable, fmt.Errorf("failed to introspect token: %v", err)
+	}
+	if !introspection.Active {
+		return http.StatusUnauthorized, fmt.Errorf("token is not active")
+	}
+	if introspection.UserId != userID && !accesspolicy.Allows(accesspolicy.Role(introspection.Role), accesspolicy.UsersManage) {
+		return http.StatusForbidden, fmt.Errorf("statistics of other users are not readable")
+	}
+	return http.StatusOK, nil
+}
+
+func (s *statsServer) userStatsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+	if code, err := s.authorize(r, userID); err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.store.UserEvents(userID)); err != nil {
+		log.Printf("Failed to encode stats: %v", err)
+	}
+}
+
 func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML config file, overridden by the environment")
+	printConfig := flag.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+	flag.Parse()
+	cfg, err := statsconfig.Load(*configPath)
+	if err != nil {
+		log.Fatal("Invalid config: ", err)
+	}
+	if *printConfig {
+		if err := statsconfig.Print(os.Stdout, cfg); err != nil {
+			log.Fatal("Failed to print config: ", err)
+		}
+		return
+	}
+
+	store, err := eventstore.Open(cfg.DataFile)
+	if err != nil {
+		log.Fatal("Failed to load events: ", err)
+	}
+	defer store.Close()
+
+	connAuth, err := grpc.Dial(cfg.AuthServiceAddress, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		log.Fatal("Failed to connect to auth service: ", err)
+	}
+	defer connAuth.Close()
+	server := &statsServer{store: store, authClient: protoauth.NewAuthServiceClient(connAuth)}
+
 	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:   []string{"kafka:9092"},
-		Topic:     "stats",
-		GroupID:   "stats-consumer-group",
-		Partition: 0,
-		MinBytes:  10e3,
-		MaxBytes:  10e6,
+		Brokers:  cfg.Kafka.Brokers,
+		Topic:    cfg.Kafka.Topic,
+		GroupID:  cfg.Kafka.GroupID,
+		MinBytes: 10e3,
+		MaxBytes: 10e6,
 	})
+	defer reader.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/stats/user/{id}", server.userStatsHandler)
+	go func() {
+		log.Fatal(http.ListenAndServe(cfg.ListenAddress, mux))
+	}()
 
 	log.Println("Stats service started. Listening for messages...")
 
+	ctx := context.Background()
 	for {
-		msg, err := reader.ReadMessage(context.Background())
+		msg, err := reader.FetchMessage(ctx)
 		if err != nil {
 			log.Printf("Could not read message: %v", err)
 			continue
 		}
 		log.Printf("Received: %s", string(msg.Value))
+		var e eventstore.Event
+		if err := json.Unmarshal(msg.Value, &e); err != nil {
+			log.Printf("Could not decode message: %v", err)
+		} else if err := store.Add(e); err != nil {
+			// The offset is not committed, the event is read again after a
+			// restart.
+			log.Fatal("Failed to store event: ", err)
+		}
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			log.Printf("Could not commit message: %v", err)
+		}
 	}
 }
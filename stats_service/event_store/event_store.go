@@ -0,0 +1,145 @@
+// Package eventstore keeps the stats events by user in memory, backed by a
+// JSON lines file so the service does not have to replay the topic on start.
+package eventstore
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const AccountDeletedEvent = "account_deleted"
+
+type Event struct {
+	EventType string `json:"event_type"`
+	UserID    string `json:"user_id"`
+	ObjectID  string `json:"object_id"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Store appends every event to its file. An account_deleted event drops the
+// events of the user and rewrites the file without them, so the data of a
+// deleted account does not survive a restart.
+type Store struct {
+	path   string
+	file   *os.File
+	byUser map[string][]Event
+	mx     sync.RWMutex
+}
+
+// Open loads the events kept in the file at path, creating it if missing.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, byUser: make(map[string][]Event)}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	s.file = file
+	return s, nil
+}
+
+func (s *Store) load() error {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open event file: %w", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("failed to decode event file: %w", err)
+		}
+		s.byUser[e.UserID] = append(s.byUser[e.UserID], e)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read event file: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) Close() error {
+	return s.file.Close()
+}
+
+// Add stores the event and returns once it is on disk.
+func (s *Store) Add(e Event) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if e.EventType == AccountDeletedEvent {
+		if _, ok := s.byUser[e.UserID]; !ok {
+			return nil
+		}
+		delete(s.byUser, e.UserID)
+		return s.rewrite()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync event file: %w", err)
+	}
+	s.byUser[e.UserID] = append(s.byUser[e.UserID], e)
+	return nil
+}
+
+// rewrite replaces the file with the events in memory through a temporary
+// file, so a crash leaves either the old or the new file.
+func (s *Store) rewrite() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create event file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, events := range s.byUser {
+		for _, e := range events {
+			if err := encoder.Encode(e); err != nil {
+				tmp.Close()
+				return err
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write event file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync event file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write event file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace event file: %w", err)
+	}
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open event file: %w", err)
+	}
+	s.file.Close()
+	s.file = file
+	return nil
+}
+
+func (s *Store) UserEvents(userID string) []Event {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return append([]Event{}, s.byUser[userID]...)
+}
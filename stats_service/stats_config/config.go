@@ -0,0 +1,74 @@
+package statsconfig
+
+import (
+	"errors"
+	"io"
+	configloader "shared/config_loader"
+)
+
+// Config is the effective configuration of the stats service. Every field can
+// be set in the YAML file under its yaml key and overridden by the environment
+// variable in its env tag.
+type Config struct {
+	ListenAddress      string `yaml:"listen_address" env:"LISTEN_ADDRESS"`
+	AuthServiceAddress string `yaml:"auth_service_address" env:"AUTH_SERVICE_ADDRESS"`
+	DataFile           string `yaml:"data_file" env:"STATS_DATA_FILE"`
+	Kafka              Kafka  `yaml:"kafka"`
+}
+
+// Kafka offsets are committed once an event is in DataFile, so the consumer
+// group resumes where the file ends.
+type Kafka struct {
+	Brokers []string `yaml:"brokers" env:"KAFKA_BROKERS"`
+	Topic   string   `yaml:"topic" env:"KAFKA_STATS_TOPIC"`
+	GroupID string   `yaml:"group_id" env:"KAFKA_GROUP_ID"`
+}
+
+// Load starts from Default, applies the YAML file at path unless path is
+// empty, then the environment, and validates the result.
+func Load(path string) (Config, error) {
+	return configloader.Load(path, Default())
+}
+
+// Print writes the redacted configuration as YAML that Load accepts back.
+func Print(w io.Writer, c Config) error {
+	return configloader.Print(w, c)
+}
+
+// Default matches the docker-compose setup.
+func Default() Config {
+	return Config{
+		ListenAddress:      ":8085",
+		AuthServiceAddress: "auth-service:8080",
+		DataFile:           "/data/stats_events.jsonl",
+		Kafka: Kafka{
+			Brokers: []string{"kafka:9092"},
+			Topic:   "stats",
+			GroupID: "stats-consumer-group",
+		},
+	}
+}
+
+// Validate reports every invalid value at once.
+func (c Config) Validate() error {
+	var errs []error
+	if c.ListenAddress == "" {
+		errs = append(errs, errors.New("listen_address is empty"))
+	}
+	if c.AuthServiceAddress == "" {
+		errs = append(errs, errors.New("auth_service_address is empty"))
+	}
+	if c.DataFile == "" {
+		errs = append(errs, errors.New("data_file is empty"))
+	}
+	if len(c.Kafka.Brokers) == 0 {
+		errs = append(errs, errors.New("kafka.brokers is empty"))
+	}
+	if c.Kafka.Topic == "" {
+		errs = append(errs, errors.New("kafka.topic is empty"))
+	}
+	if c.Kafka.GroupID == "" {
+		errs = append(errs, errors.New("kafka.group_id is empty"))
+	}
+	return errors.Join(errs...)
+}